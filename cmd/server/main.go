@@ -14,6 +14,7 @@ import (
 	"github.com/mwombeki6/mock-sims/internal/database"
 	"github.com/mwombeki6/mock-sims/internal/handlers"
 	"github.com/mwombeki6/mock-sims/internal/middleware"
+	"github.com/mwombeki6/mock-sims/internal/services"
 )
 
 // @title Mock SIMS API
@@ -68,7 +69,7 @@ func main() {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: cfg.AllowedOrigins,
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, Last-Event-ID",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
@@ -97,31 +98,168 @@ func main() {
 	app.Post("/oauth/authorize", h.OAuth.Authorize)
 	app.Post("/oauth/token", h.OAuth.Token)
 
+	// Webhook event catalogue (public so LMS developers can fetch schemas)
+	app.Get("/webhooks/events", h.Webhook.ListEvents)
+	app.Get("/webhooks/events/:type", h.Webhook.GetEvent)
+
+	// Calendar feeds (feed token in the query, since calendar apps cannot send bearer tokens)
+	app.Get("/calendar/students/:id.ics", h.Calendar.Feed(services.FeedStudent))
+	app.Get("/calendar/faculty/:id.ics", h.Calendar.Feed(services.FeedFaculty))
+	app.Get("/calendar/venues/:id.ics", h.Calendar.Feed(services.FeedVenue))
+	app.Get("/calendar/courses/:code.ics", h.Calendar.Feed(services.FeedCourse))
+
+	// Live event streams may carry the token in the query; no other route accepts it there
+	app.Use("/api/events/stream", middleware.StreamTokenQuery())
+	app.Use("/api/events/ws", middleware.StreamTokenQuery())
+
 	// API routes (protected)
 	api := app.Group("/api", middleware.AuthMiddleware(db, cfg))
 
+	adminOnly := middleware.RequireUserType("admin")
+
+	// Routes open to client-credential tokens (LMS integrations) are registered first; RejectClients
+	// keeps clients off every route registered after it
+	// Change feed (polling alternative to webhooks) and live event streams (admins and client-credential tokens only)
+	streamAccess := middleware.RequireUserType("admin", "client")
+	api.Get("/changes", streamAccess, h.Change.List)
+	api.Get("/events/stream", streamAccess, h.Stream.SSE)
+	api.Get("/events/ws", streamAccess, h.Stream.UpgradeWebSocket, h.Stream.WebSocket())
+
+	// Lecture attendance (clients need the read_attendance or write_attendance scope)
+	api.Post("/lectures/:id/attendance", h.Attendance.Submit)
+	api.Get("/lectures/:id/attendance", h.Attendance.GetLectureSessions)
+	api.Get("/attendance-sessions/:id", h.Attendance.GetSession)
+	api.Get("/offerings/:id/attendance", h.Attendance.GetOfferingReport)
+
+	// LMS grade passback (clients need the write_grades scope)
+	api.Get("/offerings/:id/components", h.Assessment.GetComponents)
+	api.Post("/assessment-components/:id/scores", h.Assessment.RecordScores)
+
+	// Everything below serves users only
+	api.Use(middleware.RejectClients())
+
 	// Student endpoints
+	api.Post("/students", adminOnly, h.Student.Create)
 	api.Get("/students/me", h.Student.GetMe)
+	studentOnly := middleware.RequireUserType("student")
+	api.Get("/students/me/registration", studentOnly, h.Registration.GetAvailableCourses)
+	api.Post("/students/me/registrations", studentOnly, h.Registration.Register)
+	api.Delete("/students/me/registrations/:code", studentOnly, h.Registration.Drop)
+	api.Get("/students/me/waitlist", studentOnly, h.Registration.GetWaitlist)
+	api.Delete("/students/me/waitlist/:code", studentOnly, h.Registration.LeaveWaitlist)
 	api.Get("/students/:id/courses", h.Student.GetCourses)
 	api.Get("/students/:id/grades", h.Student.GetGrades)
 	api.Get("/students/:id/timetable", h.Student.GetTimetable)
+	api.Get("/students/:id/academic-record", h.Student.GetAcademicRecord)
+	api.Get("/students/:id/attendance", h.Attendance.GetStudentAttendance)
+	api.Get("/students/:id/exam-timetable", h.Exam.GetStudentTimetable)
+	api.Put("/students/:id", adminOnly, h.Student.Update)
+	api.Put("/students/:id/status", adminOnly, h.Student.UpdateStatus)
+	api.Delete("/students/:id", adminOnly, h.Student.Delete)
 
 	// Faculty endpoints
 	api.Get("/faculty/me", h.Faculty.GetMe)
 	api.Get("/faculty/:id/courses", h.Faculty.GetCourses)
 	api.Post("/faculty/courses/:id/ca-marks", h.Faculty.SubmitCAMarks)
+	api.Post("/faculty/courses/:id/final-exam", h.Faculty.SubmitFinalExam)
 
 	// Course endpoints
 	api.Get("/courses", h.Course.List)
+	api.Post("/courses", adminOnly, h.Course.Create)
 	api.Get("/courses/:code", h.Course.Get)
 	api.Get("/courses/:code/lectures", h.Course.GetLectures)
 	api.Get("/courses/:code/students", h.Course.GetStudents)
+	api.Get("/courses/:code/seats", h.Course.GetSeats)
+	api.Get("/courses/:code/waitlist", adminOnly, h.Course.GetWaitlist)
+	api.Put("/courses/:code/capacity", adminOnly, h.Course.SetCapacity)
+	api.Put("/courses/:code", adminOnly, h.Course.Update)
+	api.Delete("/courses/:code", adminOnly, h.Course.Delete)
+	api.Post("/courses/:code/prerequisites", adminOnly, h.Course.AddPrerequisite)
+	api.Delete("/courses/:code/prerequisites/:id", adminOnly, h.Course.RemovePrerequisite)
+	api.Post("/lectures", adminOnly, h.Course.CreateLecture)
+	api.Put("/lectures/:id", adminOnly, h.Course.RescheduleLecture)
+	api.Post("/course-assignments", adminOnly, h.Course.AssignLecturer)
+	api.Delete("/course-assignments/:id", adminOnly, h.Course.RemoveAssignment)
+
+	// Course offerings and sections
+	api.Get("/offerings", h.Offering.List)
+	api.Get("/offerings/:id", h.Offering.Get)
+	api.Post("/offerings/:id/sections", adminOnly, h.Offering.CreateSection)
+	api.Put("/sections/:id", adminOnly, h.Offering.UpdateSection)
+	api.Delete("/sections/:id", adminOnly, h.Offering.DeleteSection)
+	api.Get("/sections/:id/students", h.Offering.GetSectionStudents)
+	api.Put("/enrollments/:id/section", adminOnly, h.Offering.MoveEnrollment)
+
+	// Assessment components and scores
+	api.Put("/offerings/:id/components", h.Assessment.SetComponents)
+	api.Get("/offerings/:id/scores", h.Assessment.GetScores)
+	api.Get("/offerings/:id/marks-template", h.Assessment.DownloadMarksTemplate)
+	api.Post("/offerings/:id/marks-upload", h.Assessment.UploadMarks)
+
+	// Results moderation (lecturer → HOD → Dean → Senate)
+	api.Get("/offerings/:id/results-workflow", h.Results.Get)
+	api.Post("/offerings/:id/results-workflow/:action", h.Results.Transition)
+
+	// Grade change requests and history
+	api.Get("/grades/:id/history", h.Grade.GetHistory)
+	api.Get("/grades/:id/change-requests", h.Grade.GetChangeRequests)
+	api.Post("/grades/:id/change-requests", h.Grade.RequestChange)
+	api.Get("/grade-change-requests", h.Grade.ListChangeRequests)
+	api.Post("/grade-change-requests/:id/approve", h.Grade.ApproveChange)
+	api.Post("/grade-change-requests/:id/reject", h.Grade.RejectChange)
+
+	// Examination timetable and seating
+	api.Get("/offerings/:id/exam-sessions", h.Exam.ListSessions)
+	api.Post("/offerings/:id/exam-sessions", adminOnly, h.Exam.CreateSession)
+	api.Get("/exam-sessions/:id", h.Exam.GetSession)
+	api.Put("/exam-sessions/:id", adminOnly, h.Exam.UpdateSession)
+	api.Post("/exam-sessions/:id/allocate", adminOnly, h.Exam.AllocateSeats)
+	api.Delete("/exam-sessions/:id", adminOnly, h.Exam.DeleteSession)
+	api.Get("/semesters/:id/exam-clashes", adminOnly, h.Exam.GetClashes)
+
+	// Lecture timetable generation and audit
+	api.Post("/semesters/:id/timetable/generate", adminOnly, h.Timetable.Generate)
+	api.Get("/semesters/:id/timetable/conflicts", adminOnly, h.Timetable.GetConflicts)
+
+	// Calendar feed tokens and holidays
+	api.Get("/calendar/token", h.Calendar.GetFeeds)
+	api.Post("/calendar/token", h.Calendar.RotateToken)
+	api.Delete("/calendar/token", h.Calendar.RevokeToken)
+	api.Get("/holidays", h.Calendar.ListHolidays)
+	api.Post("/holidays", adminOnly, h.Calendar.CreateHoliday)
+	api.Delete("/holidays/:id", adminOnly, h.Calendar.DeleteHoliday)
+
+	// Grading schemes
+	api.Get("/grading-schemes", h.Grading.List)
+	api.Get("/grading-schemes/:code", h.Grading.Get)
+	api.Put("/grading-schemes/:code", adminOnly, h.Grading.Save)
+	api.Get("/programs/:code/grading-scheme", h.Grading.GetForProgram)
 
 	// Admin endpoints
 	api.Get("/colleges", h.Admin.GetColleges)
 	api.Get("/departments", h.Admin.GetDepartments)
+	api.Put("/departments/:code/head", adminOnly, h.Admin.SetDepartmentHead)
+	api.Put("/colleges/:code/dean", adminOnly, h.Admin.SetCollegeDean)
 	api.Get("/programs", h.Admin.GetPrograms)
+	api.Get("/programs/:code/curriculum", h.Admin.GetCurriculum)
+	api.Put("/programs/:code", adminOnly, h.Admin.UpdateProgram)
+	api.Post("/semesters/:id/activate", adminOnly, h.Admin.ActivateSemester)
+	api.Put("/semesters/:id/registration-window", adminOnly, h.Registration.SetRegistrationWindow)
+	api.Post("/semesters/:id/results/compute", adminOnly, h.Admin.ComputeResults)
+	api.Post("/payments", adminOnly, h.Admin.RecordPayment)
+	api.Delete("/payments/:id", adminOnly, h.Admin.DeletePayment)
 	api.Post("/enrollments", h.Admin.CreateEnrollments)
+	api.Delete("/enrollments/:id", adminOnly, h.Admin.DeleteEnrollment)
+
+	// Webhook subscriptions (admin)
+	api.Get("/webhooks/subscriptions", adminOnly, h.Webhook.ListSubscriptions)
+	api.Post("/webhooks/subscriptions", adminOnly, h.Webhook.CreateSubscription)
+	api.Delete("/webhooks/subscriptions/:id", adminOnly, h.Webhook.DeleteSubscription)
+	api.Post("/webhooks/subscriptions/:id/test", adminOnly, h.Webhook.TestSubscription)
+
+	// Retry failed webhook deliveries in the background
+	stopRetries := make(chan struct{})
+	services.NewWebhookService(db, cfg).StartRetryWorker(stopRetries)
 
 	// Start server
 	log.Printf("🚀 Mock SIMS starting on %s:%s", cfg.Host, cfg.Port)
@@ -139,6 +277,7 @@ func main() {
 	<-quit
 
 	log.Println("🛑 Shutting down Mock SIMS...")
+	close(stopRetries)
 	if err := app.Shutdown(); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
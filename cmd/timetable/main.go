@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/database"
+	"github.com/mwombeki6/mock-sims/internal/services"
+)
+
+// timetable generates a semester's lecture timetable from the command line, or with -check audits
+// the current one for conflicts. It exits with status 1 when some lectures cannot be placed or
+// conflicts are found.
+func main() {
+	semester := flag.Uint("semester", 0, "semester ID (default: the current semester)")
+	apply := flag.Bool("apply", false, "write the timetable to the semester's lectures")
+	partial := flag.Bool("partial", false, "with -apply, apply even when some lectures cannot be placed")
+	perWeek := flag.Int("lectures-per-week", 0, "lectures per course section (default: keep each section's current count)")
+	weights := flag.String("weights", "", "soft constraint weights overriding TIMETABLE_SOFT_CONSTRAINTS, e.g. same_day:10,late_slot:0")
+	check := flag.Bool("check", false, "audit the semester's lectures for conflicts instead of generating a timetable")
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+
+	options := services.TimetableOptions{
+		Apply:           *apply,
+		Partial:         *partial,
+		LecturesPerWeek: *perWeek,
+	}
+	if *weights != "" {
+		options.Weights = map[string]float64{}
+		for _, entry := range strings.Split(*weights, ",") {
+			name, value, ok := strings.Cut(entry, ":")
+			weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if !ok || err != nil {
+				log.Fatalf("Invalid weight %q; use constraint:weight", entry)
+			}
+			options.Weights[strings.TrimSpace(name)] = weight
+		}
+	}
+
+	cfg := config.Load()
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	timetable := services.NewTimetableService(db, cfg)
+
+	if *check {
+		audit, err := timetable.Conflicts(uint(*semester))
+		if err != nil {
+			log.Fatalf("Failed to audit timetable: %v", err)
+		}
+		if *asJSON {
+			printJSON(audit)
+		} else {
+			printAudit(audit)
+		}
+		if len(audit.Conflicts) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := timetable.Generate(uint(*semester), options)
+	if err != nil {
+		log.Fatalf("Failed to generate timetable: %v", err)
+	}
+
+	if *asJSON {
+		printJSON(result)
+	} else {
+		printResult(result)
+	}
+
+	if len(result.Unsatisfied) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printJSON(value interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Fatalf("Failed to encode result: %v", err)
+	}
+}
+
+func printResult(result *services.TimetableResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tTIME\tCOURSE\tSECTION\tVENUE\tSTUDENTS\tLECTURER\tPENALTY")
+	for _, lecture := range result.Lectures {
+		fmt.Fprintf(w, "%s\t%s-%s\t%s\t%s\t%s\t%d\t%d\t%.2f\n", lecture.DayOfWeek, lecture.StartTime, lecture.EndTime,
+			lecture.CourseCode, lecture.Section, lecture.Venue, lecture.Students, lecture.FacultyID, lecture.Penalty)
+	}
+	w.Flush()
+
+	if len(result.SoftViolations) > 0 {
+		fmt.Printf("\nSoft constraint violations (%d):\n", len(result.SoftViolations))
+		for _, issue := range result.SoftViolations {
+			fmt.Printf("  %-20s %s %s: %s\n", issue.Constraint, issue.CourseCode, issue.Section, issue.Message)
+		}
+	}
+	if len(result.Unsatisfied) > 0 {
+		fmt.Printf("\nCould not be placed (%d):\n", len(result.Unsatisfied))
+		for _, issue := range result.Unsatisfied {
+			fmt.Printf("  %-20s %s %s: %s\n", issue.Constraint, issue.CourseCode, issue.Section, issue.Message)
+		}
+	}
+
+	status := "not applied (dry run)"
+	switch {
+	case result.Applied:
+		status = "applied"
+	case len(result.Unsatisfied) > 0:
+		status = "not applied"
+	}
+	fmt.Printf("\nSemester %d: %d lectures, penalty %.2f, %s\n", result.SemesterID, len(result.Lectures), result.Penalty, status)
+}
+
+func printAudit(audit *services.TimetableAudit) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tLECTURES\tDETAILS")
+	for _, conflict := range audit.Conflicts {
+		ids := make([]string, len(conflict.LectureIDs))
+		for i, id := range conflict.LectureIDs {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", conflict.Type, strings.Join(ids, ","), conflict.Message)
+	}
+	w.Flush()
+
+	fmt.Printf("\nSemester %d: %d lectures, %d conflicts (%d venue double-bookings, %d lecturer clashes, %d student overlaps, %d undersized venues)\n",
+		audit.SemesterID, audit.Lectures, len(audit.Conflicts), audit.Counts[services.ConflictVenueDoubleBooking],
+		audit.Counts[services.ConflictLecturerClash], audit.Counts[services.ConflictStudentOverlap], audit.Counts[services.ConflictVenueCapacity])
+}
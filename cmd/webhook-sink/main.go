@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/mwombeki6/mock-sims/internal/config"
+)
+
+// webhook-sink is a stand-in LMS that receives SIMS webhooks during local development.
+// It verifies signatures, keeps received events in memory, and can simulate failing receivers.
+func main() {
+	// Shares LMS_WEBHOOK_SECRET (and .env) with the server
+	cfg := config.Load()
+
+	secret := getEnv("SINK_SECRET", cfg.LMSWebhookSecret)
+	path := getEnv("SINK_PATH", "/webhooks/sims")
+	maxEvents := getEnvInt("SINK_MAX_EVENTS", 1000)
+
+	failure := FailureConfig{
+		Mode:       getEnv("SINK_FAILURE_MODE", ModeNone),
+		Rate:       getEnvFloat("SINK_FAILURE_RATE", 1),
+		StatusCode: getEnvInt("SINK_FAILURE_STATUS", 500),
+		DelayMs:    getEnvInt("SINK_DELAY_MS", 0),
+		FailFirst:  getEnvInt("SINK_FAIL_FIRST", 0),
+	}
+	if err := validateFailure(&failure); err != nil {
+		log.Fatalf("Invalid failure mode: %v", err)
+	}
+
+	sink := &Sink{
+		store:  NewStore(maxEvents, failure),
+		secret: secret,
+	}
+
+	app := fiber.New(fiber.Config{
+		AppName: "Mock SIMS Webhook Sink",
+	})
+	app.Use(recover.New())
+	app.Use(logger.New())
+
+	// Receiver
+	app.Post(path, sink.Receive)
+
+	// UI and JSON API
+	app.Get("/", sink.UI)
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"status":  "ok",
+			"service": "webhook-sink",
+		})
+	})
+	app.Get("/api/events", sink.ListEvents)
+	app.Get("/api/events/:seq", sink.GetEvent)
+	app.Delete("/api/events", sink.ClearEvents)
+	app.Get("/api/failure-mode", sink.GetFailureMode)
+	app.Put("/api/failure-mode", sink.SetFailureMode)
+
+	addr := getEnv("SINK_HOST", "0.0.0.0") + ":" + getEnv("SINK_PORT", "50051")
+	log.Printf("🪝 Webhook sink listening on %s%s (failure mode: %s)", addr, path, failure.Mode)
+
+	go func() {
+		if err := app.Listen(addr); err != nil {
+			log.Fatalf("Failed to start webhook sink: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	if err := app.Shutdown(); err != nil {
+		log.Fatalf("Webhook sink forced to shutdown: %v", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/utils"
+)
+
+// defaultTimeoutDelay outlasts the SIMS webhook client's 10 second timeout
+const defaultTimeoutDelay = 15 * time.Second
+
+type Sink struct {
+	store  *Store
+	secret string
+}
+
+// Receive accepts a SIMS webhook delivery
+// POST /webhooks/sims
+func (s *Sink) Receive(c *fiber.Ctx) error {
+	body := append([]byte(nil), c.Body()...)
+	signature := c.Get("X-SIMS-Signature")
+
+	event := ReceivedEvent{
+		DeliveryID:     c.Get("X-SIMS-Delivery"),
+		Event:          c.Get("X-SIMS-Event"),
+		Version:        c.Get("X-SIMS-Event-Version"),
+		Attempt:        c.Get("X-SIMS-Attempt", "1"),
+		Test:           c.Get("X-SIMS-Test") == "true",
+		Signature:      signature,
+		SignatureValid: utils.VerifyHMACSignature(body, signature, s.secret),
+		ReceivedAt:     time.Now(),
+	}
+	if json.Valid(body) {
+		event.Payload = body
+	} else {
+		event.Payload, _ = json.Marshal(string(body))
+	}
+
+	if !event.SignatureValid {
+		event.Outcome = "invalid_signature"
+		event.ResponseStatus = 401
+		s.store.Add(event)
+		return c.Status(401).JSON(fiber.Map{
+			"error": "invalid signature",
+		})
+	}
+
+	failure, fail := s.store.shouldFail(event.DeliveryID)
+	if !fail {
+		event.Outcome = "accepted"
+		event.ResponseStatus = 200
+		stored := s.store.Add(event)
+		return c.JSON(fiber.Map{
+			"received": true,
+			"seq":      stored.Seq,
+		})
+	}
+
+	switch failure.Mode {
+	case ModeError:
+		event.Outcome = "failed"
+		event.ResponseStatus = failure.StatusCode
+		s.store.Add(event)
+		return c.Status(failure.StatusCode).JSON(fiber.Map{
+			"error": "simulated failure",
+		})
+
+	case ModeTimeout:
+		event.Outcome = "timeout"
+		event.ResponseStatus = 504
+		s.store.Add(event)
+		delay := time.Duration(failure.DelayMs) * time.Millisecond
+		if delay <= 0 {
+			delay = defaultTimeoutDelay
+		}
+		time.Sleep(delay)
+		return c.Status(504).JSON(fiber.Map{
+			"error": "simulated timeout",
+		})
+
+	default: // ModeSlow
+		event.Outcome = "slow"
+		event.ResponseStatus = 200
+		stored := s.store.Add(event)
+		time.Sleep(time.Duration(failure.DelayMs) * time.Millisecond)
+		return c.JSON(fiber.Map{
+			"received": true,
+			"seq":      stored.Seq,
+		})
+	}
+}
+
+// ListEvents returns received events, newest first
+// GET /api/events?event=grade.submitted&limit=50
+func (s *Sink) ListEvents(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 {
+		limit = 100
+	}
+
+	events := s.store.List(c.Query("event"), limit)
+	return c.JSON(fiber.Map{
+		"events":       events,
+		"total":        len(events),
+		"stats":        s.store.Stats(),
+		"failure_mode": s.store.Failure(),
+	})
+}
+
+// GetEvent returns one received event
+// GET /api/events/:seq
+func (s *Sink) GetEvent(c *fiber.Ctx) error {
+	seq, err := strconv.Atoi(c.Params("seq"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid event sequence",
+		})
+	}
+
+	event, ok := s.store.Get(seq)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "event not found",
+		})
+	}
+
+	return c.JSON(event)
+}
+
+// ClearEvents drops all received events
+// DELETE /api/events
+func (s *Sink) ClearEvents(c *fiber.Ctx) error {
+	s.store.Clear()
+	return c.JSON(fiber.Map{
+		"message": "Events cleared",
+	})
+}
+
+// GetFailureMode returns the current failure simulation settings
+// GET /api/failure-mode
+func (s *Sink) GetFailureMode(c *fiber.Ctx) error {
+	return c.JSON(s.store.Failure())
+}
+
+// SetFailureMode changes the failure simulation at runtime
+// PUT /api/failure-mode
+func (s *Sink) SetFailureMode(c *fiber.Ctx) error {
+	failure := s.store.Failure()
+	if err := c.BodyParser(&failure); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateFailure(&failure); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	s.store.SetFailure(failure)
+	return c.JSON(failure)
+}
+
+// validateFailure checks a failure config and fills in defaults
+func validateFailure(failure *FailureConfig) error {
+	switch failure.Mode {
+	case "":
+		failure.Mode = ModeNone
+	case ModeNone, ModeError, ModeTimeout, ModeSlow:
+	default:
+		return fmt.Errorf("mode must be one of %s, %s, %s, %s", ModeNone, ModeError, ModeTimeout, ModeSlow)
+	}
+
+	if failure.Rate < 0 || failure.Rate > 1 {
+		return errors.New("rate must be between 0 and 1")
+	}
+	if failure.StatusCode == 0 {
+		failure.StatusCode = 500
+	}
+	if failure.StatusCode < 400 || failure.StatusCode > 599 {
+		return errors.New("status_code must be a 4xx or 5xx code")
+	}
+	if failure.DelayMs < 0 || failure.FailFirst < 0 {
+		return errors.New("delay_ms and fail_first must not be negative")
+	}
+	if failure.Mode == ModeSlow && failure.DelayMs == 0 {
+		failure.DelayMs = 3000
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Failure modes the sink can simulate
+const (
+	ModeNone    = "none"    // Accept everything with 200
+	ModeError   = "error"   // Respond with FailureConfig.StatusCode (500 by default)
+	ModeTimeout = "timeout" // Hold the request longer than the sender's client timeout
+	ModeSlow    = "slow"    // Respond 200 after FailureConfig.DelayMs
+)
+
+// FailureConfig controls how the sink misbehaves
+type FailureConfig struct {
+	Mode       string  `json:"mode"`
+	Rate       float64 `json:"rate"`        // Probability (0-1) that a delivery is affected
+	StatusCode int     `json:"status_code"` // Status returned in error mode
+	DelayMs    int     `json:"delay_ms"`    // Delay used by slow and timeout modes
+	FailFirst  int     `json:"fail_first"`  // When > 0, only the first N attempts of each delivery are affected
+}
+
+// ReceivedEvent is one webhook request as seen by the sink
+type ReceivedEvent struct {
+	Seq            int             `json:"seq"`
+	DeliveryID     string          `json:"delivery_id"`
+	Event          string          `json:"event"`
+	Version        string          `json:"version"`
+	Attempt        string          `json:"attempt"`
+	Test           bool            `json:"test"`
+	Signature      string          `json:"signature"`
+	SignatureValid bool            `json:"signature_valid"`
+	Duplicate      bool            `json:"duplicate"` // Same delivery ID was received before (a retry)
+	Outcome        string          `json:"outcome"`   // accepted, failed, timeout, slow, invalid_signature
+	ResponseStatus int             `json:"response_status"`
+	Payload        json.RawMessage `json:"payload"`
+	ReceivedAt     time.Time       `json:"received_at"`
+}
+
+// Store keeps the most recent events in memory
+type Store struct {
+	mu        sync.RWMutex
+	events    []ReceivedEvent
+	seen      map[string]int // Delivery ID -> number of requests received
+	nextSeq   int
+	maxEvents int
+	failure   FailureConfig
+}
+
+func NewStore(maxEvents int, failure FailureConfig) *Store {
+	return &Store{
+		seen:      make(map[string]int),
+		nextSeq:   1,
+		maxEvents: maxEvents,
+		failure:   failure,
+	}
+}
+
+// Add records an event and returns it with its sequence number and duplicate flag set
+func (s *Store) Add(event ReceivedEvent) ReceivedEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.Seq = s.nextSeq
+	s.nextSeq++
+	if event.DeliveryID != "" {
+		event.Duplicate = s.seen[event.DeliveryID] > 0
+		s.seen[event.DeliveryID]++
+	}
+
+	s.events = append(s.events, event)
+	if len(s.events) > s.maxEvents {
+		s.events = s.events[len(s.events)-s.maxEvents:]
+	}
+
+	return event
+}
+
+// List returns events newest first, optionally filtered by event type
+func (s *Store) List(eventType string, limit int) []ReceivedEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := []ReceivedEvent{}
+	for i := len(s.events) - 1; i >= 0 && len(events) < limit; i-- {
+		if eventType == "" || s.events[i].Event == eventType {
+			events = append(events, s.events[i])
+		}
+	}
+	return events
+}
+
+// Get returns a single event by sequence number
+func (s *Store) Get(seq int) (ReceivedEvent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, event := range s.events {
+		if event.Seq == seq {
+			return event, true
+		}
+	}
+	return ReceivedEvent{}, false
+}
+
+// Clear drops all stored events and delivery history
+func (s *Store) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = nil
+	s.seen = make(map[string]int)
+}
+
+// Stats summarises outcomes of everything currently stored
+func (s *Store) Stats() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := map[string]int{"total": len(s.events)}
+	for _, event := range s.events {
+		stats[event.Outcome]++
+		if event.Duplicate {
+			stats["retries"]++
+		}
+	}
+	return stats
+}
+
+func (s *Store) Failure() FailureConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.failure
+}
+
+func (s *Store) SetFailure(failure FailureConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failure = failure
+}
+
+// shouldFail decides whether the configured failure mode applies to this delivery
+func (s *Store) shouldFail(deliveryID string) (FailureConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	failure := s.failure
+	if failure.Mode == ModeNone {
+		return failure, false
+	}
+	if failure.FailFirst > 0 {
+		// Called before Add, so seen counts earlier attempts only
+		return failure, s.seen[deliveryID] < failure.FailFirst
+	}
+	return failure, rand.Float64() < failure.Rate
+}
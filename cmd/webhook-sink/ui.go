@@ -0,0 +1,131 @@
+package main
+
+import "github.com/gofiber/fiber/v2"
+
+// UI serves a small dashboard that polls the JSON API
+// GET /
+func (s *Sink) UI(c *fiber.Ctx) error {
+	return c.Type("html").SendString(uiHTML)
+}
+
+const uiHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Mock SIMS - Webhook Sink</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; margin: 0; background: #f5f6fa; color: #2d3436; }
+        header { background: #2d3436; color: #fff; padding: 16px 24px; display: flex; justify-content: space-between; align-items: center; }
+        header h1 { font-size: 18px; margin: 0; }
+        main { padding: 24px; display: grid; grid-template-columns: 320px 1fr; gap: 24px; }
+        section { background: #fff; border-radius: 6px; padding: 16px; box-shadow: 0 1px 3px rgba(0,0,0,.1); }
+        label { display: block; font-size: 12px; margin-top: 10px; color: #636e72; }
+        input, select { width: 100%; padding: 6px; box-sizing: border-box; }
+        button { margin-top: 12px; padding: 8px 12px; border: 0; border-radius: 4px; background: #0984e3; color: #fff; cursor: pointer; }
+        button.secondary { background: #b2bec3; }
+        table { width: 100%; border-collapse: collapse; font-size: 13px; }
+        th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #dfe6e9; vertical-align: top; }
+        tr.event { cursor: pointer; }
+        tr.event:hover { background: #f1f2f6; }
+        .badge { padding: 2px 6px; border-radius: 3px; font-size: 11px; color: #fff; }
+        .accepted { background: #00b894; } .slow { background: #fdcb6e; color: #2d3436; }
+        .failed, .timeout, .invalid_signature { background: #d63031; }
+        pre { background: #2d3436; color: #dfe6e9; padding: 12px; overflow: auto; font-size: 12px; margin: 0; }
+        .stats span { margin-right: 12px; font-size: 13px; }
+    </style>
+</head>
+<body>
+<header>
+    <h1>🪝 Mock SIMS Webhook Sink</h1>
+    <div class="stats" id="stats"></div>
+</header>
+<main>
+    <section>
+        <h3>Failure mode</h3>
+        <label>Mode</label>
+        <select id="mode">
+            <option value="none">none - accept everything</option>
+            <option value="error">error - respond with status code</option>
+            <option value="timeout">timeout - hold the request</option>
+            <option value="slow">slow - respond 200 after delay</option>
+        </select>
+        <label>Rate (0-1)</label>
+        <input id="rate" type="number" min="0" max="1" step="0.1">
+        <label>Status code (error mode)</label>
+        <input id="status_code" type="number">
+        <label>Delay ms (slow / timeout)</label>
+        <input id="delay_ms" type="number" min="0">
+        <label>Fail first N attempts per delivery (0 = use rate)</label>
+        <input id="fail_first" type="number" min="0">
+        <button onclick="saveMode()">Apply</button>
+        <button class="secondary" onclick="clearEvents()">Clear events</button>
+        <p id="message"></p>
+    </section>
+    <section>
+        <table>
+            <thead><tr><th>#</th><th>Received</th><th>Event</th><th>Attempt</th><th>Outcome</th><th>Signature</th><th>Delivery</th></tr></thead>
+            <tbody id="events"></tbody>
+        </table>
+        <h4>Payload</h4>
+        <pre id="payload">Select an event</pre>
+    </section>
+</main>
+<script>
+    const fields = ['mode', 'rate', 'status_code', 'delay_ms', 'fail_first'];
+
+    async function loadMode() {
+        const res = await fetch('/api/failure-mode');
+        const mode = await res.json();
+        fields.forEach(f => document.getElementById(f).value = mode[f]);
+    }
+
+    async function saveMode() {
+        const body = {};
+        fields.forEach(f => {
+            const v = document.getElementById(f).value;
+            body[f] = f === 'mode' ? v : Number(v);
+        });
+        const res = await fetch('/api/failure-mode', { method: 'PUT', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) });
+        const data = await res.json();
+        document.getElementById('message').textContent = res.ok ? 'Saved' : data.error;
+    }
+
+    async function clearEvents() {
+        await fetch('/api/events', { method: 'DELETE' });
+        document.getElementById('payload').textContent = 'Select an event';
+        loadEvents();
+    }
+
+    function escapeHTML(value) {
+        return String(value).replace(/[&<>"]/g, c => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;' }[c]));
+    }
+
+    async function loadEvents() {
+        const res = await fetch('/api/events?limit=200');
+        const data = await res.json();
+        document.getElementById('stats').innerHTML = Object.entries(data.stats)
+            .map(([k, v]) => '<span>' + escapeHTML(k) + ': ' + v + '</span>').join('');
+        document.getElementById('events').innerHTML = data.events.map(e =>
+            '<tr class="event" onclick="showEvent(' + e.seq + ')">' +
+            '<td>' + e.seq + '</td>' +
+            '<td>' + new Date(e.received_at).toLocaleTimeString() + '</td>' +
+            '<td>' + escapeHTML(e.event) + (e.test ? ' (test)' : '') + '</td>' +
+            '<td>' + escapeHTML(e.attempt) + (e.duplicate ? ' ↻' : '') + '</td>' +
+            '<td><span class="badge ' + e.outcome + '">' + e.outcome + '</span></td>' +
+            '<td>' + (e.signature_valid ? '✔' : '✘') + '</td>' +
+            '<td><code>' + escapeHTML(e.delivery_id) + '</code></td></tr>').join('');
+    }
+
+    async function showEvent(seq) {
+        const res = await fetch('/api/events/' + seq);
+        const event = await res.json();
+        document.getElementById('payload').textContent = JSON.stringify(event.payload, null, 2);
+    }
+
+    loadMode();
+    loadEvents();
+    setInterval(loadEvents, 2000);
+</script>
+</body>
+</html>`
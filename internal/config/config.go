@@ -34,8 +34,36 @@ type Config struct {
 	JWTExpiry string
 
 	// LMS Integration
-	LMSWebhookURL    string
-	LMSWebhookSecret string
+	LMSWebhookURL        string
+	LMSWebhookSecret     string
+	WebhookRetryInterval string // Seconds between retry sweeps of failed deliveries
+	WebhookMaxAttempts   string
+
+	// Registration rules
+	RegistrationRules                  string // Enabled rules, comma-separated
+	RegistrationCreditLimits           string // DegreeLevel:min-max per semester, comma-separated
+	RegistrationEnforceMinCredits      string // "true" makes the minimum load blocking instead of a warning
+	RegistrationBlockedStatuses        string // Student enrollment statuses that cannot register
+	RegistrationBlockedPaymentStatuses string // Student payment statuses that cannot register
+
+	// Results and academic standing
+	ResultsMinCGPA         string // CGPA below this puts a student on probation (schemes without min_cgpa)
+	ResultsDiscontinueCGPA string // CGPA below this discontinues a student (schemes without discontinue_cgpa)
+	ResultsMaxProbations   string // Consecutive probation semesters allowed before discontinuation
+	ResultsDegreeClasses   string // Class:minimum CGPA, comma-separated (schemes without degree_classes)
+
+	// Lecture attendance
+	AttendanceThreshold   string // Attendance percentage below which a student may not sit the final exam
+	AttendanceMinSessions string // Sessions a student must be expected at before the threshold applies
+
+	// Timetable generation
+	TimetableDays            string // Teaching days, comma-separated
+	TimetableSlots           string // Lecture slots as start-end, comma-separated
+	TimetableSoftConstraints string // Soft constraint:weight, comma-separated; 0 disables a constraint
+
+	// Calendar feeds
+	CalendarTimezone string // IANA time zone lecture and exam times are in
+	CalendarBaseURL  string // Public URL feed links are built from
 
 	// CORS
 	AllowedOrigins string
@@ -76,8 +104,36 @@ func Load() *Config {
 		JWTExpiry: getEnv("JWT_EXPIRY", "86400"),
 
 		// LMS Integration
-		LMSWebhookURL:    getEnv("LMS_WEBHOOK_URL", "http://localhost:50051/webhooks/sims"),
-		LMSWebhookSecret: getEnv("LMS_WEBHOOK_SECRET", "webhook-secret"),
+		LMSWebhookURL:        getEnv("LMS_WEBHOOK_URL", "http://localhost:50051/webhooks/sims"),
+		LMSWebhookSecret:     getEnv("LMS_WEBHOOK_SECRET", "webhook-secret"),
+		WebhookRetryInterval: getEnv("WEBHOOK_RETRY_INTERVAL", "60"),
+		WebhookMaxAttempts:   getEnv("WEBHOOK_MAX_ATTEMPTS", "5"),
+
+		// Registration rules
+		RegistrationRules:                  getEnv("REGISTRATION_RULES", "enrollment_status,payment_status,duplicate,prerequisite,timetable_clash,credit_limit,capacity"),
+		RegistrationCreditLimits:           getEnv("REGISTRATION_CREDIT_LIMITS", "Certificate:12-36,Diploma:12-48,Bachelor:12-54,Masters:9-45,PhD:0-30"),
+		RegistrationEnforceMinCredits:      getEnv("REGISTRATION_ENFORCE_MIN_CREDITS", "false"),
+		RegistrationBlockedStatuses:        getEnv("REGISTRATION_BLOCKED_STATUSES", "suspended,graduated,discontinued"),
+		RegistrationBlockedPaymentStatuses: getEnv("REGISTRATION_BLOCKED_PAYMENT_STATUSES", "pending"),
+
+		// Results and academic standing
+		ResultsMinCGPA:         getEnv("RESULTS_MIN_CGPA", "2.0"),
+		ResultsDiscontinueCGPA: getEnv("RESULTS_DISCONTINUE_CGPA", "1.6"),
+		ResultsMaxProbations:   getEnv("RESULTS_MAX_PROBATIONS", "2"),
+		ResultsDegreeClasses:   getEnv("RESULTS_DEGREE_CLASSES", "First Class:4.4,Upper Second Class:3.5,Lower Second Class:2.7,Pass:2.0"),
+
+		// Lecture attendance
+		AttendanceThreshold:   getEnv("ATTENDANCE_THRESHOLD", "75"),
+		AttendanceMinSessions: getEnv("ATTENDANCE_MIN_SESSIONS", "4"),
+
+		// Timetable generation
+		TimetableDays:            getEnv("TIMETABLE_DAYS", "Monday,Tuesday,Wednesday,Thursday,Friday"),
+		TimetableSlots:           getEnv("TIMETABLE_SLOTS", "08:00-10:00,10:00-12:00,14:00-16:00,16:00-18:00"),
+		TimetableSoftConstraints: getEnv("TIMETABLE_SOFT_CONSTRAINTS", "elective_clash:10,same_day:5,lecturer_daily_load:3,program_daily_load:2,late_slot:1,venue_fit:1,lab_venue:4"),
+
+		// Calendar feeds
+		CalendarTimezone: getEnv("CALENDAR_TIMEZONE", "Africa/Dar_es_Salaam"),
+		CalendarBaseURL:  getEnv("CALENDAR_BASE_URL", "http://localhost:8000"),
 
 		// CORS
 		AllowedOrigins: getEnv("ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080"),
@@ -0,0 +1,41 @@
+package database
+
+import "gorm.io/gorm"
+
+// linkAssessmentStatements give every offering without components the default 40% continuous
+// assessment and 60% final examination, then turn existing CA and final exam marks into scores.
+var linkAssessmentStatements = []string{
+	`INSERT INTO assessment_components (offering_id, name, type, weight, max_marks, position, created_at, updated_at)
+	SELECT o.id, d.name, d.type, d.weight, d.max_marks, d.position, NOW(), NOW()
+	FROM course_offerings o
+	CROSS JOIN (VALUES
+		('Continuous Assessment', 'ca', 40, 40, 1),
+		('Final Examination', 'final_exam', 60, 60, 2)
+	) AS d(name, type, weight, max_marks, position)
+	WHERE o.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM assessment_components ac WHERE ac.offering_id = o.id)`,
+
+	`INSERT INTO assessment_scores (component_id, enrollment_id, student_id, score, created_at, updated_at)
+	SELECT ac.id, g.enrollment_id, g.student_id,
+		CASE WHEN ac.type = 'final_exam' THEN g.final_exam ELSE g.ca_marks END, NOW(), NOW()
+	FROM grades g
+	JOIN assessment_components ac ON ac.offering_id = g.offering_id
+	WHERE g.deleted_at IS NULL
+	AND ((ac.name = 'Continuous Assessment' AND ac.type = 'ca')
+		OR (ac.name = 'Final Examination' AND ac.type = 'final_exam' AND (g.final_exam > 0 OR g.letter_grade <> '')))
+	AND ac.weight = ac.max_marks
+	AND NOT EXISTS (SELECT 1 FROM assessment_scores s WHERE s.enrollment_id = g.enrollment_id)`,
+}
+
+// LinkAssessments backfills assessment components and scores for offerings and grades created
+// before components existed. It is idempotent and skips offerings and grades that already have them.
+func LinkAssessments(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range linkAssessmentStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -31,6 +31,14 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 func Migrate(db *gorm.DB) error {
 	log.Println("🔄 Running database migrations...")
 
+	// program_courses carries curriculum fields, so register it as a custom join table
+	if err := db.SetupJoinTable(&models.Program{}, "Courses", &models.ProgramCourse{}); err != nil {
+		return err
+	}
+	if err := db.SetupJoinTable(&models.Course{}, "Programs", &models.ProgramCourse{}); err != nil {
+		return err
+	}
+
 	err := db.AutoMigrate(
 		// Organizational structure
 		&models.College{},
@@ -38,6 +46,7 @@ func Migrate(db *gorm.DB) error {
 		&models.Program{},
 		&models.Venue{},
 		&models.Semester{},
+		&models.Holiday{},
 
 		// People
 		&models.User{},
@@ -51,14 +60,38 @@ func Migrate(db *gorm.DB) error {
 		&models.Enrollment{},
 		&models.Grade{},
 		&models.CourseAssignment{},
+		&models.CoursePrerequisite{},
+		&models.ElectiveGroup{},
+		&models.ProgramCourse{},
+		&models.CourseOffering{},
+		&models.CourseSection{},
+		&models.WaitlistEntry{},
+		&models.AttendanceSession{},
+		&models.AttendanceRecord{},
+		&models.ExamSession{},
+		&models.ExamVenue{},
+		&models.ExamSeat{},
+		&models.GradingScheme{},
+		&models.GradeBand{},
+		&models.AssessmentComponent{},
+		&models.AssessmentScore{},
+		&models.SemesterResult{},
+		&models.ResultsWorkflow{},
+		&models.ResultsWorkflowAction{},
+		&models.GradeHistory{},
+		&models.GradeChangeRequest{},
+		&models.GradeChangeItem{},
 
 		// OAuth
 		&models.OAuthClient{},
 		&models.OAuthAuthorizationCode{},
 		&models.OAuthAccessToken{},
+		&models.CalendarToken{},
 
 		// Webhooks & Payments
 		&models.WebhookLog{},
+		&models.WebhookSubscription{},
+		&models.DomainEvent{},
 		&models.Payment{},
 	)
 
@@ -66,6 +99,22 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := LinkOfferings(db); err != nil {
+		return fmt.Errorf("failed to link course offerings: %w", err)
+	}
+
+	if err := LinkAssessments(db); err != nil {
+		return fmt.Errorf("failed to link assessment components: %w", err)
+	}
+
+	if err := LinkResultsWorkflows(db); err != nil {
+		return fmt.Errorf("failed to link results workflows: %w", err)
+	}
+
+	if err := ProtectGradeHistory(db); err != nil {
+		return fmt.Errorf("failed to protect grade history: %w", err)
+	}
+
 	log.Println("✅ Migrations completed successfully")
 	return nil
 }
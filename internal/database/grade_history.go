@@ -0,0 +1,30 @@
+package database
+
+import "gorm.io/gorm"
+
+// protectGradeHistoryStatements make grade_histories append-only at the database level
+var protectGradeHistoryStatements = []string{
+	`CREATE OR REPLACE FUNCTION grade_histories_immutable() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'grade history is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+
+	`DROP TRIGGER IF EXISTS grade_histories_immutable ON grade_histories`,
+
+	`CREATE TRIGGER grade_histories_immutable
+	BEFORE UPDATE OR DELETE ON grade_histories
+	FOR EACH ROW EXECUTE FUNCTION grade_histories_immutable()`,
+}
+
+// ProtectGradeHistory rejects any UPDATE or DELETE on the grade history. It is idempotent.
+func ProtectGradeHistory(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range protectGradeHistoryStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
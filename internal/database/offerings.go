@@ -0,0 +1,50 @@
+package database
+
+import "gorm.io/gorm"
+
+// linkOfferingStatements create an offering (with a default section A) for every course taught
+// or enrolled in a semester, then attach unlinked lectures, assignments, enrollments and grades.
+var linkOfferingStatements = []string{
+	`INSERT INTO course_offerings (course_id, semester_id, created_at, updated_at)
+	SELECT course_id, semester_id, NOW(), NOW() FROM (
+		SELECT course_id, semester_id FROM enrollments WHERE deleted_at IS NULL
+		UNION SELECT course_id, semester_id FROM lectures WHERE deleted_at IS NULL
+		UNION SELECT course_id, semester_id FROM course_assignments WHERE deleted_at IS NULL
+	) taught
+	ON CONFLICT (course_id, semester_id) DO NOTHING`,
+
+	`INSERT INTO course_sections (offering_id, code, name, created_at, updated_at)
+	SELECT o.id, 'A', 'Section A', NOW(), NOW() FROM course_offerings o
+	WHERE NOT EXISTS (SELECT 1 FROM course_sections cs WHERE cs.offering_id = o.id)`,
+
+	`UPDATE enrollments e SET offering_id = o.id
+	FROM course_offerings o
+	WHERE e.offering_id IS NULL AND o.course_id = e.course_id AND o.semester_id = e.semester_id`,
+
+	`UPDATE enrollments e SET section_id = (
+		SELECT MIN(cs.id) FROM course_sections cs WHERE cs.offering_id = e.offering_id AND cs.deleted_at IS NULL)
+	WHERE e.section_id IS NULL AND e.offering_id IS NOT NULL`,
+
+	`UPDATE lectures l SET section_id = (
+		SELECT MIN(cs.id) FROM course_sections cs
+		JOIN course_offerings o ON o.id = cs.offering_id
+		WHERE o.course_id = l.course_id AND o.semester_id = l.semester_id AND cs.deleted_at IS NULL)
+	WHERE l.section_id IS NULL`,
+
+	`UPDATE grades g SET offering_id = e.offering_id, section_id = e.section_id
+	FROM enrollments e
+	WHERE g.offering_id IS NULL AND e.id = g.enrollment_id AND e.offering_id IS NOT NULL`,
+}
+
+// LinkOfferings backfills course offerings and sections for data created before they existed.
+// It is idempotent and only touches rows that are not linked yet.
+func LinkOfferings(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range linkOfferingStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
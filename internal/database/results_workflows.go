@@ -0,0 +1,37 @@
+package database
+
+import "gorm.io/gorm"
+
+// linkResultsWorkflowStatements treat graded offerings from before the approval workflow existed as
+// already published: their grades become visible and they get a published workflow.
+var linkResultsWorkflowStatements = []string{
+	`UPDATE grades g
+	SET published_at = COALESCE(g.submitted_at, g.updated_at, NOW())
+	WHERE g.deleted_at IS NULL
+	AND g.published_at IS NULL
+	AND g.letter_grade <> ''
+	AND g.offering_id IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM results_workflows w WHERE w.offering_id = g.offering_id)`,
+
+	`INSERT INTO results_workflows (offering_id, status, published_at, created_at, updated_at)
+	SELECT g.offering_id, 'published', MAX(g.published_at), NOW(), NOW()
+	FROM grades g
+	WHERE g.deleted_at IS NULL
+	AND g.published_at IS NOT NULL
+	AND g.offering_id IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM results_workflows w WHERE w.offering_id = g.offering_id)
+	GROUP BY g.offering_id`,
+}
+
+// LinkResultsWorkflows publishes legacy grades and records a published workflow for their offerings.
+// It is idempotent: offerings that already have a workflow are left to it.
+func LinkResultsWorkflows(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range linkResultsWorkflowStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
@@ -9,16 +11,20 @@ import (
 )
 
 type AdminHandler struct {
-	db           *gorm.DB
-	cfg          *config.Config
-	adminService *services.AdminService
+	db                *gorm.DB
+	cfg               *config.Config
+	adminService      *services.AdminService
+	curriculumService *services.CurriculumService
+	resultsService    *services.ResultsService
 }
 
 func NewAdminHandler(db *gorm.DB, cfg *config.Config) *AdminHandler {
 	return &AdminHandler{
-		db:           db,
-		cfg:          cfg,
-		adminService: services.NewAdminService(db, cfg),
+		db:                db,
+		cfg:               cfg,
+		adminService:      services.NewAdminService(db, cfg),
+		curriculumService: services.NewCurriculumService(db, cfg),
+		resultsService:    services.NewResultsService(db, cfg),
 	}
 }
 
@@ -135,28 +141,239 @@ func (h *AdminHandler) GetPrograms(c *fiber.Ctx) error {
 	})
 }
 
-// CreateEnrollments creates bulk enrollments
+// GetCurriculum returns a program's courses by year, semester and type
+// GET /api/programs/:code/curriculum
+func (h *AdminHandler) GetCurriculum(c *fiber.Ctx) error {
+	curriculum, err := h.curriculumService.GetCurriculum(c.Params("code"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"program": fiber.Map{
+			"code":         curriculum.Program.Code,
+			"name":         curriculum.Program.Name,
+			"degree_level": curriculum.Program.DegreeLevel,
+			"duration":     curriculum.Program.Duration,
+		},
+		"years": curriculum.Years,
+	})
+}
+
+// CreateEnrollments creates bulk enrollments and reports the outcome per item
 // POST /api/enrollments
 func (h *AdminHandler) CreateEnrollments(c *fiber.Ctx) error {
+	var request services.BulkEnrollmentInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	result, err := h.adminService.CreateBulkEnrollments(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// 422 when an all_or_nothing batch was rolled back, 207 when only some items failed
+	status := 200
+	if !result.Committed {
+		status = 422
+	} else if result.Failed > 0 {
+		status = 207
+	}
+
+	return c.Status(status).JSON(result)
+}
+
+// UpdateProgram updates a program
+// PUT /api/programs/:code
+func (h *AdminHandler) UpdateProgram(c *fiber.Ctx) error {
+	var request services.ProgramInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	program, err := h.adminService.UpdateProgram(c.Params("code"), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "program updated successfully",
+		"code":    program.Code,
+	})
+}
+
+// SetDepartmentHead assigns the lecturer who approves a department's results
+// PUT /api/departments/:code/head
+func (h *AdminHandler) SetDepartmentHead(c *fiber.Ctx) error {
+	var request struct {
+		StaffID string `json:"staff_id"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.StaffID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "staff_id is required",
+		})
+	}
+
+	department, err := h.adminService.SetDepartmentHead(c.Params("code"), request.StaffID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":         "head of department updated successfully",
+		"department":      department.Code,
+		"head_faculty_id": department.HeadFacultyID,
+	})
+}
+
+// SetCollegeDean assigns the lecturer who approves a college's results
+// PUT /api/colleges/:code/dean
+func (h *AdminHandler) SetCollegeDean(c *fiber.Ctx) error {
 	var request struct {
-		Enrollments []models.Enrollment `json:"enrollments"`
+		StaffID string `json:"staff_id"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.StaffID == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "staff_id is required",
+		})
 	}
 
+	college, err := h.adminService.SetCollegeDean(c.Params("code"), request.StaffID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":         "dean updated successfully",
+		"college":         college.Code,
+		"dean_faculty_id": college.DeanFacultyID,
+	})
+}
+
+// ActivateSemester makes a semester the current semester
+// POST /api/semesters/:id/activate
+func (h *AdminHandler) ActivateSemester(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	semester, err := h.adminService.ActivateSemester(uint(semesterID))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "semester activated successfully",
+		"semester": semester.Name,
+	})
+}
+
+// ComputeResults recomputes GPA and academic standing for every student graded in a semester
+// POST /api/semesters/:id/results/compute
+func (h *AdminHandler) ComputeResults(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	students, err := h.resultsService.ComputeSemester(uint(semesterID))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "results computed successfully",
+		"students": students,
+	})
+}
+
+// RecordPayment records a student payment
+// POST /api/payments
+func (h *AdminHandler) RecordPayment(c *fiber.Ctx) error {
+	var request services.PaymentInput
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "invalid request body",
 		})
 	}
 
-	// Create enrollments
-	if err := h.adminService.CreateBulkEnrollments(request.Enrollments); err != nil {
-		return c.Status(500).JSON(fiber.Map{
+	payment, err := h.adminService.RecordPayment(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":        "payment recorded successfully",
+		"payment_id":     payment.ID,
+		"invoice_number": payment.InvoiceNumber,
+		"receipt_number": payment.ReceiptNumber,
+	})
+}
+
+// DeleteEnrollment removes an enrollment entered in error
+// DELETE /api/enrollments/:id
+func (h *AdminHandler) DeleteEnrollment(c *fiber.Ctx) error {
+	enrollmentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid enrollment ID",
+		})
+	}
+
+	if err := h.adminService.DeleteEnrollment(uint(enrollmentID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "enrollment deleted successfully",
+	})
+}
+
+// DeletePayment voids a payment record
+// DELETE /api/payments/:id
+func (h *AdminHandler) DeletePayment(c *fiber.Ctx) error {
+	paymentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid payment ID",
+		})
+	}
+
+	if err := h.adminService.DeletePayment(uint(paymentID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "enrollments created successfully",
-		"count":   len(request.Enrollments),
+		"message": "payment deleted successfully",
 	})
 }
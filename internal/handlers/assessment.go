@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type AssessmentHandler struct {
+	db                *gorm.DB
+	cfg               *config.Config
+	assessmentService *services.AssessmentService
+	marksService      *services.MarksSheetService
+	facultyService    *services.FacultyService
+}
+
+func NewAssessmentHandler(db *gorm.DB, cfg *config.Config) *AssessmentHandler {
+	return &AssessmentHandler{
+		db:                db,
+		cfg:               cfg,
+		assessmentService: services.NewAssessmentService(db, cfg),
+		marksService:      services.NewMarksSheetService(db, cfg),
+		facultyService:    services.NewFacultyService(db, cfg),
+	}
+}
+
+// GetComponents returns an offering's weighted assessment components
+// GET /api/offerings/:id/components
+func (h *AssessmentHandler) GetComponents(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	components, err := h.assessmentService.Components(uint(offeringID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"offering_id": offeringID,
+		"components":  components,
+	})
+}
+
+// SetComponents replaces an offering's assessment components (admin or the course lecturer)
+// PUT /api/offerings/:id/components
+func (h *AssessmentHandler) SetComponents(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+	if !h.canManage(c, uint(offeringID)) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "you are not assigned to this course",
+		})
+	}
+
+	var request struct {
+		Components []services.AssessmentComponentInput `json:"components"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	components, err := h.assessmentService.SetComponents(uint(offeringID), request.Components, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "assessment components updated successfully",
+		"components": components,
+	})
+}
+
+// GetScores returns every student's component scores and derived marks (admin or the course lecturer)
+// GET /api/offerings/:id/scores
+func (h *AssessmentHandler) GetScores(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+	if !h.canManage(c, uint(offeringID)) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "you are not assigned to this course",
+		})
+	}
+
+	components, students, err := h.assessmentService.OfferingScores(uint(offeringID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"offering_id": offeringID,
+		"components":  components,
+		"students":    students,
+		"total":       len(students),
+	})
+}
+
+// RecordScores stores raw scores for one component (admin, the course lecturer or an LMS client)
+// POST /api/assessment-components/:id/scores
+func (h *AssessmentHandler) RecordScores(c *fiber.Ctx) error {
+	componentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid component ID",
+		})
+	}
+
+	offeringID, err := h.assessmentService.ComponentOffering(uint(componentID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if !h.canManage(c, offeringID) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "you are not assigned to this course",
+		})
+	}
+
+	var request struct {
+		Scores []services.ScoreInput `json:"scores"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if len(request.Scores) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "scores are required",
+		})
+	}
+
+	results, err := h.assessmentService.RecordScores(uint(componentID), request.Scores, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recorded := 0
+	for _, result := range results {
+		if result.Status == services.ScoreRecorded {
+			recorded++
+		}
+	}
+
+	// Nothing written is a failure; some scores rejected is a partial success
+	status, message := 200, "scores recorded successfully"
+	switch {
+	case recorded == 0:
+		status, message = 422, "no scores were recorded"
+	case recorded < len(results):
+		status, message = 207, "some scores were not recorded"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message":      message,
+		"component_id": componentID,
+		"recorded":     recorded,
+		"failed":       len(results) - recorded,
+		"results":      results,
+	})
+}
+
+// DownloadMarksTemplate returns the offering's marks sheet pre-filled with the roster (?format=csv|xlsx)
+// GET /api/offerings/:id/marks-template
+func (h *AssessmentHandler) DownloadMarksTemplate(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+	if !h.canManage(c, uint(offeringID)) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "you are not assigned to this course",
+		})
+	}
+
+	sheet, err := h.marksService.Template(uint(offeringID), strings.ToLower(c.Query("format", services.SheetCSV)))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, sheet.ContentType)
+	c.Attachment(sheet.Filename)
+	return c.Send(sheet.Data)
+}
+
+// UploadMarks validates a CSV or XLSX marks sheet (multipart field "file") and records its scores.
+// With ?preview=true nothing is recorded; the row-level errors and counts are returned either way.
+// POST /api/offerings/:id/marks-upload
+func (h *AssessmentHandler) UploadMarks(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+	if !h.canManage(c, uint(offeringID)) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "you are not assigned to this course",
+		})
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "a marks sheet is required in the \"file\" field",
+		})
+	}
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "could not read the uploaded file",
+		})
+	}
+	defer file.Close()
+
+	result, err := h.marksService.Upload(uint(offeringID), format, file, c.QueryBool("preview"), requestActor(c, services.SourceCSV))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if len(result.Errors) > 0 {
+		return c.Status(422).JSON(result)
+	}
+	return c.JSON(result)
+}
+
+// canManage allows admins, lecturers assigned to the offering's course and LMS clients whose token
+// carries the write_grades scope (grade passback, recorded with source lti). The router only lets
+// clients reach the score routes.
+func (h *AssessmentHandler) canManage(c *fiber.Ctx, offeringID uint) bool {
+	switch c.Locals("user_type") {
+	case "admin":
+		return true
+	case "client":
+		return clientHasScope(c, "write_grades")
+	case "faculty":
+		userID, ok := c.Locals("user_id").(uint)
+		if !ok {
+			return false
+		}
+		faculty, err := h.facultyService.GetFacultyByUserID(userID)
+		return err == nil && h.assessmentService.FacultyTeaches(faculty.ID, offeringID)
+	}
+	return false
+}
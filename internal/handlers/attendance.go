@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type AttendanceHandler struct {
+	db                *gorm.DB
+	cfg               *config.Config
+	attendanceService *services.AttendanceService
+}
+
+func NewAttendanceHandler(db *gorm.DB, cfg *config.Config) *AttendanceHandler {
+	return &AttendanceHandler{
+		db:                db,
+		cfg:               cfg,
+		attendanceService: services.NewAttendanceService(db, cfg),
+	}
+}
+
+// clientAllowed lets LMS client tokens through only when they carry the given scope (write_attendance
+// to record, read_attendance to read); users are checked against the offering by the service
+func (h *AttendanceHandler) clientAllowed(c *fiber.Ctx, scope string) bool {
+	return c.Locals("user_type") != "client" || clientHasScope(c, scope)
+}
+
+// Submit records attendance for a lecture on a date in bulk (course lecturer, admin or LMS client)
+// POST /api/lectures/:id/attendance
+func (h *AttendanceHandler) Submit(c *fiber.Ctx) error {
+	lectureID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid lecture ID",
+		})
+	}
+	if !h.clientAllowed(c, "write_attendance") {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "this client token lacks the write_attendance scope",
+		})
+	}
+
+	var request services.AttendanceSubmission
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	session, results, err := h.attendanceService.Submit(uint(lectureID), request, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recorded := 0
+	for _, result := range results {
+		if result.Status == services.AttendanceRecorded {
+			recorded++
+		}
+	}
+
+	// Nothing written is a failure; some records rejected is a partial success
+	status, message := 200, "attendance recorded"
+	switch {
+	case recorded == 0:
+		status, message = 422, "no attendance was recorded"
+	case recorded < len(results):
+		status, message = 207, "some attendance records were not recorded"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message":  message,
+		"session":  session,
+		"recorded": recorded,
+		"failed":   len(results) - recorded,
+		"results":  results,
+	})
+}
+
+// GetLectureSessions lists the attendance sessions recorded for a lecture
+// GET /api/lectures/:id/attendance
+func (h *AttendanceHandler) GetLectureSessions(c *fiber.Ctx) error {
+	lectureID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid lecture ID",
+		})
+	}
+	if !h.clientAllowed(c, "read_attendance") {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "this client token lacks the read_attendance scope",
+		})
+	}
+
+	sessions, err := h.attendanceService.LectureSessions(uint(lectureID), requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"lecture_id": lectureID,
+		"sessions":   sessions,
+		"total":      len(sessions),
+	})
+}
+
+// GetSession returns an attendance session with its records
+// GET /api/attendance-sessions/:id
+func (h *AttendanceHandler) GetSession(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid session ID",
+		})
+	}
+	if !h.clientAllowed(c, "read_attendance") {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "this client token lacks the read_attendance scope",
+		})
+	}
+
+	session, err := h.attendanceService.Session(uint(sessionID), requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(session)
+}
+
+// GetOfferingReport returns an offering's sessions and every student's attendance percentage
+// GET /api/offerings/:id/attendance
+func (h *AttendanceHandler) GetOfferingReport(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+	if !h.clientAllowed(c, "read_attendance") {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "this client token lacks the read_attendance scope",
+		})
+	}
+
+	report, err := h.attendanceService.OfferingReport(uint(offeringID), requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetStudentAttendance returns a student's attendance percentage and exam eligibility per course
+// GET /api/students/:id/attendance?semester_id=
+func (h *AttendanceHandler) GetStudentAttendance(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	attendance, err := h.attendanceService.StudentAttendance(uint(studentID), uint(c.QueryInt("semester_id")))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"student_id": studentID,
+		"threshold":  h.attendanceService.Threshold(),
+		"courses":    attendance,
+	})
+}
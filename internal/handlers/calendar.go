@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type CalendarHandler struct {
+	db              *gorm.DB
+	cfg             *config.Config
+	calendarService *services.CalendarService
+}
+
+func NewCalendarHandler(db *gorm.DB, cfg *config.Config) *CalendarHandler {
+	return &CalendarHandler{
+		db:              db,
+		cfg:             cfg,
+		calendarService: services.NewCalendarService(db, cfg),
+	}
+}
+
+// Feed serves an ICS feed of one kind to calendar apps, authenticated by a feed token
+// GET /calendar/students/:id.ics?token=&semester_id=
+// GET /calendar/faculty/:id.ics?token=&semester_id=
+// GET /calendar/venues/:id.ics?token=&semester_id=
+// GET /calendar/courses/:code.ics?token=&semester_id=
+func (h *CalendarHandler) Feed(kind string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := h.calendarService.Authenticate(c.Query("token"))
+		if err != nil {
+			return c.Status(401).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		key := c.Params("id")
+		if kind == services.FeedCourse {
+			key = c.Params("code")
+		}
+
+		feed, err := h.calendarService.Feed(user, kind, key, uint(c.QueryInt("semester_id")))
+		if errors.Is(err, services.ErrFeedForbidden) {
+			return c.Status(403).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"%s-%s.ics\"", kind, key))
+		return c.Send(feed)
+	}
+}
+
+// GetFeeds returns the caller's calendar feed token and subscription URLs, issuing a token on first use
+// GET /api/calendar/token
+func (h *CalendarHandler) GetFeeds(c *fiber.Ctx) error {
+	actor := requestActor(c, services.SourceREST)
+	if actor.UserID == 0 || actor.UserType == "client" {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "calendar feeds belong to users",
+		})
+	}
+
+	feeds, err := h.calendarService.Feeds(actor.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(feeds)
+}
+
+// RotateToken replaces the caller's calendar feed token; existing subscriptions stop updating
+// POST /api/calendar/token
+func (h *CalendarHandler) RotateToken(c *fiber.Ctx) error {
+	actor := requestActor(c, services.SourceREST)
+	if actor.UserID == 0 || actor.UserType == "client" {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "calendar feeds belong to users",
+		})
+	}
+
+	feeds, err := h.calendarService.RotateToken(actor.UserID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(feeds)
+}
+
+// RevokeToken deletes the caller's calendar feed token
+// DELETE /api/calendar/token
+func (h *CalendarHandler) RevokeToken(c *fiber.Ctx) error {
+	actor := requestActor(c, services.SourceREST)
+	if err := h.calendarService.RevokeToken(actor.UserID); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "calendar feed token revoked",
+	})
+}
+
+// ListHolidays lists holidays, within a semester when semester_id is given
+// GET /api/holidays?semester_id=
+func (h *CalendarHandler) ListHolidays(c *fiber.Ctx) error {
+	holidays, err := h.calendarService.Holidays(uint(c.QueryInt("semester_id")))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"holidays": holidays,
+		"total":    len(holidays),
+	})
+}
+
+// CreateHoliday adds a holiday on which no lectures are held (admin)
+// POST /api/holidays
+func (h *CalendarHandler) CreateHoliday(c *fiber.Ctx) error {
+	var request services.HolidayInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	holiday, err := h.calendarService.CreateHoliday(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(holiday)
+}
+
+// DeleteHoliday removes a holiday (admin)
+// DELETE /api/holidays/:id
+func (h *CalendarHandler) DeleteHoliday(c *fiber.Ctx) error {
+	holidayID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid holiday ID",
+		})
+	}
+
+	if err := h.calendarService.DeleteHoliday(uint(holidayID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "holiday deleted",
+	})
+}
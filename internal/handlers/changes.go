@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type ChangeHandler struct {
+	db                *gorm.DB
+	cfg               *config.Config
+	changeFeedService *services.ChangeFeedService
+}
+
+func NewChangeHandler(db *gorm.DB, cfg *config.Config) *ChangeHandler {
+	return &ChangeHandler{
+		db:                db,
+		cfg:               cfg,
+		changeFeedService: services.NewChangeFeedService(db, cfg),
+	}
+}
+
+// List returns entity changes after a cursor for consumers that poll instead of receiving webhooks
+// GET /api/changes?since=<cursor>&entities=enrollment,grade&limit=100
+func (h *ChangeHandler) List(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	var entities []string
+	if raw := c.Query("entities"); raw != "" {
+		for _, entity := range strings.Split(raw, ",") {
+			if entity = strings.TrimSpace(entity); entity != "" {
+				entities = append(entities, entity)
+			}
+		}
+	}
+
+	page, err := h.changeFeedService.GetChanges(c.Query("since"), entities, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"changes":     page.Changes,
+		"total":       len(page.Changes),
+		"next_cursor": page.NextCursor,
+		"has_more":    page.HasMore,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,16 +11,20 @@ import (
 )
 
 type CourseHandler struct {
-	db            *gorm.DB
-	cfg           *config.Config
-	courseService *services.CourseService
+	db                  *gorm.DB
+	cfg                 *config.Config
+	courseService       *services.CourseService
+	prerequisiteService *services.PrerequisiteService
+	waitlistService     *services.WaitlistService
 }
 
 func NewCourseHandler(db *gorm.DB, cfg *config.Config) *CourseHandler {
 	return &CourseHandler{
-		db:            db,
-		cfg:           cfg,
-		courseService: services.NewCourseService(db, cfg),
+		db:                  db,
+		cfg:                 cfg,
+		courseService:       services.NewCourseService(db, cfg),
+		prerequisiteService: services.NewPrerequisiteService(db, cfg),
+		waitlistService:     services.NewWaitlistService(db, cfg),
 	}
 }
 
@@ -80,6 +85,14 @@ func (h *CourseHandler) Get(c *fiber.Ctx) error {
 		})
 	}
 
+	// Prerequisite graph (transitive requirements and dependent courses)
+	graph, err := h.prerequisiteService.GetPrerequisiteGraph(course)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Build response
 	return c.JSON(fiber.Map{
 		"code":        course.Code,
@@ -95,6 +108,7 @@ func (h *CourseHandler) Get(c *fiber.Ctx) error {
 			"name": course.Department.College.Name,
 			"code": course.Department.College.Code,
 		},
+		"prerequisites": graph,
 	})
 }
 
@@ -165,3 +179,282 @@ func (h *CourseHandler) GetStudents(c *fiber.Ctx) error {
 		"total":       len(students),
 	})
 }
+
+// Create adds a course to the catalogue
+// POST /api/courses
+func (h *CourseHandler) Create(c *fiber.Ctx) error {
+	var request services.CourseInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	course, err := h.courseService.CreateCourse(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "course created successfully",
+		"code":    course.Code,
+	})
+}
+
+// Update changes catalogue fields of a course
+// PUT /api/courses/:code
+func (h *CourseHandler) Update(c *fiber.Ctx) error {
+	var request services.CourseInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	course, err := h.courseService.UpdateCourse(c.Params("code"), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "course updated successfully",
+		"code":    course.Code,
+	})
+}
+
+// RescheduleLecture moves a lecture to a new day, time or venue
+// PUT /api/lectures/:id
+func (h *CourseHandler) RescheduleLecture(c *fiber.Ctx) error {
+	lectureID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid lecture ID",
+		})
+	}
+
+	var request services.RescheduleLectureInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	lecture, conflicts, err := h.courseService.RescheduleLecture(uint(lectureID), request)
+	if errors.Is(err, services.ErrTimetableConflicts) {
+		return c.Status(409).JSON(fiber.Map{
+			"error":     err.Error(),
+			"conflicts": conflicts,
+		})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "lecture rescheduled successfully",
+		"lecture_id": lecture.ID,
+		"day":        lecture.DayOfWeek,
+		"start_time": lecture.StartTime,
+		"end_time":   lecture.EndTime,
+		"venue_id":   lecture.VenueID,
+		"conflicts":  conflicts,
+	})
+}
+
+// CreateLecture schedules a new weekly lecture, rejecting timetable conflicts unless forced
+// POST /api/lectures
+func (h *CourseHandler) CreateLecture(c *fiber.Ctx) error {
+	var request services.LectureInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	lecture, conflicts, err := h.courseService.CreateLecture(request)
+	if errors.Is(err, services.ErrTimetableConflicts) {
+		return c.Status(409).JSON(fiber.Map{
+			"error":     err.Error(),
+			"conflicts": conflicts,
+		})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":   "lecture created successfully",
+		"lecture":   lecture,
+		"conflicts": conflicts,
+	})
+}
+
+// AssignLecturer assigns a lecturer to a course
+// POST /api/course-assignments
+func (h *CourseHandler) AssignLecturer(c *fiber.Ctx) error {
+	var request services.CourseAssignmentInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	assignment, err := h.courseService.AssignCourse(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":       "lecturer assigned successfully",
+		"assignment_id": assignment.ID,
+	})
+}
+
+// RemoveAssignment removes a lecturer's course assignment
+// DELETE /api/course-assignments/:id
+func (h *CourseHandler) RemoveAssignment(c *fiber.Ctx) error {
+	assignmentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid assignment ID",
+		})
+	}
+
+	if err := h.courseService.RemoveCourseAssignment(uint(assignmentID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "course assignment removed successfully",
+	})
+}
+
+// Delete removes a course from the catalogue
+// DELETE /api/courses/:code
+func (h *CourseHandler) Delete(c *fiber.Ctx) error {
+	if err := h.courseService.DeleteCourse(c.Params("code")); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "course deleted successfully",
+	})
+}
+
+// AddPrerequisite adds a prerequisite or corequisite to a course
+// POST /api/courses/:code/prerequisites
+func (h *CourseHandler) AddPrerequisite(c *fiber.Ctx) error {
+	var request services.PrerequisiteInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	prerequisite, err := h.prerequisiteService.AddPrerequisite(c.Params("code"), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(prerequisite)
+}
+
+// RemovePrerequisite removes a requirement from a course
+// DELETE /api/courses/:code/prerequisites/:id
+func (h *CourseHandler) RemovePrerequisite(c *fiber.Ctx) error {
+	prerequisiteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid prerequisite ID",
+		})
+	}
+
+	if err := h.prerequisiteService.RemovePrerequisite(c.Params("code"), uint(prerequisiteID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "prerequisite removed successfully",
+	})
+}
+
+// GetSeats returns the capacity, enrollment and waitlist counts of a course offering
+// GET /api/courses/:code/seats?semester_id=1
+func (h *CourseHandler) GetSeats(c *fiber.Ctx) error {
+	seats, err := h.waitlistService.CourseSeats(c.Params("code"), uint(c.QueryInt("semester_id", 0)))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(seats)
+}
+
+// SetCapacity sets a course offering's enrollment cap (null derives it from the venues)
+// PUT /api/courses/:code/capacity
+func (h *CourseHandler) SetCapacity(c *fiber.Ctx) error {
+	var request struct {
+		SemesterID uint `json:"semester_id"` // Defaults to the current semester
+		Capacity   *int `json:"capacity"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	seats, err := h.waitlistService.SetCapacity(c.Params("code"), request.SemesterID, request.Capacity)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(seats)
+}
+
+// GetWaitlist lists the students waiting for a course, in promotion order
+// GET /api/courses/:code/waitlist?semester_id=1
+func (h *CourseHandler) GetWaitlist(c *fiber.Ctx) error {
+	entries, err := h.waitlistService.CourseWaitlist(c.Params("code"), uint(c.QueryInt("semester_id", 0)))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var waitlist []fiber.Map
+	for i, entry := range entries {
+		waitlist = append(waitlist, fiber.Map{
+			"position":   i + 1,
+			"entry_id":   entry.ID,
+			"reg_number": entry.Student.RegNumber,
+			"name":       entry.Student.FirstName + " " + entry.Student.LastName,
+			"joined_at":  entry.JoinedAt,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"waitlist": waitlist,
+		"total":    len(waitlist),
+	})
+}
@@ -1118,7 +1118,7 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 			"get": map[string]interface{}{
 				"tags":        []string{"Students"},
 				"summary":     "Get student's grades",
-				"description": "Returns all grades for a student",
+				"description": "Returns a student's grades once their offering's results are published",
 				"security":    []map[string][]string{{"BearerAuth": {}}},
 				"parameters": []map[string]interface{}{
 					{
@@ -1172,6 +1172,143 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 				},
 			},
 		},
+		"/api/students/{id}/academic-record": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"Students"},
+				"summary":     "Get student's academic record",
+				"description": "Returns semester GPA, cumulative GPA, academic standing and degree class for every graded semester",
+				"security":    []map[string][]string{{"BearerAuth": {}}},
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "id",
+						"in":          "path",
+						"required":    true,
+						"description": "Student ID",
+						"schema":      map[string]string{"type": "integer"},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Academic record",
+					},
+					"404": map[string]interface{}{
+						"description": "Student not found",
+					},
+				},
+			},
+		},
+		"/api/students/{id}/attendance": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"Students"},
+				"summary":     "Get student's attendance",
+				"description": "Returns the student's lecture attendance percentage and final exam eligibility for each course of a semester",
+				"security":    []map[string][]string{{"BearerAuth": {}}},
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "id",
+						"in":          "path",
+						"required":    true,
+						"description": "Student ID",
+						"schema":      map[string]string{"type": "integer"},
+					},
+					{
+						"name":        "semester_id",
+						"in":          "query",
+						"required":    false,
+						"description": "Semester ID (defaults to the current semester)",
+						"schema":      map[string]string{"type": "integer"},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Attendance per course with the eligibility threshold",
+					},
+				},
+			},
+		},
+		"/api/students/{id}/exam-timetable": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"Students"},
+				"summary":     "Get student's exam timetable",
+				"description": "Returns the exam sessions of the student's courses with venue, seat number, eligibility and a flag for exams that overlap",
+				"security":    []map[string][]string{{"BearerAuth": {}}},
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "id",
+						"in":          "path",
+						"required":    true,
+						"description": "Student ID",
+						"schema":      map[string]string{"type": "integer"},
+					},
+					{
+						"name":        "semester_id",
+						"in":          "query",
+						"required":    false,
+						"description": "Semester ID (defaults to the current semester)",
+						"schema":      map[string]string{"type": "integer"},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Exam timetable with the number of clashing exams",
+					},
+				},
+			},
+		},
+		"/api/calendar/token": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"Students"},
+				"summary":     "Get calendar feed token",
+				"description": "Returns the caller's calendar feed token, issuing one on first use, with the URLs of their personal, course and venue ICS feeds",
+				"security":    []map[string][]string{{"BearerAuth": {}}},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Feed token and subscription URLs",
+					},
+				},
+			},
+		},
+		"/calendar/students/{id}.ics": map[string]interface{}{
+			"get": map[string]interface{}{
+				"tags":        []string{"Students"},
+				"summary":     "Subscribe to student timetable",
+				"description": "iCalendar feed of the student's weekly lectures, recurring over the semester except on holidays, and exam sessions. Authenticated by the student's feed token.",
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "id",
+						"in":          "path",
+						"required":    true,
+						"description": "Student ID",
+						"schema":      map[string]string{"type": "integer"},
+					},
+					{
+						"name":        "token",
+						"in":          "query",
+						"required":    true,
+						"description": "Calendar feed token from /api/calendar/token",
+						"schema":      map[string]string{"type": "string"},
+					},
+					{
+						"name":        "semester_id",
+						"in":          "query",
+						"required":    false,
+						"description": "Semester ID (defaults to the current semester)",
+						"schema":      map[string]string{"type": "integer"},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "text/calendar feed",
+					},
+					"401": map[string]interface{}{
+						"description": "Invalid feed token",
+					},
+					"403": map[string]interface{}{
+						"description": "The token belongs to another student",
+					},
+				},
+			},
+		},
 		"/api/faculty/me": map[string]interface{}{
 			"get": map[string]interface{}{
 				"tags":        []string{"Faculty"},
@@ -1228,7 +1365,7 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 			"post": map[string]interface{}{
 				"tags":        []string{"Faculty"},
 				"summary":     "Submit CA marks",
-				"description": "Submit Continuous Assessment marks for students",
+				"description": "Submit Continuous Assessment marks for students enrolled this semester; returns an outcome per student",
 				"security":    []map[string][]string{{"BearerAuth": {}}},
 				"parameters": []map[string]interface{}{
 					{
@@ -1251,20 +1388,110 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 				},
 				"responses": map[string]interface{}{
 					"200": map[string]interface{}{
-						"description": "CA marks submitted successfully",
+						"description": "All CA marks recorded",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"message":   map[string]string{"type": "string", "example": "CA marks submitted successfully"},
+										"course_id": map[string]string{"type": "integer"},
+										"updated":   map[string]string{"type": "integer"},
+										"failed":    map[string]string{"type": "integer"},
+										"results": map[string]interface{}{
+											"type": "array",
+											"items": map[string]interface{}{
+												"type": "object",
+												"properties": map[string]interface{}{
+													"student_id": map[string]string{"type": "integer"},
+													"ca_marks":   map[string]string{"type": "number"},
+													"status":     map[string]string{"type": "string", "example": "updated"},
+													"message":    map[string]string{"type": "string"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					"207": map[string]interface{}{
+						"description": "Some marks recorded; the rest are not_enrolled, no_offering, no_component or out_of_range",
+					},
+					"400": map[string]interface{}{
+						"description": "Not assigned to the course this semester, or results already submitted",
+					},
+					"422": map[string]interface{}{
+						"description": "No marks recorded",
+					},
+				},
+			},
+		},
+		"/api/faculty/courses/{id}/final-exam": map[string]interface{}{
+			"post": map[string]interface{}{
+				"tags":        []string{"Faculty"},
+				"summary":     "Submit final exam marks",
+				"description": "Submit final exam marks (or ABS, I, DQ markers); totals, letter grades and grade points are computed",
+				"security":    []map[string][]string{{"BearerAuth": {}}},
+				"parameters": []map[string]interface{}{
+					{
+						"name":        "id",
+						"in":          "path",
+						"required":    true,
+						"description": "Course ID",
+						"schema":      map[string]string{"type": "integer"},
+					},
+				},
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{
+								"$ref": "#/components/schemas/FinalExamRequest",
+							},
+						},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "All final exam marks recorded; each result carries the computed grade",
 						"content": map[string]interface{}{
 							"application/json": map[string]interface{}{
 								"schema": map[string]interface{}{
 									"type": "object",
 									"properties": map[string]interface{}{
-										"message":     map[string]string{"type": "string", "example": "CA marks submitted successfully"},
-										"course_id":   map[string]string{"type": "integer"},
-										"marks_count": map[string]string{"type": "integer"},
+										"message":   map[string]string{"type": "string", "example": "final exam marks submitted successfully"},
+										"course_id": map[string]string{"type": "integer"},
+										"recorded":  map[string]string{"type": "integer"},
+										"failed":    map[string]string{"type": "integer"},
+										"results": map[string]interface{}{
+											"type": "array",
+											"items": map[string]interface{}{
+												"type": "object",
+												"properties": map[string]interface{}{
+													"student_id": map[string]string{"type": "integer"},
+													"final_exam": map[string]string{"type": "number"},
+													"marker":     map[string]string{"type": "string"},
+													"status":     map[string]string{"type": "string", "example": "recorded"},
+													"message":    map[string]string{"type": "string"},
+													"grade":      map[string]string{"type": "object"},
+												},
+											},
+										},
 									},
 								},
 							},
 						},
 					},
+					"207": map[string]interface{}{
+						"description": "Some marks recorded; the rest are not_enrolled, no_offering, no_component or invalid",
+					},
+					"400": map[string]interface{}{
+						"description": "Not assigned to the course this semester, or results already submitted",
+					},
+					"422": map[string]interface{}{
+						"description": "No marks recorded",
+					},
 				},
 			},
 		},
@@ -1479,7 +1706,7 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 			"post": map[string]interface{}{
 				"tags":        []string{"Admin"},
 				"summary":     "Create bulk enrollments",
-				"description": "Creates multiple student enrollments",
+				"description": "Creates enrollments identified by reg_number, course_code and semester name, reporting a status per item (created, already_exists, invalid_student, invalid_course, invalid_semester, rule_violation, not_created)",
 				"security":    []map[string][]string{{"BearerAuth": {}}},
 				"requestBody": map[string]interface{}{
 					"required": true,
@@ -1492,20 +1719,9 @@ func (h *DocsHandler) getPaths() map[string]interface{} {
 					},
 				},
 				"responses": map[string]interface{}{
-					"200": map[string]interface{}{
-						"description": "Enrollments created successfully",
-						"content": map[string]interface{}{
-							"application/json": map[string]interface{}{
-								"schema": map[string]interface{}{
-									"type": "object",
-									"properties": map[string]interface{}{
-										"message": map[string]string{"type": "string", "example": "enrollments created successfully"},
-										"count":   map[string]string{"type": "integer"},
-									},
-								},
-							},
-						},
-					},
+					"200": bulkEnrollmentResponse("All items created or already existing"),
+					"207": bulkEnrollmentResponse("Best-effort batch with some failed items"),
+					"422": bulkEnrollmentResponse("All-or-nothing batch rolled back"),
 				},
 			},
 		},
@@ -1656,6 +1872,22 @@ func (h *DocsHandler) getComponents() map[string]interface{} {
 					},
 				},
 			},
+			"FinalExamRequest": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"marks": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"student_id": map[string]interface{}{"type": "integer"},
+								"final_exam": map[string]interface{}{"type": "number"},
+								"marker":     map[string]interface{}{"type": "string", "enum": []string{"ABS", "I", "DQ"}},
+							},
+						},
+					},
+				},
+			},
 			"CoursesListResponse": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -1733,9 +1965,46 @@ func (h *DocsHandler) getComponents() map[string]interface{} {
 			"BulkEnrollmentRequest": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
+					"mode": map[string]interface{}{
+						"type":    "string",
+						"enum":    []string{"best_effort", "all_or_nothing"},
+						"default": "best_effort",
+					},
 					"enrollments": map[string]interface{}{
-						"type":  "array",
-						"items": map[string]string{"type": "object"},
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"reg_number", "course_code"},
+							"properties": map[string]interface{}{
+								"reg_number":  map[string]string{"type": "string", "example": "22100523050001"},
+								"course_code": map[string]string{"type": "string", "example": "CS101"},
+								"semester":    map[string]string{"type": "string", "example": "2024/2025 Semester I", "description": "Defaults to the current semester"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// bulkEnrollmentResponse documents the per-item bulk enrollment result
+func bulkEnrollmentResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"mode":      map[string]string{"type": "string"},
+						"committed": map[string]string{"type": "boolean"},
+						"created":   map[string]string{"type": "integer"},
+						"failed":    map[string]string{"type": "integer"},
+						"items": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]string{"type": "object"},
+						},
 					},
 				},
 			},
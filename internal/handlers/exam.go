@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type ExamHandler struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	examService *services.ExamService
+}
+
+func NewExamHandler(db *gorm.DB, cfg *config.Config) *ExamHandler {
+	return &ExamHandler{
+		db:          db,
+		cfg:         cfg,
+		examService: services.NewExamService(db, cfg),
+	}
+}
+
+// ListSessions lists a course offering's exam sessions with their venues
+// GET /api/offerings/:id/exam-sessions
+func (h *ExamHandler) ListSessions(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	sessions, err := h.examService.ForOffering(uint(offeringID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"offering_id": offeringID,
+		"sessions":    sessions,
+		"total":       len(sessions),
+	})
+}
+
+// CreateSession schedules an exam session for a course offering and seats its students (admin)
+// POST /api/offerings/:id/exam-sessions
+func (h *ExamHandler) CreateSession(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	var request services.ExamSessionInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	result, err := h.examService.Create(uint(offeringID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(result)
+}
+
+// GetSession returns an exam session with its venues and seating plan
+// GET /api/exam-sessions/:id
+func (h *ExamHandler) GetSession(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid exam session ID",
+		})
+	}
+
+	session, err := h.examService.Get(uint(sessionID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(session)
+}
+
+// UpdateSession reschedules an exam session or changes its venues and seats its students again (admin)
+// PUT /api/exam-sessions/:id
+func (h *ExamHandler) UpdateSession(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid exam session ID",
+		})
+	}
+
+	var request services.ExamSessionInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	result, err := h.examService.Update(uint(sessionID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// AllocateSeats seats an exam session's students again, e.g. after late registrations (admin)
+// POST /api/exam-sessions/:id/allocate
+func (h *ExamHandler) AllocateSeats(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid exam session ID",
+		})
+	}
+
+	result, err := h.examService.Allocate(uint(sessionID))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// DeleteSession removes an exam session with its seating plan (admin)
+// DELETE /api/exam-sessions/:id
+func (h *ExamHandler) DeleteSession(c *fiber.Ctx) error {
+	sessionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid exam session ID",
+		})
+	}
+
+	if err := h.examService.Delete(uint(sessionID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "exam session deleted",
+	})
+}
+
+// GetClashes lists students with two overlapping exams in a semester (admin)
+// GET /api/semesters/:id/exam-clashes
+func (h *ExamHandler) GetClashes(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	clashes, err := h.examService.Clashes(uint(semesterID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"semester_id": semesterID,
+		"clashes":     clashes,
+		"total":       len(clashes),
+	})
+}
+
+// GetStudentTimetable returns a student's exams with venues and seat numbers
+// GET /api/students/:id/exam-timetable?semester_id=
+func (h *ExamHandler) GetStudentTimetable(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	exams, err := h.examService.StudentTimetable(uint(studentID), uint(c.QueryInt("semester_id")))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	clashes := 0
+	for _, exam := range exams {
+		if exam.Clash {
+			clashes++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"student_id": studentID,
+		"exams":      exams,
+		"clashes":    clashes,
+	})
+}
@@ -129,47 +129,120 @@ func (h *FacultyHandler) SubmitCAMarks(c *fiber.Ctx) error {
 		})
 	}
 
-	// Parse request body
-	type CAMarkEntry struct {
-		StudentID uint    `json:"student_id"`
-		CAMarks   float64 `json:"ca_marks"`
+	var request struct {
+		Marks []services.CAMarkEntry `json:"marks"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if len(request.Marks) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "marks are required",
+		})
 	}
 
-	var request struct {
-		Marks []CAMarkEntry `json:"marks"`
+	// Submit CA marks
+	results, err := h.facultyService.SubmitCAMarks(faculty.ID, uint(courseID), request.Marks, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	updated := 0
+	for _, result := range results {
+		if result.Status == services.CAMarkUpdated {
+			updated++
+		}
+	}
+
+	// Nothing written is a failure; some marks rejected is a partial success
+	status, message := 200, "CA marks submitted successfully"
+	switch {
+	case updated == 0:
+		status, message = 422, "no CA marks were recorded"
+	case updated < len(results):
+		status, message = 207, "some CA marks were not recorded"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message":   message,
+		"course_id": courseID,
+		"updated":   updated,
+		"failed":    len(results) - updated,
+		"results":   results,
+	})
+}
+
+// SubmitFinalExam submits final exam marks (or ABS, I, DQ markers) and returns the computed grades
+// POST /api/faculty/courses/:id/final-exam
+func (h *FacultyHandler) SubmitFinalExam(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	faculty, err := h.facultyService.GetFacultyByUserID(userID.(uint))
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "not a faculty member",
+		})
+	}
+
+	courseID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid course ID",
+		})
 	}
 
+	var request struct {
+		Marks []services.FinalExamMark `json:"marks"`
+	}
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "invalid request body",
 		})
 	}
 
-	// Convert to service format
-	var marks []struct {
-		StudentID uint
-		CAMarks   float64
-	}
-	for _, entry := range request.Marks {
-		marks = append(marks, struct {
-			StudentID uint
-			CAMarks   float64
-		}{
-			StudentID: entry.StudentID,
-			CAMarks:   entry.CAMarks,
+	if len(request.Marks) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "marks are required",
 		})
 	}
 
-	// Submit CA marks
-	if err := h.facultyService.SubmitCAMarks(faculty.ID, uint(courseID), marks); err != nil {
-		return c.Status(500).JSON(fiber.Map{
+	results, err := h.facultyService.SubmitFinalExam(faculty.ID, uint(courseID), request.Marks, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "CA marks submitted successfully",
+	recorded := 0
+	for _, result := range results {
+		if result.Status == services.FinalExamRecorded {
+			recorded++
+		}
+	}
+
+	// Nothing written is a failure; some marks rejected is a partial success
+	status, message := 200, "final exam marks submitted successfully"
+	switch {
+	case recorded == 0:
+		status, message = 422, "no final exam marks were recorded"
+	case recorded < len(results):
+		status, message = 207, "some final exam marks were not recorded"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message":   message,
 		"course_id": courseID,
-		"marks_count": len(marks),
+		"recorded":  recorded,
+		"failed":    len(results) - recorded,
+		"results":   results,
 	})
 }
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type GradeHandler struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	changeService *services.GradeChangeService
+}
+
+func NewGradeHandler(db *gorm.DB, cfg *config.Config) *GradeHandler {
+	return &GradeHandler{
+		db:            db,
+		cfg:           cfg,
+		changeService: services.NewGradeChangeService(db, cfg),
+	}
+}
+
+// GetHistory returns every recorded change of a grade (admin, course lecturer or head of department)
+// GET /api/grades/:id/history
+func (h *GradeHandler) GetHistory(c *fiber.Ctx) error {
+	gradeID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid grade ID",
+		})
+	}
+	if err := h.changeService.CanView(uint(gradeID), requestActor(c, services.SourceREST)); err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	history, err := h.changeService.History(uint(gradeID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"grade_id": gradeID,
+		"history":  history,
+		"total":    len(history),
+	})
+}
+
+// GetChangeRequests lists a grade's change requests
+// GET /api/grades/:id/change-requests
+func (h *GradeHandler) GetChangeRequests(c *fiber.Ctx) error {
+	gradeID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid grade ID",
+		})
+	}
+	if err := h.changeService.CanView(uint(gradeID), requestActor(c, services.SourceREST)); err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	requests, err := h.changeService.ForGrade(uint(gradeID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"grade_id": gradeID,
+		"requests": requests,
+		"total":    len(requests),
+	})
+}
+
+// RequestChange files a change request for a published grade (course lecturer or admin)
+// POST /api/grades/:id/change-requests
+func (h *GradeHandler) RequestChange(c *fiber.Ctx) error {
+	gradeID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid grade ID",
+		})
+	}
+
+	var request services.GradeChangeInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	change, err := h.changeService.Request(uint(gradeID), request, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "grade change requested",
+		"request": change,
+	})
+}
+
+// ListChangeRequests lists grade change requests, optionally by status (?status=pending)
+// GET /api/grade-change-requests
+func (h *GradeHandler) ListChangeRequests(c *fiber.Ctx) error {
+	requests, err := h.changeService.List(c.Query("status"), requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"requests": requests,
+		"total":    len(requests),
+	})
+}
+
+// ApproveChange approves a grade change request and applies it (head of department or admin)
+// POST /api/grade-change-requests/:id/approve
+func (h *GradeHandler) ApproveChange(c *fiber.Ctx) error {
+	return h.decide(c, true)
+}
+
+// RejectChange rejects a grade change request with a comment (head of department or admin)
+// POST /api/grade-change-requests/:id/reject
+func (h *GradeHandler) RejectChange(c *fiber.Ctx) error {
+	return h.decide(c, false)
+}
+
+func (h *GradeHandler) decide(c *fiber.Ctx, approve bool) error {
+	requestID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request ID",
+		})
+	}
+
+	var request struct {
+		Comment string `json:"comment"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	change, err := h.changeService.Decide(uint(requestID), approve, requestActor(c, services.SourceREST), request.Comment)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "grade change " + change.Status,
+		"request": change,
+	})
+}
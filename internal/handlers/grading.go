@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type GradingHandler struct {
+	db             *gorm.DB
+	cfg            *config.Config
+	gradingService *services.GradingService
+}
+
+func NewGradingHandler(db *gorm.DB, cfg *config.Config) *GradingHandler {
+	return &GradingHandler{
+		db:             db,
+		cfg:            cfg,
+		gradingService: services.NewGradingService(db, cfg),
+	}
+}
+
+// List returns every grading scheme with its grade bands
+// GET /api/grading-schemes
+func (h *GradingHandler) List(c *fiber.Ctx) error {
+	schemes, err := h.gradingService.ListSchemes()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"schemes": schemes,
+		"total":   len(schemes),
+	})
+}
+
+// Get returns a grading scheme by code
+// GET /api/grading-schemes/:code
+func (h *GradingHandler) Get(c *fiber.Ctx) error {
+	scheme, err := h.gradingService.GetScheme(c.Params("code"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(scheme)
+}
+
+// Save creates or replaces a grading scheme and its bands
+// PUT /api/grading-schemes/:code
+func (h *GradingHandler) Save(c *fiber.Ctx) error {
+	var request services.GradingSchemeInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	scheme, err := h.gradingService.SaveScheme(c.Params("code"), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "grading scheme saved successfully",
+		"scheme":  scheme,
+	})
+}
+
+// GetForProgram returns the grading scheme that applies to a program
+// GET /api/programs/:code/grading-scheme
+func (h *GradingHandler) GetForProgram(c *fiber.Ctx) error {
+	scheme, err := h.gradingService.SchemeForProgramCode(c.Params("code"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(scheme)
+}
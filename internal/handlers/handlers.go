@@ -7,22 +7,48 @@ import (
 
 // Handlers aggregates all handler groups
 type Handlers struct {
-	OAuth   *OAuthHandler
-	Student *StudentHandler
-	Faculty *FacultyHandler
-	Course  *CourseHandler
-	Admin   *AdminHandler
-	Docs    *DocsHandler
+	OAuth        *OAuthHandler
+	Student      *StudentHandler
+	Registration *RegistrationHandler
+	Faculty      *FacultyHandler
+	Course       *CourseHandler
+	Offering     *OfferingHandler
+	Grading      *GradingHandler
+	Assessment   *AssessmentHandler
+	Results      *ResultsWorkflowHandler
+	Grade        *GradeHandler
+	Attendance   *AttendanceHandler
+	Exam         *ExamHandler
+	Timetable    *TimetableHandler
+	Calendar     *CalendarHandler
+	Admin        *AdminHandler
+	Webhook      *WebhookHandler
+	Change       *ChangeHandler
+	Stream       *StreamHandler
+	Docs         *DocsHandler
 }
 
 // New creates a new Handlers instance
 func New(db *gorm.DB, cfg *config.Config) *Handlers {
 	return &Handlers{
-		OAuth:   NewOAuthHandler(db, cfg),
-		Student: NewStudentHandler(db, cfg),
-		Faculty: NewFacultyHandler(db, cfg),
-		Course:  NewCourseHandler(db, cfg),
-		Admin:   NewAdminHandler(db, cfg),
-		Docs:    NewDocsHandler(),
+		OAuth:        NewOAuthHandler(db, cfg),
+		Student:      NewStudentHandler(db, cfg),
+		Registration: NewRegistrationHandler(db, cfg),
+		Faculty:      NewFacultyHandler(db, cfg),
+		Course:       NewCourseHandler(db, cfg),
+		Offering:     NewOfferingHandler(db, cfg),
+		Grading:      NewGradingHandler(db, cfg),
+		Assessment:   NewAssessmentHandler(db, cfg),
+		Results:      NewResultsWorkflowHandler(db, cfg),
+		Grade:        NewGradeHandler(db, cfg),
+		Attendance:   NewAttendanceHandler(db, cfg),
+		Exam:         NewExamHandler(db, cfg),
+		Timetable:    NewTimetableHandler(db, cfg),
+		Calendar:     NewCalendarHandler(db, cfg),
+		Admin:        NewAdminHandler(db, cfg),
+		Webhook:      NewWebhookHandler(db, cfg),
+		Change:       NewChangeHandler(db, cfg),
+		Stream:       NewStreamHandler(db, cfg),
+		Docs:         NewDocsHandler(),
 	}
 }
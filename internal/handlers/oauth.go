@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -89,6 +90,8 @@ func (h *OAuthHandler) Token(c *fiber.Ctx) error {
 		return h.handleAuthorizationCodeGrant(c)
 	case "refresh_token":
 		return h.handleRefreshTokenGrant(c)
+	case "client_credentials":
+		return h.handleClientCredentialsGrant(c)
 	default:
 		return c.Status(400).JSON(fiber.Map{
 			"error": "unsupported_grant_type",
@@ -155,6 +158,39 @@ func (h *OAuthHandler) handleRefreshTokenGrant(c *fiber.Ctx) error {
 	})
 }
 
+// handleClientCredentialsGrant issues a token to a client acting on its own behalf (no user)
+func (h *OAuthHandler) handleClientCredentialsGrant(c *fiber.Ctx) error {
+	clientID := c.FormValue("client_id")
+	clientSecret := c.FormValue("client_secret")
+
+	if clientID == "" || clientSecret == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid_request",
+		})
+	}
+
+	token, err := h.oauthService.IssueClientCredentialsToken(clientID, clientSecret, c.FormValue("scope"))
+	if errors.Is(err, services.ErrInvalidScope) {
+		return c.Status(400).JSON(fiber.Map{
+			"error":             "invalid_scope",
+			"error_description": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error":             "invalid_client",
+			"error_description": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"access_token": token.Token,
+		"token_type":   "Bearer",
+		"expires_in":   int(time.Until(token.ExpiresAt).Seconds()),
+		"scope":        token.Scopes,
+	})
+}
+
 // getLoginPageHTML returns the SIMS-style login page HTML
 func (h *OAuthHandler) getLoginPageHTML(clientID, redirectURI, state string) string {
 	return `
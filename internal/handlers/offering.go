@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type OfferingHandler struct {
+	db              *gorm.DB
+	cfg             *config.Config
+	offeringService *services.OfferingService
+}
+
+func NewOfferingHandler(db *gorm.DB, cfg *config.Config) *OfferingHandler {
+	return &OfferingHandler{
+		db:              db,
+		cfg:             cfg,
+		offeringService: services.NewOfferingService(db, cfg),
+	}
+}
+
+// List returns the course offerings of a semester with their sections
+// GET /api/offerings?semester_id=1&course=CS101
+func (h *OfferingHandler) List(c *fiber.Ctx) error {
+	offerings, err := h.offeringService.ListOfferings(uint(c.QueryInt("semester_id", 0)), c.Query("course"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"offerings": offerings,
+		"total":     len(offerings),
+	})
+}
+
+// Get returns an offering with its sections, lecturers and lectures
+// GET /api/offerings/:id
+func (h *OfferingHandler) Get(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	offering, err := h.offeringService.GetOffering(uint(offeringID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(offering)
+}
+
+// CreateSection adds a section (stream) to an offering
+// POST /api/offerings/:id/sections
+func (h *OfferingHandler) CreateSection(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	var request services.SectionInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	section, err := h.offeringService.CreateSection(uint(offeringID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(section)
+}
+
+// UpdateSection changes a section's name, program stream or capacity
+// PUT /api/sections/:id
+func (h *OfferingHandler) UpdateSection(c *fiber.Ctx) error {
+	sectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid section ID",
+		})
+	}
+
+	var request services.SectionInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	section, err := h.offeringService.UpdateSection(uint(sectionID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(section)
+}
+
+// DeleteSection removes an empty section
+// DELETE /api/sections/:id
+func (h *OfferingHandler) DeleteSection(c *fiber.Ctx) error {
+	sectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid section ID",
+		})
+	}
+
+	if err := h.offeringService.DeleteSection(uint(sectionID)); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "section deleted successfully",
+	})
+}
+
+// GetSectionStudents returns a section's roster
+// GET /api/sections/:id/students
+func (h *OfferingHandler) GetSectionStudents(c *fiber.Ctx) error {
+	sectionID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid section ID",
+		})
+	}
+
+	section, enrollments, err := h.offeringService.SectionRoster(uint(sectionID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var students []fiber.Map
+	for _, enrollment := range enrollments {
+		students = append(students, fiber.Map{
+			"enrollment_id": enrollment.ID,
+			"student_id":    enrollment.StudentID,
+			"reg_number":    enrollment.Student.RegNumber,
+			"name":          enrollment.Student.FirstName + " " + enrollment.Student.LastName,
+			"program":       enrollment.Student.Program.Code,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"section":      section.Code,
+		"course":       section.Offering.Course.Code,
+		"semester":     section.Offering.Semester.Name,
+		"lms_shell_id": services.LMSShellID(&section.Offering, section),
+		"students":     students,
+		"total":        len(students),
+	})
+}
+
+// MoveEnrollment moves an enrollment to another section of its offering
+// PUT /api/enrollments/:id/section
+func (h *OfferingHandler) MoveEnrollment(c *fiber.Ctx) error {
+	enrollmentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid enrollment ID",
+		})
+	}
+
+	var request struct {
+		SectionID uint `json:"section_id"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.SectionID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "section_id is required",
+		})
+	}
+
+	enrollment, err := h.offeringService.MoveEnrollment(uint(enrollmentID), request.SectionID, requestActor(c, services.SourceREST))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "enrollment moved successfully",
+		"enrollment": enrollment,
+	})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type RegistrationHandler struct {
+	db                  *gorm.DB
+	cfg                 *config.Config
+	studentService      *services.StudentService
+	registrationService *services.RegistrationService
+	waitlistService     *services.WaitlistService
+}
+
+func NewRegistrationHandler(db *gorm.DB, cfg *config.Config) *RegistrationHandler {
+	return &RegistrationHandler{
+		db:                  db,
+		cfg:                 cfg,
+		studentService:      services.NewStudentService(db, cfg),
+		registrationService: services.NewRegistrationService(db, cfg),
+		waitlistService:     services.NewWaitlistService(db, cfg),
+	}
+}
+
+// currentStudentID resolves the authenticated student
+func (h *RegistrationHandler) currentStudentID(c *fiber.Ctx) (uint, error) {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return 0, errors.New("unauthorized")
+	}
+
+	student, err := h.studentService.GetStudentByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return student.ID, nil
+}
+
+// GetAvailableCourses lists program courses open for registration this semester
+// GET /api/students/me/registration
+func (h *RegistrationHandler) GetAvailableCourses(c *fiber.Ctx) error {
+	studentID, err := h.currentStudentID(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	semester, courses, err := h.registrationService.GetAvailableCourses(studentID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"semester": fiber.Map{
+			"id":                     semester.ID,
+			"name":                   semester.Name,
+			"phase":                  services.RegistrationPhase(semester, time.Now()),
+			"registration_opens_at":  semester.RegistrationOpensAt,
+			"registration_closes_at": semester.RegistrationClosesAt,
+			"add_drop_opens_at":      semester.AddDropOpensAt,
+			"add_drop_closes_at":     semester.AddDropClosesAt,
+		},
+		"courses": courses,
+		"total":   len(courses),
+	})
+}
+
+// Register registers the student for one or more courses
+// POST /api/students/me/registrations
+func (h *RegistrationHandler) Register(c *fiber.Ctx) error {
+	studentID, err := h.currentStudentID(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var request struct {
+		CourseCodes []string `json:"course_codes"`
+	}
+	if err := c.BodyParser(&request); err != nil || len(request.CourseCodes) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "course_codes is required",
+		})
+	}
+
+	results, err := h.registrationService.Register(studentID, request.CourseCodes)
+	if err != nil {
+		status := 400
+		if errors.Is(err, services.ErrRegistrationClosed) {
+			status = 403
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	return c.JSON(fiber.Map{
+		"results":    results,
+		"registered": counts[services.RegistrationRegistered],
+		"waitlisted": counts[services.RegistrationWaitlisted],
+		"rejected":   counts[services.RegistrationRejected],
+	})
+}
+
+// Drop drops a course for the current semester
+// DELETE /api/students/me/registrations/:code
+func (h *RegistrationHandler) Drop(c *fiber.Ctx) error {
+	studentID, err := h.currentStudentID(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	enrollment, err := h.registrationService.Drop(studentID, c.Params("code"))
+	if err != nil {
+		status := 400
+		if errors.Is(err, services.ErrRegistrationClosed) {
+			status = 403
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "course dropped successfully",
+		"enrollment": enrollment,
+	})
+}
+
+// GetWaitlist returns the student's waitlist positions
+// GET /api/students/me/waitlist
+func (h *RegistrationHandler) GetWaitlist(c *fiber.Ctx) error {
+	studentID, err := h.currentStudentID(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	positions, err := h.waitlistService.StudentPositions(studentID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"waitlist": positions,
+		"total":    len(positions),
+	})
+}
+
+// LeaveWaitlist removes the student from a course waitlist in the current semester
+// DELETE /api/students/me/waitlist/:code
+func (h *RegistrationHandler) LeaveWaitlist(c *fiber.Ctx) error {
+	studentID, err := h.currentStudentID(c)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.waitlistService.Leave(studentID, c.Params("code")); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "left waitlist successfully",
+	})
+}
+
+// SetRegistrationWindow configures a semester's registration and add/drop windows
+// PUT /api/semesters/:id/registration-window
+func (h *RegistrationHandler) SetRegistrationWindow(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	var request services.RegistrationWindowInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	semester, err := h.registrationService.SetRegistrationWindow(uint(semesterID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"semester": semester,
+		"phase":    services.RegistrationPhase(semester, time.Now()),
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type ResultsWorkflowHandler struct {
+	db              *gorm.DB
+	cfg             *config.Config
+	workflowService *services.ResultsWorkflowService
+}
+
+func NewResultsWorkflowHandler(db *gorm.DB, cfg *config.Config) *ResultsWorkflowHandler {
+	return &ResultsWorkflowHandler{
+		db:              db,
+		cfg:             cfg,
+		workflowService: services.NewResultsWorkflowService(db, cfg),
+	}
+}
+
+// Get returns an offering's results workflow status and approval history
+// GET /api/offerings/:id/results-workflow
+func (h *ResultsWorkflowHandler) Get(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	workflow, err := h.workflowService.Get(uint(offeringID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(workflow)
+}
+
+// Transition submits, approves, rejects or publishes an offering's results
+// POST /api/offerings/:id/results-workflow/:action
+func (h *ResultsWorkflowHandler) Transition(c *fiber.Ctx) error {
+	offeringID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid offering ID",
+		})
+	}
+
+	actor := requestActor(c, services.SourceWorkflow)
+	if actor.UserType != "admin" && actor.UserType != "faculty" {
+		return c.Status(403).JSON(fiber.Map{
+			"error": "only lecturers and administrators can act on results",
+		})
+	}
+
+	var request struct {
+		Comment string `json:"comment"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	workflow, err := h.workflowService.Transition(uint(offeringID), c.Params("action"), actor, request.Comment)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "results " + workflow.Status,
+		"workflow": workflow,
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// streamHeartbeat keeps idle connections open through proxies
+const streamHeartbeat = 15 * time.Second
+
+type StreamHandler struct {
+	db                *gorm.DB
+	cfg               *config.Config
+	changeFeedService *services.ChangeFeedService
+}
+
+func NewStreamHandler(db *gorm.DB, cfg *config.Config) *StreamHandler {
+	return &StreamHandler{
+		db:                db,
+		cfg:               cfg,
+		changeFeedService: services.NewChangeFeedService(db, cfg),
+	}
+}
+
+// SSE streams live SIMS events as Server-Sent Events
+// GET /api/events/stream?events=enrollment.*,grade.submitted
+// Resume with the Last-Event-ID header (or last_event_id query parameter).
+func (h *StreamHandler) SSE(c *fiber.Ctx) error {
+	filter := services.NewEventFilter(c.Query("events"))
+	lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+
+	// Subscribe before replaying so nothing published in between is lost
+	live, unsubscribe := services.Broker().Subscribe()
+
+	afterID, upTo, err := h.changeFeedService.ReplayRange(lastEventID)
+	if err != nil {
+		unsubscribe()
+		if errors.Is(err, services.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid Last-Event-ID",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		lastSent := upTo
+		fmt.Fprintf(w, "retry: 3000\n\n")
+		if w.Flush() != nil {
+			return
+		}
+		err := h.changeFeedService.ReplayEvents(afterID, upTo, filter, func(change services.Change) error {
+			return writeSSE(w, change)
+		})
+		if err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case change, ok := <-live:
+				if !ok {
+					return
+				}
+				if change.Sequence <= lastSent || !filter.Matches(change.Event) {
+					continue
+				}
+				if writeSSE(w, change) != nil {
+					return
+				}
+				lastSent = change.Sequence
+			case <-heartbeat.C:
+				fmt.Fprintf(w, ": ping\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	}))
+
+	return nil
+}
+
+// writeSSE writes one event in SSE wire format and flushes it
+func writeSSE(w *bufio.Writer, change services.Change) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.Cursor, change.Event, body)
+	return w.Flush()
+}
+
+// UpgradeWebSocket rejects plain HTTP requests to the WebSocket endpoint
+func (h *StreamHandler) UpgradeWebSocket(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(426).JSON(fiber.Map{
+			"error": "websocket upgrade required",
+		})
+	}
+	c.Locals("events", c.Query("events"))
+	c.Locals("last_event_id", c.Query("last_event_id"))
+	return c.Next()
+}
+
+// WebSocket streams the same events as SSE, one JSON message per event
+// GET /api/events/ws?events=enrollment.*&last_event_id=<cursor>
+func (h *StreamHandler) WebSocket() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		events, _ := conn.Locals("events").(string)
+		lastEventID, _ := conn.Locals("last_event_id").(string)
+		filter := services.NewEventFilter(events)
+
+		live, unsubscribe := services.Broker().Subscribe()
+		defer unsubscribe()
+
+		afterID, upTo, err := h.changeFeedService.ReplayRange(lastEventID)
+		if err != nil {
+			conn.WriteJSON(fiber.Map{"error": err.Error()})
+			return
+		}
+
+		// Detect client disconnects (the client never needs to send anything)
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		lastSent := upTo
+		err = h.changeFeedService.ReplayEvents(afterID, upTo, filter, func(change services.Change) error {
+			return conn.WriteJSON(change)
+		})
+		if err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case change, ok := <-live:
+				if !ok {
+					return
+				}
+				if change.Sequence <= lastSent || !filter.Matches(change.Event) {
+					continue
+				}
+				if conn.WriteJSON(change) != nil {
+					return
+				}
+				lastSent = change.Sequence
+			case <-heartbeat.C:
+				if conn.WriteMessage(websocket.PingMessage, nil) != nil {
+					return
+				}
+			}
+		}
+	})
+}
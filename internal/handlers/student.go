@@ -13,6 +13,7 @@ type StudentHandler struct {
 	db             *gorm.DB
 	cfg            *config.Config
 	studentService *services.StudentService
+	resultsService *services.ResultsService
 }
 
 func NewStudentHandler(db *gorm.DB, cfg *config.Config) *StudentHandler {
@@ -20,6 +21,7 @@ func NewStudentHandler(db *gorm.DB, cfg *config.Config) *StudentHandler {
 		db:             db,
 		cfg:            cfg,
 		studentService: services.NewStudentService(db, cfg),
+		resultsService: services.NewResultsService(db, cfg),
 	}
 }
 
@@ -42,10 +44,6 @@ func (h *StudentHandler) GetMe(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate current GPA
-	gpa, _ := h.studentService.CalculateGPA(student.ID)
-	student.GPA = gpa
-
 	// Build response matching real SIMS structure
 	return c.JSON(fiber.Map{
 		"student_id":   student.ID,
@@ -63,7 +61,7 @@ func (h *StudentHandler) GetMe(c *fiber.Ctx) error {
 			"college":    student.Program.Department.College.Name,
 		},
 		"year_of_study":      student.YearOfStudy,
-		"gpa":                gpa,
+		"gpa":                student.GPA,
 		"enrollment_status":  student.EnrollmentStatus,
 		"payment_status":     student.PaymentStatus,
 		"admission_year":     student.AdmissionYear,
@@ -143,9 +141,11 @@ func (h *StudentHandler) GetGrades(c *fiber.Ctx) error {
 			"total_marks":   grade.TotalMarks,
 			"letter_grade":  grade.LetterGrade,
 			"grade_point":   grade.GradePoint,
+			"outcome":       grade.Outcome,
 			"semester":      grade.Enrollment.Semester.Name,
 			"remark":        grade.Remarks,
 			"submitted_at":  grade.SubmittedAt,
+			"published_at":  grade.PublishedAt,
 		})
 	}
 
@@ -196,3 +196,137 @@ func (h *StudentHandler) GetTimetable(c *fiber.Ctx) error {
 		"semester":   "2024/2025 - Semester II",
 	})
 }
+
+// GetAcademicRecord returns a student's semester GPA, CGPA and standing history
+// GET /api/students/:id/academic-record
+func (h *StudentHandler) GetAcademicRecord(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	record, err := h.resultsService.GetAcademicRecord(uint(studentID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(record)
+}
+
+// Create registers a new student
+// POST /api/students
+func (h *StudentHandler) Create(c *fiber.Ctx) error {
+	var request services.CreateStudentInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	student, password, err := h.studentService.CreateStudent(request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := fiber.Map{
+		"message":    "student created successfully",
+		"student_id": student.ID,
+		"reg_number": student.RegNumber,
+	}
+	// A generated password is shown once and cannot be retrieved later
+	if password != "" {
+		response["initial_password"] = password
+	}
+	return c.Status(201).JSON(response)
+}
+
+// Update changes a student's profile
+// PUT /api/students/:id
+func (h *StudentHandler) Update(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	var request services.UpdateStudentInput
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	student, err := h.studentService.UpdateStudent(uint(studentID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "student updated successfully",
+		"student_id": student.ID,
+	})
+}
+
+// UpdateStatus changes a student's enrollment status
+// PUT /api/students/:id/status
+func (h *StudentHandler) UpdateStatus(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	var request struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	student, err := h.studentService.UpdateStudentStatus(uint(studentID), request.Status, request.Reason)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":           "student status updated successfully",
+		"student_id":        student.ID,
+		"enrollment_status": student.EnrollmentStatus,
+	})
+}
+
+// Delete removes a student record
+// DELETE /api/students/:id
+func (h *StudentHandler) Delete(c *fiber.Ctx) error {
+	studentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid student ID",
+		})
+	}
+
+	if err := h.studentService.DeleteStudent(uint(studentID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "student deleted successfully",
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type TimetableHandler struct {
+	db               *gorm.DB
+	cfg              *config.Config
+	timetableService *services.TimetableService
+}
+
+func NewTimetableHandler(db *gorm.DB, cfg *config.Config) *TimetableHandler {
+	return &TimetableHandler{
+		db:               db,
+		cfg:              cfg,
+		timetableService: services.NewTimetableService(db, cfg),
+	}
+}
+
+// Generate builds a semester's lecture timetable and optionally applies it (admin)
+// POST /api/semesters/:id/timetable/generate
+func (h *TimetableHandler) Generate(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	var request services.TimetableOptions
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	result, err := h.timetableService.Generate(uint(semesterID), request)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Lectures that cannot be placed make the timetable unusable as a whole
+	if len(result.Unsatisfied) > 0 {
+		return c.Status(422).JSON(result)
+	}
+	return c.JSON(result)
+}
+
+// GetConflicts audits a semester's lectures for venue, lecturer and student clashes and undersized venues (admin)
+// GET /api/semesters/:id/timetable/conflicts
+func (h *TimetableHandler) GetConflicts(c *fiber.Ctx) error {
+	semesterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid semester ID",
+		})
+	}
+
+	audit, err := h.timetableService.Conflicts(uint(semesterID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(audit)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/services"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	db             *gorm.DB
+	cfg            *config.Config
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(db *gorm.DB, cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{
+		db:             db,
+		cfg:            cfg,
+		webhookService: services.NewWebhookService(db, cfg),
+	}
+}
+
+// ListEvents returns the catalogue of webhook events with their JSON schemas
+// GET /webhooks/events
+func (h *WebhookHandler) ListEvents(c *fiber.Ctx) error {
+	events := services.EventCatalogue()
+
+	return c.JSON(fiber.Map{
+		"events": events,
+		"total":  len(events),
+		"headers": fiber.Map{
+			"X-SIMS-Signature":     "HMAC-SHA256 of the raw body using the shared webhook secret",
+			"X-SIMS-Event":         "Event type",
+			"X-SIMS-Event-Version": "Payload schema version",
+			"X-SIMS-Delivery":      "Unique event ID (same as payload id)",
+			"X-SIMS-Timestamp":     "RFC 3339 time the event was published",
+			"X-SIMS-Test":          "Present (\"true\") only on test-fire deliveries",
+		},
+	})
+}
+
+// GetEvent returns a single event definition
+// GET /webhooks/events/:type
+func (h *WebhookHandler) GetEvent(c *fiber.Ctx) error {
+	definition, ok := services.LookupEvent(c.Params("type"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "event type not found",
+		})
+	}
+
+	return c.JSON(definition)
+}
+
+// ListSubscriptions returns the configured LMS target plus every registered subscription
+// GET /api/webhooks/subscriptions
+func (h *WebhookHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subscriptions, err := h.webhookService.ListSubscriptions()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"default": fiber.Map{
+			"id":         services.DefaultSubscriptionID,
+			"url":        h.cfg.LMSWebhookURL,
+			"configured": h.cfg.LMSWebhookURL != "",
+		},
+		"subscriptions": subscriptions,
+		"total":         len(subscriptions),
+	})
+}
+
+// CreateSubscription registers an additional webhook receiver
+// POST /api/webhooks/subscriptions
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	var input services.SubscriptionInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(input)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The secret is only returned once, at creation time
+	return c.Status(201).JSON(fiber.Map{
+		"subscription": subscription,
+		"secret":       subscription.Secret,
+	})
+}
+
+// DeleteSubscription removes a webhook receiver
+// DELETE /api/webhooks/subscriptions/:id
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	subscriptionID, err := c.ParamsInt("id")
+	if err != nil || subscriptionID <= 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid subscription ID",
+		})
+	}
+
+	if err := h.webhookService.DeleteSubscription(uint(subscriptionID)); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Subscription deleted",
+	})
+}
+
+// TestSubscription fires a test event at one subscription and returns the receiver's response
+// POST /api/webhooks/subscriptions/:id/test  (use "default" for LMS_WEBHOOK_URL)
+func (h *WebhookHandler) TestSubscription(c *fiber.Ctx) error {
+	var input struct {
+		Event   string                 `json:"event"`
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if input.Event == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "event is required",
+		})
+	}
+
+	payload, result, err := h.webhookService.TestFire(c.Params("id"), input.Event, input.Payload)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"delivered":     result.Error == "",
+		"status_code":   result.StatusCode,
+		"latency_ms":    result.LatencyMs,
+		"response_body": result.ResponseBody,
+		"error":         result.Error,
+		"url":           result.URL,
+		"signature":     result.Signature,
+		"payload":       payload,
+	})
+}
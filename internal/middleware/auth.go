@@ -40,6 +40,14 @@ func AuthMiddleware(db *gorm.DB, cfg *config.Config) fiber.Handler {
 			})
 		}
 
+		// Client credential tokens act as the client itself
+		if user == nil {
+			c.Locals("user_type", "client")
+			c.Locals("client_id", accessToken.ClientID)
+			c.Locals("access_token", accessToken)
+			return c.Next()
+		}
+
 		// Store user and token info in context
 		c.Locals("user_id", user.ID)
 		c.Locals("user_email", user.Email)
@@ -50,6 +58,18 @@ func AuthMiddleware(db *gorm.DB, cfg *config.Config) fiber.Handler {
 	}
 }
 
+// StreamTokenQuery accepts the bearer token as ?access_token= on the live event streams, since browsers'
+// EventSource and WebSocket APIs cannot set headers. Mount it on the stream routes only, before
+// AuthMiddleware: tokens in URLs end up in access logs and browser history.
+func StreamTokenQuery() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" && c.Query("access_token") != "" {
+			c.Request().Header.Set("Authorization", "Bearer "+c.Query("access_token"))
+		}
+		return c.Next()
+	}
+}
+
 // RequireUserType middleware checks if user has specific type
 func RequireUserType(allowedTypes ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -72,3 +92,16 @@ func RequireUserType(allowedTypes ...string) fiber.Handler {
 		})
 	}
 }
+
+// RejectClients stops client credential tokens. Routes registered on the group before it stay open to
+// clients that pass their own guard; every route registered after it serves users only.
+func RejectClients() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals("user_type") == "client" {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "forbidden - client credential tokens cannot access this endpoint",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -21,6 +21,9 @@ type College struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Lecturer who approves results as Dean
+	DeanFacultyID *uint `gorm:"index" json:"dean_faculty_id"`
+
 	Departments []Department `gorm:"foreignKey:CollegeID" json:"departments,omitempty"`
 }
 
@@ -35,6 +38,9 @@ type Department struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Lecturer who approves results as Head of Department
+	HeadFacultyID *uint `gorm:"index" json:"head_faculty_id"`
+
 	College  College   `gorm:"foreignKey:CollegeID" json:"college,omitempty"`
 	Programs []Program `gorm:"foreignKey:DepartmentID" json:"programs,omitempty"`
 }
@@ -71,9 +77,24 @@ type Semester struct {
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Student self-registration windows (a nil window is closed)
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	AddDropOpensAt       *time.Time `json:"add_drop_opens_at"`
+	AddDropClosesAt      *time.Time `json:"add_drop_closes_at"`
+
 	Enrollments []Enrollment `gorm:"foreignKey:SemesterID" json:"enrollments,omitempty"`
 }
 
+// Holiday is a public or university holiday on which no lectures are held
+type Holiday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      time.Time `gorm:"type:date;uniqueIndex;not null" json:"date"`
+	Name      string    `gorm:"size:100;not null" json:"name"` // Union Day
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // Venue represents a classroom or lecture hall
 type Venue struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -179,11 +200,59 @@ type Course struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Department        Department         `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
-	Lectures          []Lecture          `gorm:"foreignKey:CourseID" json:"lectures,omitempty"`
-	Enrollments       []Enrollment       `gorm:"foreignKey:CourseID" json:"enrollments,omitempty"`
-	CourseAssignments []CourseAssignment `gorm:"foreignKey:CourseID" json:"course_assignments,omitempty"`
-	Programs          []Program          `gorm:"many2many:program_courses" json:"programs,omitempty"`
+	// Practical courses are timetabled in lab venues
+	RequiresLab bool `gorm:"default:false" json:"requires_lab"`
+
+	Department        Department           `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+	Lectures          []Lecture            `gorm:"foreignKey:CourseID" json:"lectures,omitempty"`
+	Enrollments       []Enrollment         `gorm:"foreignKey:CourseID" json:"enrollments,omitempty"`
+	CourseAssignments []CourseAssignment   `gorm:"foreignKey:CourseID" json:"course_assignments,omitempty"`
+	Programs          []Program            `gorm:"many2many:program_courses" json:"programs,omitempty"`
+	Prerequisites     []CoursePrerequisite `gorm:"foreignKey:CourseID" json:"prerequisites,omitempty"`
+}
+
+// ProgramCourse is the program_courses join row: when a course is taken in a program and whether it is compulsory
+type ProgramCourse struct {
+	ProgramID       uint      `gorm:"primaryKey" json:"program_id"`
+	CourseID        uint      `gorm:"primaryKey" json:"course_id"`
+	YearOfStudy     int       `gorm:"default:1" json:"year_of_study"`
+	SemesterNum     int       `gorm:"default:1" json:"semester_num"`
+	CourseType      string    `gorm:"size:20;default:'core'" json:"course_type"` // core, elective, optional
+	ElectiveGroupID *uint     `gorm:"index" json:"elective_group_id"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	Course        Course         `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	ElectiveGroup *ElectiveGroup `gorm:"foreignKey:ElectiveGroupID" json:"elective_group,omitempty"`
+}
+
+// ElectiveGroup is a set of elective courses from which a student must take at least MinCredits
+type ElectiveGroup struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ProgramID   uint           `gorm:"not null;index" json:"program_id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	YearOfStudy int            `gorm:"not null" json:"year_of_study"`
+	SemesterNum int            `gorm:"not null" json:"semester_num"`
+	MinCredits  int            `gorm:"not null" json:"min_credits"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// CoursePrerequisite links a course to a course that must be passed before (prerequisite)
+// or taken alongside (corequisite) it. Rows of a course sharing a non-zero GroupNo are
+// alternatives: passing any one of them satisfies the group.
+type CoursePrerequisite struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	CourseID         uint           `gorm:"not null;index" json:"course_id"`
+	RequiredCourseID uint           `gorm:"not null;index" json:"required_course_id"`
+	Type             string         `gorm:"size:20;not null;default:'prerequisite'" json:"type"` // prerequisite, corequisite
+	MinGrade         string         `gorm:"size:5" json:"min_grade"`                             // Empty means any passing grade
+	GroupNo          int            `gorm:"default:0" json:"group_no"`                           // 0 = required on its own
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	RequiredCourse Course `gorm:"foreignKey:RequiredCourseID" json:"required_course,omitempty"`
 }
 
 // Lecture represents a scheduled class/lecture
@@ -200,6 +269,9 @@ type Lecture struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Section (stream) of the course offering the lecture belongs to
+	SectionID *uint `gorm:"index" json:"section_id"`
+
 	Course   Course   `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	Faculty  Faculty  `gorm:"foreignKey:FacultyID" json:"faculty,omitempty"`
 	Semester Semester `gorm:"foreignKey:SemesterID" json:"semester,omitempty"`
@@ -218,6 +290,14 @@ type Enrollment struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Course offering and section the student is on the roster of
+	OfferingID *uint `gorm:"index" json:"offering_id"`
+	SectionID  *uint `gorm:"index" json:"section_id"`
+
+	// Attendance standing, refreshed whenever attendance is recorded for the offering
+	AttendanceRate *float64 `gorm:"type:decimal(5,2)" json:"attendance_rate"`
+	ExamIneligible bool     `gorm:"default:false;index" json:"exam_ineligible"` // Below the attendance threshold
+
 	Student  Student  `gorm:"foreignKey:StudentID" json:"student,omitempty"`
 	Course   Course   `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	Semester Semester `gorm:"foreignKey:SemesterID" json:"semester,omitempty"`
@@ -230,8 +310,8 @@ type Grade struct {
 	EnrollmentID uint           `gorm:"uniqueIndex;not null" json:"enrollment_id"`
 	StudentID    uint           `gorm:"not null;index" json:"student_id"`
 	CourseID     uint           `gorm:"not null;index" json:"course_id"`
-	CAMarks      float64        `gorm:"type:decimal(5,2)" json:"ca_marks"`         // Continuous Assessment (0-40)
-	FinalExam    float64        `gorm:"type:decimal(5,2)" json:"final_exam"`       // Final Exam (0-60)
+	CAMarks      float64        `gorm:"type:decimal(5,2)" json:"ca_marks"`         // Weighted coursework components (0-40 by default)
+	FinalExam    float64        `gorm:"type:decimal(5,2)" json:"final_exam"`       // Weighted final exam components (0-60 by default)
 	TotalMarks   float64        `gorm:"type:decimal(5,2)" json:"total_marks"`      // Total (0-100)
 	LetterGrade  string         `gorm:"size:5" json:"letter_grade"`                // A, B+, B, C, D, F
 	GradePoint   float64        `gorm:"type:decimal(3,2)" json:"grade_point"`      // 5.0, 4.0, 3.5, etc.
@@ -241,11 +321,182 @@ type Grade struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Course offering and section the grade was earned in
+	OfferingID *uint `gorm:"index" json:"offering_id"`
+	SectionID  *uint `gorm:"index" json:"section_id"`
+
+	// Special outcome instead of a marks-based grade: ABS (absent), I (incomplete), DQ (disqualified)
+	Outcome string `gorm:"size:5" json:"outcome"`
+
+	// Set when the offering's results are published; unpublished grades are provisional
+	PublishedAt *time.Time `gorm:"index" json:"published_at"`
+
 	Enrollment Enrollment `gorm:"foreignKey:EnrollmentID" json:"enrollment,omitempty"`
 	Student    Student    `gorm:"foreignKey:StudentID" json:"student,omitempty"`
 	Course     Course     `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 }
 
+// ResultsWorkflow moves an offering's results from draft through approval to publication
+type ResultsWorkflow struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	OfferingID     uint           `gorm:"uniqueIndex;not null" json:"offering_id"`
+	Status         string         `gorm:"size:20;default:'draft'" json:"status"` // draft, submitted, hod_approved, dean_approved, published
+	SubmittedAt    *time.Time     `json:"submitted_at"`
+	HODApprovedAt  *time.Time     `json:"hod_approved_at"`
+	DeanApprovedAt *time.Time     `json:"dean_approved_at"`
+	PublishedAt    *time.Time     `json:"published_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Offering CourseOffering         `gorm:"foreignKey:OfferingID" json:"-"`
+	Actions  []ResultsWorkflowAction `gorm:"foreignKey:WorkflowID" json:"actions,omitempty"`
+}
+
+// ResultsWorkflowAction records one step of a results workflow, who took it and why
+type ResultsWorkflowAction struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	WorkflowID uint      `gorm:"not null;index" json:"workflow_id"`
+	Action     string    `gorm:"size:20;not null" json:"action"` // submit, approve, reject, publish
+	FromStatus string    `gorm:"size:20" json:"from_status"`
+	ToStatus   string    `gorm:"size:20" json:"to_status"`
+	UserID     uint      `gorm:"index" json:"user_id"`
+	Role       string    `gorm:"size:20" json:"role"` // lecturer, hod, dean, senate, admin
+	Comment    string    `gorm:"type:text" json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GradeHistory is an append-only record of one Grade field change. Rows are never updated or deleted.
+type GradeHistory struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	GradeID         uint      `gorm:"not null;index" json:"grade_id"`
+	EnrollmentID    uint      `gorm:"index" json:"enrollment_id"`
+	StudentID       uint      `gorm:"index" json:"student_id"`
+	Field           string    `gorm:"size:30;not null" json:"field"`
+	OldValue        string    `gorm:"size:100" json:"old_value"`
+	NewValue        string    `gorm:"size:100" json:"new_value"`
+	ActorID         uint      `gorm:"index" json:"actor_id"`          // User ID; 0 for LMS clients and the system
+	ActorType       string    `gorm:"size:20" json:"actor_type"`      // admin, faculty, client, system
+	Source          string    `gorm:"size:20;not null" json:"source"` // rest, csv, lti, workflow, change_request, system
+	ChangeRequestID *uint     `gorm:"index" json:"change_request_id"`
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+}
+
+// GradeChangeRequest proposes new component scores for a published grade, pending approval
+type GradeChangeRequest struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	GradeID       uint           `gorm:"not null;index" json:"grade_id"`
+	RequestedBy   uint           `gorm:"not null;index" json:"requested_by"` // User ID
+	Reason        string         `gorm:"type:text;not null" json:"reason"`
+	EffectiveDate time.Time      `json:"effective_date"`                                // Informational; the change applies on approval
+	Status        string         `gorm:"size:20;default:'pending';index" json:"status"` // pending, approved, rejected
+	ApprovedBy    *uint          `json:"approved_by"`                                   // User ID of the approver or rejecter
+	ApproverRole  string         `gorm:"size:20" json:"approver_role"`
+	Comment       string         `gorm:"type:text" json:"comment"`
+	DecidedAt     *time.Time     `json:"decided_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Grade Grade             `gorm:"foreignKey:GradeID" json:"-"`
+	Items []GradeChangeItem `gorm:"foreignKey:RequestID" json:"items,omitempty"`
+}
+
+// GradeChangeItem is one component score a change request replaces
+type GradeChangeItem struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	RequestID   uint    `gorm:"not null;index" json:"request_id"`
+	ComponentID uint    `gorm:"not null" json:"component_id"`
+	OldScore    float64 `json:"old_score"`
+	OldMarker   string  `gorm:"size:5" json:"old_marker"`
+	NewScore    float64 `json:"new_score"`
+	NewMarker   string  `gorm:"size:5" json:"new_marker"`
+}
+
+// AssessmentComponent is one weighted assessment of a course offering (test, assignment, lab, ...)
+type AssessmentComponent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OfferingID uint      `gorm:"uniqueIndex:idx_component_offering_name;not null" json:"offering_id"`
+	Name       string    `gorm:"uniqueIndex:idx_component_offering_name;size:100;not null" json:"name"`
+	Type       string    `gorm:"size:20;not null" json:"type"`       // test, assignment, lab, project, quiz, ca, final_exam
+	Weight     float64   `gorm:"type:decimal(5,2)" json:"weight"`    // Share of the 100-mark total
+	MaxMarks   float64   `gorm:"type:decimal(6,2)" json:"max_marks"` // Raw scores are out of this
+	Position   int       `gorm:"default:0" json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	Offering CourseOffering `gorm:"foreignKey:OfferingID" json:"-"`
+}
+
+// AssessmentScore is a student's raw score in one assessment component
+type AssessmentScore struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ComponentID  uint      `gorm:"uniqueIndex:idx_score_component_enrollment;not null" json:"component_id"`
+	EnrollmentID uint      `gorm:"uniqueIndex:idx_score_component_enrollment;not null;index" json:"enrollment_id"`
+	StudentID    uint      `gorm:"not null;index" json:"student_id"`
+	Score        float64   `gorm:"type:decimal(6,2)" json:"score"`
+	Marker       string    `gorm:"size:5" json:"marker"` // ABS, I or DQ instead of a score
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Component AssessmentComponent `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+}
+
+// GradingScheme maps total marks to letter grades and grade points for a program level
+type GradingScheme struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Code            string         `gorm:"uniqueIndex;size:20;not null" json:"code"` // UG, PG, NTA6
+	Name            string         `gorm:"size:100;not null" json:"name"`
+	DegreeLevel     string         `gorm:"size:50;index" json:"degree_level"`         // Empty matches any degree level
+	NTALevel        int            `gorm:"index" json:"nta_level"`                    // 0 matches any NTA level
+	PassMark        float64        `gorm:"type:decimal(5,2)" json:"pass_mark"`        // Minimum total marks for a pass
+	IsDefault       bool           `gorm:"default:false" json:"is_default"`           // Used when no scheme matches a program
+	MinCGPA         float64        `gorm:"type:decimal(3,2)" json:"min_cgpa"`         // Probation below this CGPA (0: RESULTS_MIN_CGPA)
+	DiscontinueCGPA float64        `gorm:"type:decimal(3,2)" json:"discontinue_cgpa"` // Discontinued below this CGPA (0: RESULTS_DISCONTINUE_CGPA)
+	DegreeClasses   string         `gorm:"type:text" json:"degree_classes"`           // Class:minimum CGPA, comma-separated (empty: RESULTS_DEGREE_CLASSES)
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Bands []GradeBand `gorm:"foreignKey:SchemeID" json:"bands,omitempty"`
+}
+
+// GradeBand is one letter grade of a grading scheme: totals from MinMarks up to the next band
+type GradeBand struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	SchemeID   uint      `gorm:"uniqueIndex:idx_band_scheme_letter;not null" json:"scheme_id"`
+	Letter     string    `gorm:"uniqueIndex:idx_band_scheme_letter;size:5;not null" json:"letter"`
+	MinMarks   float64   `gorm:"type:decimal(5,2)" json:"min_marks"`
+	GradePoint float64   `gorm:"type:decimal(3,2)" json:"grade_point"`
+	Remark     string    `gorm:"size:20" json:"remark"` // PASS, SUPP, RETAKE
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SemesterResult is a student's computed GPA and academic standing for one semester
+type SemesterResult struct {
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	StudentID         uint           `gorm:"uniqueIndex:idx_result_student_semester;not null" json:"student_id"`
+	SemesterID        uint           `gorm:"uniqueIndex:idx_result_student_semester;not null;index" json:"semester_id"`
+	CreditsAttempted  int            `gorm:"not null;default:0" json:"credits_attempted"`
+	CreditsEarned     int            `gorm:"not null;default:0" json:"credits_earned"`
+	QualityPoints     float64        `gorm:"type:decimal(7,2)" json:"quality_points"` // Sum of grade point x credits
+	GPA               float64        `gorm:"type:decimal(3,2)" json:"gpa"`
+	CumulativeCredits int            `gorm:"not null;default:0" json:"cumulative_credits"`
+	CumulativeEarned  int            `gorm:"not null;default:0" json:"cumulative_earned"` // Credits passed so far, each course's best attempt once
+	CumulativePoints  float64        `gorm:"type:decimal(8,2)" json:"cumulative_points"`
+	CGPA              float64        `gorm:"type:decimal(3,2)" json:"cgpa"`
+	FailedCourses     int            `gorm:"not null;default:0" json:"failed_courses"`
+	Standing          string         `gorm:"size:20" json:"standing"`     // pass, supplementary, probation, discontinued
+	DegreeClass       string         `gorm:"size:50" json:"degree_class"` // Classification of the CGPA, e.g. Upper Second Class
+	ComputedAt        time.Time      `json:"computed_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Student  Student  `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Semester Semester `gorm:"foreignKey:SemesterID" json:"semester,omitempty"`
+}
+
 // CourseAssignment represents faculty assignment to teach a course
 type CourseAssignment struct {
 	ID         uint           `gorm:"primaryKey" json:"id"`
@@ -257,11 +508,135 @@ type CourseAssignment struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Section taught; nil assigns the lecturer to every section of the offering
+	SectionID *uint `gorm:"index" json:"section_id"`
+
 	Course   Course   `gorm:"foreignKey:CourseID" json:"course,omitempty"`
 	Faculty  Faculty  `gorm:"foreignKey:FacultyID" json:"faculty,omitempty"`
 	Semester Semester `gorm:"foreignKey:SemesterID" json:"semester,omitempty"`
 }
 
+// CourseOffering is a course taught in a semester
+type CourseOffering struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	CourseID   uint           `gorm:"not null;uniqueIndex:idx_offering_course_semester" json:"course_id"`
+	SemesterID uint           `gorm:"not null;uniqueIndex:idx_offering_course_semester" json:"semester_id"`
+	Capacity   *int           `json:"capacity"` // Explicit cap; nil derives it from the lecture venues
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Course   Course          `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Semester Semester        `gorm:"foreignKey:SemesterID" json:"semester,omitempty"`
+	Sections []CourseSection `gorm:"foreignKey:OfferingID" json:"sections,omitempty"`
+}
+
+// CourseSection is a stream of a course offering with its own lecturers, lectures, capacity and roster
+type CourseSection struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	OfferingID uint           `gorm:"not null;uniqueIndex:idx_section_offering_code" json:"offering_id"`
+	Code       string         `gorm:"size:20;not null;uniqueIndex:idx_section_offering_code" json:"code"` // A, B, CS, ICT
+	Name       string         `gorm:"size:100" json:"name"`
+	ProgramID  *uint          `gorm:"index" json:"program_id"` // Stream for one program; nil is open to all
+	Capacity   *int           `json:"capacity"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Offering CourseOffering `gorm:"foreignKey:OfferingID" json:"offering,omitempty"`
+	Program  *Program       `gorm:"foreignKey:ProgramID" json:"program,omitempty"`
+}
+
+// WaitlistEntry is a student waiting for a seat in a full course offering
+type WaitlistEntry struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	OfferingID uint           `gorm:"not null;index" json:"offering_id"`
+	StudentID  uint           `gorm:"not null;index" json:"student_id"`
+	Status     string         `gorm:"size:20;default:'waiting';index" json:"status"` // waiting, promoted, cancelled, expired
+	Reason     string         `gorm:"size:255" json:"reason,omitempty"`              // Why an entry expired
+	JoinedAt   time.Time      `gorm:"not null" json:"joined_at"`
+	PromotedAt *time.Time     `json:"promoted_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Offering CourseOffering `gorm:"foreignKey:OfferingID" json:"offering,omitempty"`
+	Student  Student        `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+}
+
+// AttendanceSession is one occurrence of a lecture on a specific date
+type AttendanceSession struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	LectureID  uint           `gorm:"not null;uniqueIndex:idx_attendance_lecture_date" json:"lecture_id"`
+	Date       time.Time      `gorm:"type:date;not null;uniqueIndex:idx_attendance_lecture_date" json:"date"`
+	OfferingID uint           `gorm:"not null;index" json:"offering_id"`
+	SectionID  *uint          `gorm:"index" json:"section_id"` // Copied from the lecture; nil is every section
+	Topic      string         `gorm:"size:255" json:"topic"`
+	RecordedBy uint           `gorm:"index" json:"recorded_by"`       // User ID; 0 for LMS clients
+	Source     string         `gorm:"size:20;not null" json:"source"` // rest, lti
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Lecture Lecture            `gorm:"foreignKey:LectureID" json:"lecture,omitempty"`
+	Records []AttendanceRecord `gorm:"foreignKey:SessionID" json:"records,omitempty"`
+}
+
+// AttendanceRecord is a student's attendance at one session
+type AttendanceRecord struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	SessionID    uint      `gorm:"not null;uniqueIndex:idx_attendance_session_student" json:"session_id"`
+	StudentID    uint      `gorm:"not null;uniqueIndex:idx_attendance_session_student;index" json:"student_id"`
+	EnrollmentID uint      `gorm:"not null;index" json:"enrollment_id"`
+	Status       string    `gorm:"size:10;not null" json:"status"` // present, absent, late, excused
+	Note         string    `gorm:"size:255" json:"note,omitempty"`
+	RecordedBy   uint      `json:"recorded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ExamSession is a sitting of a course offering's examination
+type ExamSession struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	OfferingID      uint           `gorm:"not null;index" json:"offering_id"`
+	SemesterID      uint           `gorm:"not null;index" json:"semester_id"`
+	Title           string         `gorm:"size:100" json:"title"` // Final Examination, Paper II
+	Date            time.Time      `gorm:"type:date;not null;index" json:"date"`
+	StartTime       string         `gorm:"size:10;not null" json:"start_time"` // 09:00
+	EndTime         string         `gorm:"size:10;not null" json:"end_time"`   // Start time plus the duration
+	DurationMinutes int            `gorm:"not null" json:"duration_minutes"`
+	Notes           string         `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Offering CourseOffering `gorm:"foreignKey:OfferingID" json:"offering,omitempty"`
+	Venues   []ExamVenue    `gorm:"foreignKey:ExamSessionID" json:"venues,omitempty"`
+}
+
+// ExamVenue is a venue an exam session sits in and the number of exam seats it provides
+type ExamVenue struct {
+	ID            uint `gorm:"primaryKey" json:"id"`
+	ExamSessionID uint `gorm:"not null;uniqueIndex:idx_exam_venue_session" json:"exam_session_id"`
+	VenueID       uint `gorm:"not null;uniqueIndex:idx_exam_venue_session;index" json:"venue_id"`
+	Capacity      int  `gorm:"not null" json:"capacity"` // At most the venue's capacity
+	Position      int  `gorm:"default:0" json:"position"`
+
+	Venue Venue      `gorm:"foreignKey:VenueID" json:"venue,omitempty"`
+	Seats []ExamSeat `gorm:"foreignKey:ExamVenueID" json:"seats,omitempty"`
+}
+
+// ExamSeat is a student's numbered seat in an exam session
+type ExamSeat struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ExamSessionID uint      `gorm:"not null;uniqueIndex:idx_exam_seat_student" json:"exam_session_id"`
+	ExamVenueID   uint      `gorm:"not null;uniqueIndex:idx_exam_seat_number" json:"exam_venue_id"`
+	SeatNumber    int       `gorm:"not null;uniqueIndex:idx_exam_seat_number" json:"seat_number"`
+	StudentID     uint      `gorm:"not null;uniqueIndex:idx_exam_seat_student;index" json:"student_id"`
+	EnrollmentID  uint      `gorm:"not null;index" json:"enrollment_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // ============================================================================
 // OAUTH 2.0
 // ============================================================================
@@ -307,22 +682,63 @@ type OAuthAccessToken struct {
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// CalendarToken lets calendar apps, which cannot send bearer tokens, subscribe to a user's ICS feeds
+type CalendarToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Token      string     `gorm:"uniqueIndex;size:64;not null" json:"token"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
 // ============================================================================
 // WEBHOOKS & PAYMENTS
 // ============================================================================
 
 // WebhookLog represents a webhook delivery attempt
 type WebhookLog struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Event          string         `gorm:"size:100;not null;index" json:"event"` // enrollment.created, grade.submitted, etc.
+	URL            string         `gorm:"size:500;not null" json:"url"`
+	SubscriptionID *uint          `gorm:"index" json:"subscription_id"` // nil for the LMS_WEBHOOK_URL target
+	StatusCode     int            `gorm:"index" json:"status_code"`
+	Error          *string        `gorm:"type:text" json:"error"`
+	Payload        string         `gorm:"type:text" json:"-"` // Signed body, kept so failed deliveries can be retried verbatim
+	Attempt        int            `gorm:"default:1" json:"attempt"`
+	RetriedAt      *time.Time     `json:"retried_at"`
+	SentAt         time.Time      `gorm:"not null;index" json:"sent_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookSubscription is an additional webhook receiver registered by an admin
+type WebhookSubscription struct {
 	ID         uint           `gorm:"primaryKey" json:"id"`
-	Event      string         `gorm:"size:100;not null;index" json:"event"` // enrollment.created, grade.submitted, etc.
+	Name       string         `gorm:"size:100;not null" json:"name"`
 	URL        string         `gorm:"size:500;not null" json:"url"`
-	StatusCode int            `gorm:"index" json:"status_code"`
-	Error      *string        `gorm:"type:text" json:"error"`
-	SentAt     time.Time      `gorm:"not null;index" json:"sent_at"`
+	Secret     string         `gorm:"size:255;not null" json:"-"`
+	EventTypes string         `gorm:"type:text" json:"event_types"` // Comma-separated patterns; empty means all events
+	IsActive   bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// DomainEvent is the persisted record of every published event (backs the change feed)
+type DomainEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EventID    string    `gorm:"uniqueIndex;size:36;not null" json:"event_id"`
+	Event      string    `gorm:"size:100;not null;index" json:"event"`
+	Version    int       `gorm:"not null" json:"version"`
+	EntityType string    `gorm:"size:50;not null;index" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index" json:"entity_id"`
+	Tombstone  bool      `gorm:"default:false" json:"tombstone"`
+	Payload    string    `gorm:"type:text;not null" json:"payload"` // Full webhook envelope as JSON
+	OccurredAt time.Time `gorm:"not null;index" json:"occurred_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // Payment represents a student's tuition payment
 type Payment struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
@@ -150,13 +150,93 @@ var courseSeeds = []courseSeed{
 	{"ENG6304", "Language and Society", 6, 300, "ENG", "Sociolinguistics and discourse analysis.", []string{"MB040"}},
 }
 
+type electiveGroupSeed struct {
+	ProgramCode string
+	Name        string
+	YearOfStudy int
+	SemesterNum int
+	MinCredits  int
+	CourseCodes []string
+}
+
+// electiveGroupSeeds turn some program courses into electives; students pick at least MinCredits
+var electiveGroupSeeds = []electiveGroupSeed{
+	{"MB011", "Systems Electives", 2, 2, 6, []string{"CS6214", "CS6215", "CS6216"}},
+	{"MB011", "Advanced Computing Electives", 3, 2, 6, []string{"CS6302", "CS6303"}},
+	{"MD010", "Electronics Electives", 2, 2, 6, []string{"CS6204", "CS6213", "CS6216"}},
+	{"MB015", "Finance Electives", 3, 1, 6, []string{"BA6306", "BA6205"}},
+}
+
+// optionalCourseSeeds are program courses that do not count towards graduation requirements
+var optionalCourseSeeds = map[string][]string{
+	"MB015": {"CS6106"},
+	"MB040": {"ENG6202"},
+}
+
 var lectureStartSlots = []string{"08:00", "10:00", "14:00", "16:00"}
 var lectureEndSlots = []string{"10:00", "12:00", "16:00", "18:00"}
 var lectureDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
 
+// publicHolidaySeeds are Tanzania's fixed-date public holidays (month, day, name); movable feasts
+// such as Eid and Easter are added per year through the holidays API
+var publicHolidaySeeds = []struct {
+	Month int
+	Day   int
+	Name  string
+}{
+	{1, 1, "New Year's Day"},
+	{1, 12, "Zanzibar Revolution Day"},
+	{4, 7, "Karume Day"},
+	{4, 26, "Union Day"},
+	{5, 1, "Workers' Day"},
+	{7, 7, "Saba Saba"},
+	{8, 8, "Nane Nane"},
+	{10, 14, "Nyerere Day"},
+	{12, 9, "Independence Day"},
+	{12, 25, "Christmas Day"},
+	{12, 26, "Boxing Day"},
+}
+
 var paymentDescriptions = []string{
 	"Tuition Fee", "Administrative Fee", "Accommodation Fee", "Meals Fee", "Library Fee", "ICT Service Fee",
 }
 
 var paymentMethods = []string{"Bank", "M-Pesa", "Tigo Pesa", "Airtel Money"}
 var paymentStatuses = []string{"completed", "partial", "pending"}
+
+type prerequisiteSeed struct {
+	CourseCode   string
+	RequiredCode string
+	Type         string
+	MinGrade     string
+	GroupNo      int
+}
+
+// prerequisiteSeeds link catalogue courses. Rows sharing a non-zero group are "any of" alternatives.
+var prerequisiteSeeds = []prerequisiteSeed{
+	{"CS6202", "CS6103", "prerequisite", "C", 0},
+	{"CS6210", "CS6103", "prerequisite", "", 0},
+	{"CS6207", "CS6103", "prerequisite", "", 1},
+	{"CS6207", "CS6104", "prerequisite", "", 1},
+	{"CS6213", "CS6205", "corequisite", "", 0},
+	{"CS6214", "CS6205", "prerequisite", "", 0},
+	{"MS6227", "CS6105", "prerequisite", "", 0},
+	{"CS6301", "CS6201", "prerequisite", "", 0},
+	{"CS6301", "CS6203", "prerequisite", "", 0},
+	{"CS6302", "CS6203", "prerequisite", "", 1},
+	{"CS6302", "CS6201", "prerequisite", "", 1},
+	{"CS6303", "CS6105", "prerequisite", "B", 0},
+	{"CS6303", "CS6210", "prerequisite", "", 0},
+	{"BA6204", "BA6101", "prerequisite", "", 1},
+	{"BA6204", "BA6102", "prerequisite", "", 1},
+	{"BA6205", "BA6204", "prerequisite", "", 0},
+	{"ACC6201", "BA6101", "prerequisite", "C", 0},
+	{"ACC6202", "ACC6201", "corequisite", "", 0},
+	{"ME6204", "ME6102", "prerequisite", "", 0},
+	{"ME6306", "ME6204", "prerequisite", "", 0},
+	{"CE6203", "CE6101", "prerequisite", "", 0},
+	{"CE6304", "CE6203", "prerequisite", "", 0},
+	{"BIO6303", "BIO6201", "prerequisite", "", 0},
+	{"ENG6202", "ENG6105", "prerequisite", "", 0},
+	{"ENG6304", "ENG6105", "prerequisite", "C", 0},
+}
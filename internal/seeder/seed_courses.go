@@ -1,10 +1,12 @@
 package seeder
 
 import (
+	"log"
 	"strings"
 	"time"
 
 	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/mwombeki6/mock-sims/internal/services"
 )
 
 // SeedCourses persists catalogue courses and attaches them to their programs.
@@ -39,8 +41,7 @@ func (s *Seeder) SeedCourses() error {
 		}
 
 		if len(seed.ProgramCodes) > 0 {
-			assoc := s.db.Model(&course).Association("Programs")
-			if err := assoc.Clear(); err != nil {
+			if err := s.db.Where("course_id = ?", course.ID).Delete(&models.ProgramCourse{}).Error; err != nil {
 				return err
 			}
 			for _, programCode := range seed.ProgramCodes {
@@ -48,8 +49,17 @@ func (s *Seeder) SeedCourses() error {
 				if !ok {
 					continue
 				}
-				p := program
-				_ = assoc.Append(&p)
+				// Default placement: year from the course level, semester from the code's last digit
+				entry := models.ProgramCourse{
+					ProgramID:   program.ID,
+					CourseID:    course.ID,
+					YearOfStudy: defaultYearOfStudy(course.Level),
+					SemesterNum: defaultSemesterNum(course.Code),
+					CourseType:  "core",
+				}
+				if err := s.db.Create(&entry).Error; err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -57,6 +67,134 @@ func (s *Seeder) SeedCourses() error {
 	return nil
 }
 
+// SeedCurriculum applies elective groups and optional courses on top of the default core placement.
+func (s *Seeder) SeedCurriculum() error {
+	programMap, err := s.programMap()
+	if err != nil {
+		return err
+	}
+
+	var courses []models.Course
+	if err := s.db.Find(&courses).Error; err != nil {
+		return err
+	}
+	courseIDs := make(map[string]uint, len(courses))
+	for _, course := range courses {
+		courseIDs[course.Code] = course.ID
+	}
+
+	for _, seed := range electiveGroupSeeds {
+		program, ok := programMap[seed.ProgramCode]
+		if !ok {
+			continue
+		}
+
+		group := models.ElectiveGroup{
+			ProgramID:   program.ID,
+			Name:        seed.Name,
+			YearOfStudy: seed.YearOfStudy,
+			SemesterNum: seed.SemesterNum,
+			MinCredits:  seed.MinCredits,
+		}
+		if err := s.db.Where(models.ElectiveGroup{ProgramID: program.ID, Name: seed.Name}).
+			Assign(group).FirstOrCreate(&group).Error; err != nil {
+			return err
+		}
+
+		for _, code := range seed.CourseCodes {
+			courseID, ok := courseIDs[code]
+			if !ok {
+				continue
+			}
+			if err := s.db.Model(&models.ProgramCourse{}).
+				Where("program_id = ? AND course_id = ?", program.ID, courseID).
+				Updates(map[string]interface{}{
+					"course_type":       "elective",
+					"elective_group_id": group.ID,
+					"year_of_study":     seed.YearOfStudy,
+					"semester_num":      seed.SemesterNum,
+				}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for programCode, codes := range optionalCourseSeeds {
+		program, ok := programMap[programCode]
+		if !ok {
+			continue
+		}
+		for _, code := range codes {
+			courseID, ok := courseIDs[code]
+			if !ok {
+				continue
+			}
+			if err := s.db.Model(&models.ProgramCourse{}).
+				Where("program_id = ? AND course_id = ?", program.ID, courseID).
+				Update("course_type", "optional").Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultYearOfStudy places a course in the year matching its level (100 -> year 1)
+func defaultYearOfStudy(level int) int {
+	if level < 100 {
+		return 1
+	}
+	return level / 100
+}
+
+// defaultSemesterNum places odd-numbered course codes in semester I and even ones in semester II
+func defaultSemesterNum(code string) int {
+	if code == "" {
+		return 1
+	}
+	if (code[len(code)-1]-'0')%2 == 1 {
+		return 1
+	}
+	return 2
+}
+
+// SeedPrerequisites links catalogue courses to the courses they require.
+func (s *Seeder) SeedPrerequisites() error {
+	var courses []models.Course
+	if err := s.db.Find(&courses).Error; err != nil {
+		return err
+	}
+	courseIDs := make(map[string]uint, len(courses))
+	for _, course := range courses {
+		courseIDs[course.Code] = course.ID
+	}
+
+	for _, seed := range prerequisiteSeeds {
+		courseID, ok := courseIDs[seed.CourseCode]
+		requiredID, requiredOK := courseIDs[seed.RequiredCode]
+		if !ok || !requiredOK {
+			continue
+		}
+
+		prerequisite := models.CoursePrerequisite{
+			CourseID:         courseID,
+			RequiredCourseID: requiredID,
+			Type:             seed.Type,
+			MinGrade:         seed.MinGrade,
+			GroupNo:          seed.GroupNo,
+		}
+		if err := s.db.FirstOrCreate(&prerequisite, models.CoursePrerequisite{
+			CourseID:         courseID,
+			RequiredCourseID: requiredID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SeedLectures generates lecture schedules for the current semester using seeded faculties and venues.
 func (s *Seeder) SeedLectures() error {
 	var currentSemester models.Semester
@@ -135,6 +273,24 @@ func (s *Seeder) SeedLectures() error {
 	return nil
 }
 
+// SeedTimetable replaces the placeholder lecture slots with a generated timetable once sections and
+// enrollments exist. Sections that cannot be placed keep their seeded slots.
+func (s *Seeder) SeedTimetable() error {
+	var currentSemester models.Semester
+	if err := s.db.Where("is_current = ?", true).First(&currentSemester).Error; err != nil {
+		return nil
+	}
+
+	result, err := services.NewTimetableService(s.db, s.cfg).Generate(currentSemester.ID, services.TimetableOptions{Apply: true, Partial: true})
+	if err != nil {
+		return err
+	}
+	for _, issue := range result.Unsatisfied {
+		log.Printf("  timetable: %s %s not placed (%s): %s", issue.CourseCode, issue.Section, issue.Constraint, issue.Message)
+	}
+	return nil
+}
+
 // SeedEnrollments registers students into program courses for current and previous semesters.
 func (s *Seeder) SeedEnrollments() error {
 	var currentSemester models.Semester
@@ -153,38 +309,40 @@ func (s *Seeder) SeedEnrollments() error {
 		return err
 	}
 
-	var courses []models.Course
-	if err := s.db.Preload("Programs").Find(&courses).Error; err != nil {
+	var entries []models.ProgramCourse
+	if err := s.db.Preload("Course").Preload("ElectiveGroup").
+		Order("program_id, year_of_study, semester_num, course_id").
+		Find(&entries).Error; err != nil {
 		return err
 	}
 
-	programBuckets := make(map[uint]map[int][]models.Course)
-	for _, course := range courses {
-		for _, program := range course.Programs {
-			bucket := programBuckets[program.ID]
-			if bucket == nil {
-				bucket = make(map[int][]models.Course)
-			}
-			bucket[course.Level] = append(bucket[course.Level], course)
-			programBuckets[program.ID] = bucket
-		}
+	curricula := make(map[uint][]models.ProgramCourse)
+	for _, entry := range entries {
+		curricula[entry.ProgramID] = append(curricula[entry.ProgramID], entry)
 	}
 
 	for _, student := range students {
-		bucket := programBuckets[student.ProgramID]
-		if len(bucket) == 0 {
+		curriculum := curricula[student.ProgramID]
+		if len(curriculum) == 0 {
 			continue
 		}
 
-		desiredLevel := student.YearOfStudy * 100
-		if desiredLevel < 100 {
-			desiredLevel = 100
+		year := student.YearOfStudy
+		if year < 1 {
+			year = 1
 		}
 
-		currentCourses := selectCoursesForLevel(bucket, desiredLevel, 6)
+		currentCourses := selectCurriculumCourses(curriculum, year, currentSemester.SemesterNum, 6)
 		previousCourses := []models.Course{}
 		if previousSemester.ID != 0 {
-			previousCourses = selectCoursesForLevel(bucket, desiredLevel-100, 6)
+			// The semester before a semester I belongs to the previous year of study
+			previousYear := year
+			if currentSemester.SemesterNum == 1 {
+				previousYear--
+			}
+			if previousYear >= 1 {
+				previousCourses = selectCurriculumCourses(curriculum, previousYear, previousSemester.SemesterNum, 6)
+			}
 		}
 
 		for _, course := range currentCourses {
@@ -228,31 +386,55 @@ func (s *Seeder) SeedEnrollments() error {
 	return nil
 }
 
-func selectCoursesForLevel(bucket map[int][]models.Course, desiredLevel int, maxCourses int) []models.Course {
+// selectCurriculumCourses picks a student's courses for a year and semester from the program curriculum:
+// every core course plus electives from each elective group until its minimum credits are met.
+// When the placement is empty (the seeded catalogue is sparse) the nearest placement is used instead.
+func selectCurriculumCourses(curriculum []models.ProgramCourse, year, semesterNum, maxCourses int) []models.Course {
 	if maxCourses <= 0 {
 		return nil
 	}
 
-	requestedLevels := []int{desiredLevel, desiredLevel - 100, desiredLevel + 100, 300, 200, 100}
-	courseSet := make([]models.Course, 0, maxCourses)
-	seen := make(map[uint]struct{})
+	otherSemester := 3 - semesterNum
+	placements := [][2]int{
+		{year, semesterNum}, {year, otherSemester},
+		{year - 1, semesterNum}, {year - 1, otherSemester},
+		{year + 1, semesterNum}, {year + 1, otherSemester},
+	}
+
+	for _, placement := range placements {
+		if placement[0] < 1 {
+			continue
+		}
+		if courses := curriculumPlacement(curriculum, placement[0], placement[1], maxCourses); len(courses) > 0 {
+			return courses
+		}
+	}
+
+	return nil
+}
+
+func curriculumPlacement(curriculum []models.ProgramCourse, year, semesterNum, maxCourses int) []models.Course {
+	courses := make([]models.Course, 0, maxCourses)
+	electiveCredits := make(map[uint]int)
 
-	for _, level := range requestedLevels {
-		if level <= 0 {
+	for _, entry := range curriculum {
+		if entry.YearOfStudy != year || entry.SemesterNum != semesterNum || len(courses) >= maxCourses {
 			continue
 		}
-		courses := bucket[level]
-		for _, course := range courses {
-			if _, exists := seen[course.ID]; exists {
+
+		switch entry.CourseType {
+		case "core":
+			courses = append(courses, entry.Course)
+		case "elective":
+			if entry.ElectiveGroup == nil {
 				continue
 			}
-			courseSet = append(courseSet, course)
-			seen[course.ID] = struct{}{}
-			if len(courseSet) >= maxCourses {
-				return courseSet
+			if electiveCredits[entry.ElectiveGroup.ID] < entry.ElectiveGroup.MinCredits {
+				courses = append(courses, entry.Course)
+				electiveCredits[entry.ElectiveGroup.ID] += entry.Course.Credits
 			}
 		}
 	}
 
-	return courseSet
+	return courses
 }
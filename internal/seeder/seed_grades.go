@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/mwombeki6/mock-sims/internal/services"
 	"github.com/mwombeki6/mock-sims/internal/utils"
 )
 
@@ -19,7 +20,7 @@ func (s *Seeder) SeedGrades() error {
 	}
 
 	var enrollments []models.Enrollment
-	if err := s.db.Where("semester_id = ?", previousSemester.ID).Find(&enrollments).Error; err != nil {
+	if err := s.db.Preload("Student.Program").Where("semester_id = ?", previousSemester.ID).Find(&enrollments).Error; err != nil {
 		return err
 	}
 
@@ -32,7 +33,19 @@ func (s *Seeder) SeedGrades() error {
 		submittedAt = time.Now().AddDate(0, -2, 0)
 	}
 
+	grading := services.NewGradingService(s.db, s.cfg)
+	schemes := make(map[uint]*models.GradingScheme) // By program
+
 	for _, enrollment := range enrollments {
+		scheme, ok := schemes[enrollment.Student.ProgramID]
+		if !ok {
+			var err error
+			if scheme, err = grading.SchemeForProgram(&enrollment.Student.Program); err != nil {
+				return err
+			}
+			schemes[enrollment.Student.ProgramID] = scheme
+		}
+
 		grade := models.Grade{EnrollmentID: enrollment.ID}
 		total := randomFloat(52, 89)
 		if enrollment.ID%7 == 0 {
@@ -42,10 +55,10 @@ func (s *Seeder) SeedGrades() error {
 		grade.CAMarks = total * 0.4
 		grade.FinalExam = total * 0.6
 		grade.TotalMarks = grade.CAMarks + grade.FinalExam
-		grade.LetterGrade = letterFromTotal(grade.TotalMarks)
-		grade.GradePoint = gradePointFromLetter(grade.LetterGrade)
-		remark := remarkFromLetter(grade.LetterGrade)
-		grade.Remarks = remark
+		band := services.GradeFor(scheme, grade.TotalMarks)
+		grade.LetterGrade = band.Letter
+		grade.GradePoint = band.GradePoint
+		grade.Remarks = band.Remark
 		grade.StudentID = enrollment.StudentID
 		grade.CourseID = enrollment.CourseID
 		grade.SubmittedAt = &submittedAt
@@ -58,6 +71,44 @@ func (s *Seeder) SeedGrades() error {
 	return nil
 }
 
+// SeedGradingSchemes stores the default grading scale of each program level.
+func (s *Seeder) SeedGradingSchemes() error {
+	grading := services.NewGradingService(s.db, s.cfg)
+	for _, scheme := range services.DefaultGradingSchemes {
+		if _, err := grading.SaveScheme(scheme.Code, services.GradingSchemeInput{
+			Name:            scheme.Name,
+			DegreeLevel:     scheme.DegreeLevel,
+			NTALevel:        scheme.NTALevel,
+			PassMark:        scheme.PassMark,
+			IsDefault:       scheme.IsDefault,
+			MinCGPA:         scheme.MinCGPA,
+			DiscontinueCGPA: scheme.DiscontinueCGPA,
+			DegreeClasses:   scheme.DegreeClasses,
+			Bands:           scheme.Bands,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedResults computes semester GPAs and standing from the seeded grades, replacing the placeholder GPAs.
+func (s *Seeder) SeedResults() error {
+	var studentIDs []uint
+	if err := s.db.Model(&models.Student{}).Pluck("id", &studentIDs).Error; err != nil {
+		return err
+	}
+
+	results := services.NewResultsService(s.db, s.cfg)
+	for _, studentID := range studentIDs {
+		if _, err := results.ComputeStudent(studentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SeedPayments mirrors SIMS invoices across academic years.
 func (s *Seeder) SeedPayments() error {
 	var students []models.Student
@@ -112,57 +163,6 @@ func (s *Seeder) SeedPayments() error {
 	return nil
 }
 
-func letterFromTotal(total float64) string {
-	switch {
-	case total >= 70:
-		return "A"
-	case total >= 65:
-		return "B+"
-	case total >= 60:
-		return "B"
-	case total >= 50:
-		return "C"
-	case total >= 40:
-		return "D"
-	default:
-		return "F"
-	}
-}
-
-func gradePointFromLetter(letter string) float64 {
-	switch letter {
-	case "A":
-		return 5.0
-	case "B+":
-		return 4.0
-	case "B":
-		return 3.0
-	case "C":
-		return 2.0
-	case "D":
-		return 1.0
-	default:
-		return 0.0
-	}
-}
-
-func remarkFromLetter(letter string) string {
-	switch letter {
-	case "A":
-		return "PASS"
-	case "B+":
-		return "PASS"
-	case "B":
-		return "PASS"
-	case "C":
-		return "PASS"
-	case "D":
-		return "SUPP"
-	default:
-		return "RETAKE"
-	}
-}
-
 func uniqueAcademicYears(semesters []models.Semester) []string {
 	seen := make(map[string]struct{})
 	var years []string
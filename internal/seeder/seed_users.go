@@ -209,3 +209,75 @@ func randomFloat(min, max float64) float64 {
 func init() {
 	seededRand.Seed(time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC).UnixNano())
 }
+
+// rankSeniority orders academic ranks for picking default approvers
+var rankSeniority = map[string]int{
+	"Professor":           5,
+	"Associate Professor": 4,
+	"Senior Lecturer":     3,
+	"Lecturer":            2,
+	"Assistant Lecturer":  1,
+}
+
+// SeedApprovers links each department head and college dean to a faculty account for results approval:
+// the lecturer named as Head or Dean when there is one, otherwise the most senior lecturer.
+// Existing assignments are kept.
+func (s *Seeder) SeedApprovers() error {
+	var faculty []models.Faculty
+	if err := s.db.Preload("Department").Order("id").Find(&faculty).Error; err != nil {
+		return err
+	}
+
+	var departments []models.Department
+	if err := s.db.Where("head_faculty_id IS NULL").Find(&departments).Error; err != nil {
+		return err
+	}
+	for _, dept := range departments {
+		var candidates []models.Faculty
+		for _, f := range faculty {
+			if f.DepartmentID == dept.ID {
+				candidates = append(candidates, f)
+			}
+		}
+		if head := pickApprover(candidates, dept.Head); head != nil {
+			if err := s.db.Model(&dept).Update("head_faculty_id", head.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var colleges []models.College
+	if err := s.db.Where("dean_faculty_id IS NULL").Find(&colleges).Error; err != nil {
+		return err
+	}
+	for _, college := range colleges {
+		var candidates []models.Faculty
+		for _, f := range faculty {
+			if f.Department.CollegeID == college.ID {
+				candidates = append(candidates, f)
+			}
+		}
+		if dean := pickApprover(candidates, college.Dean); dean != nil {
+			if err := s.db.Model(&college).Update("dean_faculty_id", dean.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pickApprover returns the candidate named in title (e.g. "Dr. Mussa Ally Dida"), else the most senior one
+func pickApprover(candidates []models.Faculty, title string) *models.Faculty {
+	var senior *models.Faculty
+	for i := range candidates {
+		f := &candidates[i]
+		if strings.Contains(title, f.FirstName) && strings.Contains(title, f.LastName) {
+			return f
+		}
+		if senior == nil || rankSeniority[f.Rank] > rankSeniority[senior.Rank] {
+			senior = f
+		}
+	}
+	return senior
+}
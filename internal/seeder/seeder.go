@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/database"
 	"github.com/mwombeki6/mock-sims/internal/models"
 	"github.com/mwombeki6/mock-sims/internal/utils"
 	"gorm.io/gorm"
@@ -44,6 +45,11 @@ func (s *Seeder) SeedAll() error {
 		return err
 	}
 
+	log.Println("Seeding public holidays...")
+	if err := s.SeedHolidays(); err != nil {
+		return err
+	}
+
 	log.Println("Seeding venues...")
 	if err := s.SeedVenues(); err != nil {
 		return err
@@ -64,6 +70,16 @@ func (s *Seeder) SeedAll() error {
 		return err
 	}
 
+	log.Println("Seeding curriculum...")
+	if err := s.SeedCurriculum(); err != nil {
+		return err
+	}
+
+	log.Println("Seeding prerequisites...")
+	if err := s.SeedPrerequisites(); err != nil {
+		return err
+	}
+
 	log.Println("Seeding lectures...")
 	if err := s.SeedLectures(); err != nil {
 		return err
@@ -74,11 +90,44 @@ func (s *Seeder) SeedAll() error {
 		return err
 	}
 
+	log.Println("Seeding grading schemes...")
+	if err := s.SeedGradingSchemes(); err != nil {
+		return err
+	}
+
 	log.Println("Seeding grades...")
 	if err := s.SeedGrades(); err != nil {
 		return err
 	}
 
+	log.Println("Linking course offerings, sections and assessments...")
+	if err := database.LinkOfferings(s.db); err != nil {
+		return err
+	}
+	if err := database.LinkAssessments(s.db); err != nil {
+		return err
+	}
+
+	log.Println("Generating the lecture timetable...")
+	if err := s.SeedTimetable(); err != nil {
+		return err
+	}
+
+	log.Println("Assigning heads of department and deans...")
+	if err := s.SeedApprovers(); err != nil {
+		return err
+	}
+
+	log.Println("Publishing seeded results...")
+	if err := database.LinkResultsWorkflows(s.db); err != nil {
+		return err
+	}
+
+	log.Println("Computing semester results...")
+	if err := s.SeedResults(); err != nil {
+		return err
+	}
+
 	log.Println("Seeding payments...")
 	if err := s.SeedPayments(); err != nil {
 		return err
@@ -295,6 +344,10 @@ func (s *Seeder) SeedSemesters() error {
 		},
 	}
 
+	for i := range semesters {
+		setRegistrationWindows(&semesters[i])
+	}
+
 	for _, semester := range semesters {
 		if err := s.db.FirstOrCreate(&semester, models.Semester{Name: semester.Name}).Error; err != nil {
 			return err
@@ -304,6 +357,56 @@ func (s *Seeder) SeedSemesters() error {
 	return nil
 }
 
+// SeedHolidays adds the fixed-date public holidays of every year the seeded semesters span
+func (s *Seeder) SeedHolidays() error {
+	var semesters []models.Semester
+	if err := s.db.Find(&semesters).Error; err != nil {
+		return err
+	}
+	if len(semesters) == 0 {
+		return nil
+	}
+
+	first, last := semesters[0].StartDate.Year(), semesters[0].EndDate.Year()
+	for _, semester := range semesters {
+		first = min(first, semester.StartDate.Year())
+		last = max(last, semester.EndDate.Year())
+	}
+
+	for year := first; year <= last; year++ {
+		for _, seed := range publicHolidaySeeds {
+			holiday := models.Holiday{
+				Date: time.Date(year, time.Month(seed.Month), seed.Day, 0, 0, 0, 0, time.UTC),
+				Name: seed.Name,
+			}
+			if err := s.db.FirstOrCreate(&holiday, models.Holiday{Date: holiday.Date}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setRegistrationWindows gives each semester a three-week registration window before it starts
+// followed by a two-week add/drop window. The current semester's windows are anchored to today
+// so self-registration can be tried straight after seeding.
+func setRegistrationWindows(semester *models.Semester) {
+	anchor := semester.StartDate
+	if semester.IsCurrent {
+		anchor = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	}
+
+	registrationOpens := anchor.AddDate(0, 0, -21)
+	registrationCloses := anchor.Add(-time.Second)
+	addDropCloses := anchor.AddDate(0, 0, 14)
+
+	semester.RegistrationOpensAt = &registrationOpens
+	semester.RegistrationClosesAt = &registrationCloses
+	semester.AddDropOpensAt = &anchor
+	semester.AddDropClosesAt = &addDropCloses
+}
+
 // SeedVenues creates lecture venues
 func (s *Seeder) SeedVenues() error {
 	venues := []models.Venue{
@@ -341,7 +444,7 @@ func (s *Seeder) SeedOAuthClient() error {
 		ClientSecret: hashedSecret,
 		Name:         "MUST Learning Management System",
 		RedirectURIs: "http://localhost:8080/auth/callback,http://192.168.1.20:8080/auth/callback",
-		Scopes:       "read_profile,read_courses,read_grades,write_grades",
+		Scopes:       "read_profile,read_courses,read_grades,write_grades,read_attendance,write_attendance",
 		IsActive:     true,
 	}
 
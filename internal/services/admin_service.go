@@ -1,22 +1,53 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/mwombeki6/mock-sims/internal/utils"
 	"gorm.io/gorm"
 )
 
+// ProgramInput carries optional program changes (zero values are left untouched)
+type ProgramInput struct {
+	Name        string `json:"name"`
+	DegreeLevel string `json:"degree_level"`
+	NTALevel    int    `json:"nta_level"`
+	Duration    int    `json:"duration"`
+	TuitionFees int    `json:"tuition_fees"`
+}
+
+// PaymentInput records a payment against a student's invoice
+type PaymentInput struct {
+	RegNumber     string  `json:"reg_number"`
+	InvoiceNumber string  `json:"invoice_number"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+	AcademicYear  string  `json:"academic_year"`
+	Semester      string  `json:"semester"`
+	ReceiptNumber string  `json:"receipt_number"`
+}
+
 type AdminService struct {
-	db             *gorm.DB
-	cfg            *config.Config
-	webhookService *WebhookService
+	db       *gorm.DB
+	cfg      *config.Config
+	events   *EventPublisher
+	rules    *RegistrationRules
+	waitlist *WaitlistService
 }
 
 func NewAdminService(db *gorm.DB, cfg *config.Config) *AdminService {
 	return &AdminService{
-		db:             db,
-		cfg:            cfg,
-		webhookService: NewWebhookService(db, cfg),
+		db:       db,
+		cfg:      cfg,
+		events:   NewEventPublisher(db, cfg),
+		rules:    NewRegistrationRules(db, cfg),
+		waitlist: NewWaitlistService(db, cfg),
 	}
 }
 
@@ -117,48 +148,263 @@ func (s *AdminService) GetProgramsByLevel(degreeLevel string) ([]models.Program,
 	return programs, nil
 }
 
-// CreateBulkEnrollments creates multiple enrollments at once
-func (s *AdminService) CreateBulkEnrollments(enrollments []models.Enrollment) error {
-	var createdEnrollments []models.Enrollment
+// Bulk enrollment modes
+const (
+	EnrollmentModeBestEffort   = "best_effort"
+	EnrollmentModeAllOrNothing = "all_or_nothing"
+)
+
+// Per-item bulk enrollment statuses
+const (
+	EnrollmentItemCreated         = "created"
+	EnrollmentItemAlreadyExists   = "already_exists"
+	EnrollmentItemInvalidStudent  = "invalid_student"
+	EnrollmentItemInvalidCourse   = "invalid_course"
+	EnrollmentItemInvalidSemester = "invalid_semester"
+	EnrollmentItemRuleViolation   = "rule_violation"
+	EnrollmentItemNotCreated      = "not_created" // Valid, but the all_or_nothing batch was rolled back
+)
+
+// BulkEnrollmentInput is a bulk enrollment request
+type BulkEnrollmentInput struct {
+	Mode        string            `json:"mode"` // best_effort (default) or all_or_nothing
+	Enrollments []EnrollmentInput `json:"enrollments"`
+}
+
+// EnrollmentInput identifies one enrollment by natural keys
+type EnrollmentInput struct {
+	RegNumber  string `json:"reg_number"`
+	CourseCode string `json:"course_code"`
+	Semester   string `json:"semester"` // Semester name, e.g. "2024/2025 Semester I"; defaults to the current semester
+}
+
+// EnrollmentItemResult is the outcome of one requested enrollment
+type EnrollmentItemResult struct {
+	Index        int             `json:"index"`
+	RegNumber    string          `json:"reg_number"`
+	CourseCode   string          `json:"course_code"`
+	Semester     string          `json:"semester"`
+	Status       string          `json:"status"`
+	EnrollmentID uint            `json:"enrollment_id,omitempty"`
+	Violations   []RuleViolation `json:"violations,omitempty"`
+}
+
+// BulkEnrollmentResult is the outcome of a bulk enrollment request
+type BulkEnrollmentResult struct {
+	Mode      string                 `json:"mode"`
+	Committed bool                   `json:"committed"`
+	Created   int                    `json:"created"`
+	Failed    int                    `json:"failed"`
+	Items     []EnrollmentItemResult `json:"items"`
+}
+
+// CreateBulkEnrollments validates every requested enrollment and creates the valid ones.
+// In all_or_nothing mode nothing is created unless every item is valid or already exists.
+func (s *AdminService) CreateBulkEnrollments(input BulkEnrollmentInput) (*BulkEnrollmentResult, error) {
+	if input.Mode == "" {
+		input.Mode = EnrollmentModeBestEffort
+	}
+	if input.Mode != EnrollmentModeBestEffort && input.Mode != EnrollmentModeAllOrNothing {
+		return nil, errors.New("mode must be best_effort or all_or_nothing")
+	}
+	if len(input.Enrollments) == 0 {
+		return nil, errors.New("enrollments are required")
+	}
+
+	result := &BulkEnrollmentResult{Mode: input.Mode, Items: make([]EnrollmentItemResult, len(input.Enrollments))}
+	students := make(map[uint]*models.Student)
+	courses := make(map[int]models.Course)
+	groups := make(map[[2]uint][]int)
+	var order [][2]uint
+
+	// Resolve natural keys and skip enrollments that already exist
+	for i, item := range input.Enrollments {
+		entry := &result.Items[i]
+		*entry = EnrollmentItemResult{
+			Index:      i,
+			RegNumber:  strings.TrimSpace(item.RegNumber),
+			CourseCode: strings.ToUpper(strings.TrimSpace(item.CourseCode)),
+			Semester:   strings.TrimSpace(item.Semester),
+		}
+
+		var student models.Student
+		if err := s.db.Preload("Program").Where("reg_number = ?", entry.RegNumber).First(&student).Error; err != nil {
+			entry.Status = EnrollmentItemInvalidStudent
+			continue
+		}
+		var course models.Course
+		if err := s.db.Where("code = ?", entry.CourseCode).First(&course).Error; err != nil {
+			entry.Status = EnrollmentItemInvalidCourse
+			continue
+		}
+		semester, err := s.resolveSemester(entry.Semester)
+		if err != nil {
+			entry.Status = EnrollmentItemInvalidSemester
+			continue
+		}
+		entry.Semester = semester.Name
+
+		var existing models.Enrollment
+		if err := s.db.Where("student_id = ? AND course_id = ? AND semester_id = ?", student.ID, course.ID, semester.ID).
+			First(&existing).Error; err == nil {
+			entry.Status = EnrollmentItemAlreadyExists
+			entry.EnrollmentID = existing.ID
+			continue
+		}
 
-	// Use transaction for bulk insert
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		for _, enrollment := range enrollments {
-			// Check if enrollment already exists
-			var existing models.Enrollment
-			err := tx.Where("student_id = ? AND course_id = ? AND semester_id = ?",
-				enrollment.StudentID, enrollment.CourseID, enrollment.SemesterID).
-				First(&existing).Error
+		key := [2]uint{student.ID, semester.ID}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+		students[student.ID] = &student
+		courses[i] = course
+	}
 
-			if err == nil {
-				// Enrollment exists, skip
-				continue
+	// Evaluate the rules per student and semester so the batch counts towards the same load
+	for _, key := range order {
+		requested := make([]models.Course, 0, len(groups[key]))
+		for _, i := range groups[key] {
+			requested = append(requested, courses[i])
+		}
+		violations, err := s.rules.Evaluate(students[key[0]], key[1], requested)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range groups[key] {
+			result.Items[i].Violations = violations[j]
+			if hasBlocking(violations[j]) {
+				result.Items[i].Status = EnrollmentItemRuleViolation
 			}
+		}
+	}
 
-			// Create new enrollment
-			if err := tx.Create(&enrollment).Error; err != nil {
-				return err
+	// Items earlier in the batch take seats from later ones
+	if s.rules.enabled[RuleCapacity] {
+		remaining := make(map[[2]uint]*int)
+		for _, key := range order {
+			for _, i := range groups[key] {
+				if result.Items[i].Status != "" {
+					continue
+				}
+				seatKey := [2]uint{courses[i].ID, key[1]}
+				if _, ok := remaining[seatKey]; !ok {
+					seats, err := s.waitlist.Seats(courses[i].ID, key[1])
+					if err != nil {
+						return nil, err
+					}
+					remaining[seatKey] = seats.Available
+				}
+				left := remaining[seatKey]
+				if left == nil {
+					continue
+				}
+				if *left <= 0 {
+					result.Items[i].Status = EnrollmentItemRuleViolation
+					result.Items[i].Violations = append(result.Items[i].Violations,
+						blocking(RuleCapacity, courses[i].Code+" has no seats left for this batch"))
+					continue
+				}
+				*left--
+			}
+		}
+	}
+
+	for _, entry := range result.Items {
+		if entry.Status != "" && entry.Status != EnrollmentItemAlreadyExists {
+			result.Failed++
+		}
+	}
+	if input.Mode == EnrollmentModeAllOrNothing && result.Failed > 0 {
+		for i := range result.Items {
+			if result.Items[i].Status == "" {
+				result.Items[i].Status = EnrollmentItemNotCreated
 			}
+		}
+		return result, nil
+	}
 
-			// Track created enrollment for webhook notification
-			createdEnrollments = append(createdEnrollments, enrollment)
+	// Valid items are the ones still without a status
+	var created []models.Enrollment
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		for _, key := range order {
+			for _, i := range groups[key] {
+				if result.Items[i].Status != "" {
+					continue
+				}
+				enrollment := models.Enrollment{
+					StudentID:  key[0],
+					CourseID:   courses[i].ID,
+					SemesterID: key[1],
+					Status:     "active",
+					EnrolledAt: time.Now(),
+				}
+				var err error
+				if s.rules.enabled[RuleCapacity] {
+					err = reserveSeat(tx, courses[i].ID, key[1])
+				}
+				if err == nil {
+					err = placeEnrollment(tx, &enrollment, students[key[0]].ProgramID)
+				}
+				if errors.Is(err, ErrSectionsFull) || errors.Is(err, ErrCourseFull) {
+					message := courses[i].Code + " has no section with a free seat"
+					if errors.Is(err, ErrCourseFull) {
+						message = courses[i].Code + " was filled by another registration"
+					}
+					result.Items[i].Status = EnrollmentItemRuleViolation
+					result.Items[i].Violations = append(result.Items[i].Violations, blocking(RuleCapacity, message))
+					result.Failed++
+					if input.Mode == EnrollmentModeAllOrNothing {
+						return err
+					}
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(&enrollment).Error; err != nil {
+					return err
+				}
+				if err := outbox.Publish(EventEnrollmentCreated, enrollmentEventData(&enrollment)); err != nil {
+					return err
+				}
+				result.Items[i].Status = EnrollmentItemCreated
+				result.Items[i].EnrollmentID = enrollment.ID
+				created = append(created, enrollment)
+			}
 		}
 		return nil
 	})
-
+	if errors.Is(err, ErrSectionsFull) || errors.Is(err, ErrCourseFull) {
+		// The all_or_nothing batch was rolled back
+		for i := range result.Items {
+			if result.Items[i].Status == "" || result.Items[i].Status == EnrollmentItemCreated {
+				result.Items[i].Status = EnrollmentItemNotCreated
+				result.Items[i].EnrollmentID = 0
+			}
+		}
+		return result, nil
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
+	result.Committed = true
+	result.Created = len(created)
 
-	// Send webhook notifications for newly created enrollments (async, ignore errors)
-	go func(enrollments []models.Enrollment) {
-		for i := range enrollments {
-			enrollment := enrollments[i]
-			s.webhookService.SendEnrollmentCreated(&enrollment)
-		}
-	}(append([]models.Enrollment(nil), createdEnrollments...))
+	return result, nil
+}
 
-	return nil
+// resolveSemester finds a semester by name, or the current semester when no name is given
+func (s *AdminService) resolveSemester(name string) (*models.Semester, error) {
+	if name == "" {
+		return s.GetCurrentSemester()
+	}
+
+	var semester models.Semester
+	if err := s.db.Where("LOWER(name) = LOWER(?)", name).First(&semester).Error; err != nil {
+		return nil, err
+	}
+	return &semester, nil
 }
 
 // GetCurrentSemester retrieves the current active semester
@@ -184,3 +430,218 @@ func (s *AdminService) GetAllSemesters() ([]models.Semester, error) {
 
 	return semesters, nil
 }
+
+// UpdateProgram updates a program by code and publishes program.updated
+func (s *AdminService) UpdateProgram(code string, input ProgramInput) (*models.Program, error) {
+	var program models.Program
+	if err := s.db.Where("code = ?", code).First(&program).Error; err != nil {
+		return nil, errors.New("program not found")
+	}
+
+	if input.Name != "" {
+		program.Name = input.Name
+	}
+	if input.DegreeLevel != "" {
+		program.DegreeLevel = input.DegreeLevel
+	}
+	if input.NTALevel > 0 {
+		program.NTALevel = input.NTALevel
+	}
+	if input.Duration > 0 {
+		program.Duration = input.Duration
+	}
+	if input.TuitionFees > 0 {
+		program.TuitionFees = input.TuitionFees
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Save(&program).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventProgramUpdated, programEventData(&program))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+// SetDepartmentHead makes a lecturer of the department its head for results approval
+func (s *AdminService) SetDepartmentHead(code, staffID string) (*models.Department, error) {
+	var department models.Department
+	if err := s.db.Where("code = ?", strings.ToUpper(code)).First(&department).Error; err != nil {
+		return nil, errors.New("department not found")
+	}
+
+	var faculty models.Faculty
+	if err := s.db.Where("staff_id = ?", staffID).First(&faculty).Error; err != nil {
+		return nil, errors.New("faculty member not found")
+	}
+	if faculty.DepartmentID != department.ID {
+		return nil, errors.New("the head of department must belong to the department")
+	}
+
+	department.HeadFacultyID = &faculty.ID
+	if err := s.db.Model(&department).Update("head_faculty_id", faculty.ID).Error; err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
+// SetCollegeDean makes a lecturer of the college its dean for results approval
+func (s *AdminService) SetCollegeDean(code, staffID string) (*models.College, error) {
+	var college models.College
+	if err := s.db.Where("code = ?", code).First(&college).Error; err != nil {
+		return nil, errors.New("college not found")
+	}
+
+	var faculty models.Faculty
+	if err := s.db.Preload("Department").Where("staff_id = ?", staffID).First(&faculty).Error; err != nil {
+		return nil, errors.New("faculty member not found")
+	}
+	if faculty.Department.CollegeID != college.ID {
+		return nil, errors.New("the dean must belong to a department of the college")
+	}
+
+	college.DeanFacultyID = &faculty.ID
+	if err := s.db.Model(&college).Update("dean_faculty_id", faculty.ID).Error; err != nil {
+		return nil, err
+	}
+	return &college, nil
+}
+
+// ActivateSemester makes a semester the current one and publishes semester.activated
+func (s *AdminService) ActivateSemester(semesterID uint) (*models.Semester, error) {
+	var semester models.Semester
+	if err := s.db.First(&semester, semesterID).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	var previousID uint
+	if current, err := s.GetCurrentSemester(); err == nil {
+		previousID = current.ID
+	}
+	if previousID == semester.ID {
+		return &semester, nil
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Model(&models.Semester{}).Where("is_current = ?", true).Update("is_current", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&semester).Update("is_current", true).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventSemesterActivated, semesterEventData(&semester, previousID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &semester, nil
+}
+
+// RecordPayment stores a completed payment and publishes payment.received
+func (s *AdminService) RecordPayment(input PaymentInput) (*models.Payment, error) {
+	if input.RegNumber == "" || input.Amount <= 0 || input.PaymentMethod == "" {
+		return nil, errors.New("reg_number, amount and payment_method are required")
+	}
+
+	var student models.Student
+	if err := s.db.Where("reg_number = ?", input.RegNumber).First(&student).Error; err != nil {
+		return nil, errors.New("student not found")
+	}
+
+	if input.AcademicYear == "" || input.Semester == "" {
+		if current, err := s.GetCurrentSemester(); err == nil {
+			if input.AcademicYear == "" {
+				input.AcademicYear = current.AcademicYear
+			}
+			if input.Semester == "" {
+				input.Semester = semesterLabel(current.SemesterNum)
+			}
+		}
+	}
+
+	now := time.Now()
+	if input.InvoiceNumber == "" {
+		input.InvoiceNumber = utils.GenerateInvoiceNumber(int(now.UnixNano() % 10000000000))
+	}
+	if input.ReceiptNumber == "" {
+		input.ReceiptNumber = fmt.Sprintf("RCP%d", now.Unix())
+	}
+
+	payment := models.Payment{
+		StudentID:     student.ID,
+		InvoiceNumber: input.InvoiceNumber,
+		Amount:        input.Amount,
+		PaymentMethod: input.PaymentMethod,
+		PaymentDate:   now,
+		AcademicYear:  input.AcademicYear,
+		Semester:      input.Semester,
+		Status:        "completed",
+		ReceiptNumber: input.ReceiptNumber,
+	}
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventPaymentReceived, paymentEventData(&payment))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// semesterLabel formats a semester number the way payments record it
+func semesterLabel(semesterNum int) string {
+	if semesterNum == 2 {
+		return "Semester II"
+	}
+	return "Semester I"
+}
+
+// DeleteEnrollment soft-deletes an enrollment entered in error and publishes an enrollment.deleted tombstone
+func (s *AdminService) DeleteEnrollment(enrollmentID uint) error {
+	var enrollment models.Enrollment
+	if err := s.db.First(&enrollment, enrollmentID).Error; err != nil {
+		return errors.New("enrollment not found")
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Delete(&enrollment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventEnrollmentDeleted, tombstoneEventData("enrollment_id", enrollment.ID, time.Now()))
+	})
+	if err != nil {
+		return err
+	}
+
+	// The freed seat goes to the head of the waitlist; the deletion stands even if the promotion fails
+	if enrollment.Status == "active" {
+		if _, err := s.waitlist.FillOpenSeats(enrollment.CourseID, enrollment.SemesterID); err != nil {
+			log.Printf("waitlist promotion for course %d in semester %d failed: %v", enrollment.CourseID, enrollment.SemesterID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeletePayment voids a payment record and publishes a payment.deleted tombstone
+func (s *AdminService) DeletePayment(paymentID uint) error {
+	var payment models.Payment
+	if err := s.db.First(&payment, paymentID).Error; err != nil {
+		return errors.New("payment not found")
+	}
+
+	return s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Delete(&payment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventPaymentDeleted, tombstoneEventData("payment_id", payment.ID, time.Now()))
+	})
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Assessment component types. Final exam components make up Grade.FinalExam, every other type Grade.CAMarks.
+const (
+	ComponentTest       = "test"
+	ComponentAssignment = "assignment"
+	ComponentLab        = "lab"
+	ComponentProject    = "project"
+	ComponentQuiz       = "quiz"
+	ComponentCA         = "ca" // Undivided continuous assessment
+	ComponentFinalExam  = "final_exam"
+)
+
+// Markers recorded instead of a score; they become the grade's outcome, DQ over ABS over I
+const (
+	MarkerAbsent       = "ABS"
+	MarkerIncomplete   = "I"
+	MarkerDisqualified = "DQ"
+)
+
+// markerPriority decides a grade's outcome when several components carry markers
+var markerPriority = map[string]int{MarkerIncomplete: 1, MarkerAbsent: 2, MarkerDisqualified: 3}
+
+// markerRemarks are the grade remarks of each outcome
+var markerRemarks = map[string]string{MarkerIncomplete: "INCOMPLETE", MarkerAbsent: "ABSENT", MarkerDisqualified: "DISQUALIFIED"}
+
+// ComponentTypes lists the accepted values for AssessmentComponent.Type
+var ComponentTypes = []string{ComponentTest, ComponentAssignment, ComponentLab, ComponentProject, ComponentQuiz, ComponentCA, ComponentFinalExam}
+
+// DefaultAssessmentComponents reproduce the classic 40% CA and 60% final exam split
+var DefaultAssessmentComponents = []AssessmentComponentInput{
+	{Name: "Continuous Assessment", Type: ComponentCA, Weight: 40, MaxMarks: 40},
+	{Name: "Final Examination", Type: ComponentFinalExam, Weight: 60, MaxMarks: 60},
+}
+
+type AssessmentService struct {
+	db      *gorm.DB
+	cfg     *config.Config
+	grading *GradingService
+}
+
+func NewAssessmentService(db *gorm.DB, cfg *config.Config) *AssessmentService {
+	return &AssessmentService{
+		db:      db,
+		cfg:     cfg,
+		grading: NewGradingService(db, cfg),
+	}
+}
+
+// withDB returns a copy of the service bound to db, e.g. a transaction
+func (s *AssessmentService) withDB(db *gorm.DB) *AssessmentService {
+	return &AssessmentService{db: db, cfg: s.cfg, grading: s.grading}
+}
+
+// AssessmentComponentInput defines one component of an offering
+type AssessmentComponentInput struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Weight   float64 `json:"weight"`
+	MaxMarks float64 `json:"max_marks"`
+}
+
+// ScoreInput is a student's raw score in a component, or a marker (ABS, I, DQ) instead of one
+type ScoreInput struct {
+	StudentID uint    `json:"student_id"`
+	Score     float64 `json:"score"`
+	Marker    string  `json:"marker"`
+}
+
+// Score recording outcomes per student
+const (
+	ScoreRecorded    = "recorded"
+	ScoreNotEnrolled = "not_enrolled" // No active enrollment in the component's offering
+	ScoreInvalid     = "invalid"      // Out of range or an unknown marker
+)
+
+// ScoreResult is the outcome of one submitted score, with the recalculated grade when it was recorded
+type ScoreResult struct {
+	StudentID uint          `json:"student_id"`
+	Score     float64       `json:"score"`
+	Marker    string        `json:"marker,omitempty"`
+	Status    string        `json:"status"` // recorded, not_enrolled, invalid
+	Message   string        `json:"message,omitempty"`
+	Grade     *models.Grade `json:"grade,omitempty"`
+}
+
+// StudentAssessment is a student's scores in an offering with the derived grade
+type StudentAssessment struct {
+	EnrollmentID uint               `json:"enrollment_id"`
+	GradeID      uint               `json:"grade_id"`
+	StudentID    uint               `json:"student_id"`
+	RegNumber    string             `json:"reg_number"`
+	Name         string             `json:"name"`
+	Scores       map[string]float64 `json:"scores"`  // Raw score by component name
+	Markers      map[string]string  `json:"markers"` // ABS, I or DQ by component name
+	Outcome      string             `json:"outcome"`
+	CAMarks      float64            `json:"ca_marks"`
+	FinalExam    float64            `json:"final_exam"`
+	TotalMarks   float64            `json:"total_marks"`
+	LetterGrade  string             `json:"letter_grade"`
+	GradePoint   float64            `json:"grade_point"`
+}
+
+// Components returns an offering's assessment components. An offering without any reports the
+// default split, which is only stored once marks are recorded.
+func (s *AssessmentService) Components(offeringID uint) ([]models.AssessmentComponent, error) {
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+
+	var components []models.AssessmentComponent
+	if err := s.db.Where("offering_id = ?", offeringID).Order("position, id").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		components = defaultComponents(offeringID)
+	}
+	return components, nil
+}
+
+// SetComponents replaces an offering's components (matched by name so existing scores are kept)
+// and recalculates every grade in the offering. Only draft results can be changed.
+func (s *AssessmentService) SetComponents(offeringID uint, inputs []AssessmentComponentInput, actor Actor) ([]models.AssessmentComponent, error) {
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	if err := validateComponents(inputs); err != nil {
+		return nil, err
+	}
+	if err := resultsEditable(s.db, offeringID); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.AssessmentComponent
+		if err := tx.Where("offering_id = ?", offeringID).Find(&existing).Error; err != nil {
+			return err
+		}
+		byName := make(map[string]models.AssessmentComponent, len(existing))
+		for _, component := range existing {
+			byName[strings.ToLower(component.Name)] = component
+		}
+
+		kept := make(map[uint]bool, len(inputs))
+		for i, input := range inputs {
+			component, ok := byName[strings.ToLower(strings.TrimSpace(input.Name))]
+			if !ok {
+				component = models.AssessmentComponent{OfferingID: offeringID}
+			}
+			component.Name = strings.TrimSpace(input.Name)
+			component.Type = strings.ToLower(strings.TrimSpace(input.Type))
+			component.Weight = input.Weight
+			component.MaxMarks = input.MaxMarks
+			component.Position = i + 1
+			if err := tx.Save(&component).Error; err != nil {
+				return err
+			}
+			kept[component.ID] = true
+		}
+
+		for _, component := range existing {
+			if kept[component.ID] {
+				continue
+			}
+			if err := tx.Where("component_id = ?", component.ID).Delete(&models.AssessmentScore{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&component).Error; err != nil {
+				return err
+			}
+		}
+
+		// Weights may have changed, so every grade in the offering is recalculated
+		var enrollments []models.Enrollment
+		if err := tx.Where("offering_id = ? AND id IN (?)", offeringID,
+			tx.Model(&models.Grade{}).Select("enrollment_id")).Find(&enrollments).Error; err != nil {
+			return err
+		}
+		assessments := s.withDB(tx)
+		for i := range enrollments {
+			if _, err := assessments.RecalculateGrade(&enrollments[i], actor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offeringComponents(s.db, offeringID)
+}
+
+// RecordScores stores raw scores for a component and recalculates the affected grades. Every score
+// gets an outcome; the valid ones are written in a single transaction, so either all of them are
+// recorded or none.
+func (s *AssessmentService) RecordScores(componentID uint, scores []ScoreInput, actor Actor) ([]ScoreResult, error) {
+	var component models.AssessmentComponent
+	if err := s.db.First(&component, componentID).Error; err != nil {
+		return nil, errors.New("assessment component not found")
+	}
+	if err := resultsEditable(s.db, component.OfferingID); err != nil {
+		return nil, err
+	}
+
+	results := make([]ScoreResult, len(scores))
+	enrollments := make([]models.Enrollment, len(scores))
+	for i := range scores {
+		results[i] = ScoreResult{StudentID: scores[i].StudentID, Score: scores[i].Score, Marker: scores[i].Marker}
+
+		if err := s.db.Where("offering_id = ? AND student_id = ? AND status <> ?", component.OfferingID, scores[i].StudentID, "dropped").
+			First(&enrollments[i]).Error; err != nil {
+			results[i].Status = ScoreNotEnrolled
+			results[i].Message = "student is not enrolled in this offering"
+			continue
+		}
+		if err := validateScore(&scores[i], component.MaxMarks); err != nil {
+			results[i].Status = ScoreInvalid
+			results[i].Message = err.Error()
+			continue
+		}
+		results[i].Score, results[i].Marker = scores[i].Score, scores[i].Marker
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		assessments := s.withDB(tx)
+		for i := range results {
+			if results[i].Status != "" {
+				continue
+			}
+			grade, err := assessments.recordScore(&component, &enrollments[i], scores[i].Score, scores[i].Marker, actor)
+			if err != nil {
+				return err
+			}
+			results[i].Status = ScoreRecorded
+			results[i].Grade = grade
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// recordScore upserts one raw score (or marker) and recalculates the enrollment's grade.
+// Scores are locked once the offering's results are submitted for approval.
+func (s *AssessmentService) recordScore(component *models.AssessmentComponent, enrollment *models.Enrollment, score float64, marker string, actor Actor) (*models.Grade, error) {
+	if err := resultsEditable(s.db, component.OfferingID); err != nil {
+		return nil, err
+	}
+
+	record := models.AssessmentScore{ComponentID: component.ID, EnrollmentID: enrollment.ID}
+	if err := s.db.Where(&record).FirstOrInit(&record).Error; err != nil {
+		return nil, err
+	}
+	record.StudentID = enrollment.StudentID
+	record.Score = score
+	record.Marker = marker
+	if err := s.db.Save(&record).Error; err != nil {
+		return nil, err
+	}
+	return s.RecalculateGrade(enrollment, actor)
+}
+
+// RecalculateGrade derives an enrollment's CA, final exam and total marks from its weighted
+// component scores. The letter grade is only assigned once every component has a score, unless a
+// component carries a marker: the grade then takes the marker as its outcome and letter with no points.
+// Grades stay provisional until the offering's results workflow publishes them. Every changed field
+// is recorded in the grade history against the actor.
+func (s *AssessmentService) RecalculateGrade(enrollment *models.Enrollment, actor Actor) (*models.Grade, error) {
+	return s.recalculateGrade(enrollment, actor, nil)
+}
+
+// recalculateGrade is RecalculateGrade for changes made by an approved grade change request
+func (s *AssessmentService) recalculateGrade(enrollment *models.Enrollment, actor Actor, changeRequestID *uint) (*models.Grade, error) {
+	if enrollment.OfferingID == nil {
+		return nil, errors.New("enrollment is not linked to a course offering")
+	}
+	components, err := offeringComponents(s.db, *enrollment.OfferingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []models.AssessmentScore
+	if err := s.db.Where("enrollment_id = ?", enrollment.ID).Find(&scores).Error; err != nil {
+		return nil, err
+	}
+	raw := make(map[uint]float64, len(scores))
+	outcome := ""
+	for _, score := range scores {
+		if score.Marker != "" {
+			if markerPriority[score.Marker] > markerPriority[outcome] {
+				outcome = score.Marker
+			}
+			continue
+		}
+		raw[score.ComponentID] = score.Score
+	}
+
+	var grade models.Grade
+	if err := s.db.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error; err != nil {
+		grade = models.Grade{
+			EnrollmentID: enrollment.ID,
+			StudentID:    enrollment.StudentID,
+			CourseID:     enrollment.CourseID,
+			OfferingID:   enrollment.OfferingID,
+			SectionID:    enrollment.SectionID,
+		}
+	}
+	before := grade
+
+	ca, final, complete := weightedMarks(components, raw)
+	grade.CAMarks = ca
+	grade.FinalExam = final
+	grade.TotalMarks = round2(ca + final)
+	grade.LetterGrade = ""
+	grade.GradePoint = 0
+	grade.Outcome = outcome
+	switch {
+	case outcome != "":
+		grade.LetterGrade = outcome
+		grade.Remarks = markerRemarks[outcome]
+	case complete:
+		scheme, err := s.grading.SchemeForStudent(enrollment.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		band := GradeFor(scheme, grade.TotalMarks)
+		grade.LetterGrade = band.Letter
+		grade.GradePoint = band.GradePoint
+		grade.Remarks = band.Remark
+	}
+	now := time.Now()
+	grade.SubmittedAt = &now
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&grade).Error; err != nil {
+			return err
+		}
+		return recordGradeHistory(tx, &before, &grade, actor, changeRequestID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &grade, nil
+}
+
+// OfferingScores lists every enrolled student's component scores and derived marks
+func (s *AssessmentService) OfferingScores(offeringID uint) ([]models.AssessmentComponent, []StudentAssessment, error) {
+	components, err := s.Components(offeringID)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make(map[uint]string, len(components))
+	for _, component := range components {
+		names[component.ID] = component.Name
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Preload("Student").
+		Where("offering_id = ? AND status <> ?", offeringID, "dropped").
+		Order("id").Find(&enrollments).Error; err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]StudentAssessment, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		row := StudentAssessment{
+			EnrollmentID: enrollment.ID,
+			StudentID:    enrollment.StudentID,
+			RegNumber:    enrollment.Student.RegNumber,
+			Name:         enrollment.Student.FirstName + " " + enrollment.Student.LastName,
+			Scores:       map[string]float64{},
+			Markers:      map[string]string{},
+		}
+
+		var scores []models.AssessmentScore
+		if err := s.db.Where("enrollment_id = ?", enrollment.ID).Find(&scores).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, score := range scores {
+			if score.Marker != "" {
+				row.Markers[names[score.ComponentID]] = score.Marker
+				continue
+			}
+			row.Scores[names[score.ComponentID]] = score.Score
+		}
+
+		var grade models.Grade
+		if err := s.db.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error; err == nil {
+			row.GradeID = grade.ID
+			row.CAMarks = grade.CAMarks
+			row.FinalExam = grade.FinalExam
+			row.TotalMarks = grade.TotalMarks
+			row.LetterGrade = grade.LetterGrade
+			row.GradePoint = grade.GradePoint
+			row.Outcome = grade.Outcome
+		}
+		rows = append(rows, row)
+	}
+	return components, rows, nil
+}
+
+// FacultyTeaches reports whether a lecturer is assigned to an offering's course in its semester
+func (s *AssessmentService) FacultyTeaches(facultyID, offeringID uint) bool {
+	var count int64
+	s.db.Model(&models.CourseAssignment{}).
+		Joins("JOIN course_offerings ON course_offerings.course_id = course_assignments.course_id AND course_offerings.semester_id = course_assignments.semester_id").
+		Where("course_assignments.faculty_id = ? AND course_offerings.id = ?", facultyID, offeringID).
+		Count(&count)
+	return count > 0
+}
+
+// ComponentOffering returns the offering a component belongs to
+func (s *AssessmentService) ComponentOffering(componentID uint) (uint, error) {
+	var component models.AssessmentComponent
+	if err := s.db.First(&component, componentID).Error; err != nil {
+		return 0, errors.New("assessment component not found")
+	}
+	return component.OfferingID, nil
+}
+
+// offeringComponents loads an offering's components in order, creating the defaults when it has none
+func offeringComponents(db *gorm.DB, offeringID uint) ([]models.AssessmentComponent, error) {
+	var components []models.AssessmentComponent
+	if err := db.Where("offering_id = ?", offeringID).Order("position, id").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	if len(components) > 0 {
+		return components, nil
+	}
+
+	components = defaultComponents(offeringID)
+	for i := range components {
+		if err := db.Create(&components[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	return components, nil
+}
+
+// defaultComponents builds, without storing them, the default components of an offering
+func defaultComponents(offeringID uint) []models.AssessmentComponent {
+	components := make([]models.AssessmentComponent, len(DefaultAssessmentComponents))
+	for i, input := range DefaultAssessmentComponents {
+		components[i] = models.AssessmentComponent{
+			OfferingID: offeringID,
+			Name:       input.Name,
+			Type:       input.Type,
+			Weight:     input.Weight,
+			MaxMarks:   input.MaxMarks,
+			Position:   i + 1,
+		}
+	}
+	return components
+}
+
+// weightedMarks scales each raw score to its weight and splits the sum into coursework and final exam.
+// complete is false while any component is still unscored.
+func weightedMarks(components []models.AssessmentComponent, raw map[uint]float64) (ca, final float64, complete bool) {
+	complete = len(components) > 0
+	for _, component := range components {
+		score, ok := raw[component.ID]
+		if !ok {
+			complete = false
+			continue
+		}
+		if component.MaxMarks <= 0 {
+			continue
+		}
+		weighted := score / component.MaxMarks * component.Weight
+		if component.Type == ComponentFinalExam {
+			final += weighted
+		} else {
+			ca += weighted
+		}
+	}
+	return round2(ca), round2(final), complete
+}
+
+// validateScore checks a score is a finite number within 0 and the maximum, or normalises its marker
+// and clears the score. NaN would slip through the range check, since every comparison with it is false.
+func validateScore(score *ScoreInput, maxMarks float64) error {
+	if score.Marker != "" {
+		score.Marker = strings.ToUpper(strings.TrimSpace(score.Marker))
+		if _, ok := markerPriority[score.Marker]; !ok {
+			return fmt.Errorf("marker for student %d must be one of %s, %s or %s",
+				score.StudentID, MarkerAbsent, MarkerIncomplete, MarkerDisqualified)
+		}
+		score.Score = 0
+		return nil
+	}
+	if math.IsNaN(score.Score) || math.IsInf(score.Score, 0) || score.Score < 0 || score.Score > maxMarks {
+		return fmt.Errorf("score for student %d must be between 0 and %.2f", score.StudentID, maxMarks)
+	}
+	return nil
+}
+
+// validateComponents checks names are unique, types known and weights add up to 100
+func validateComponents(inputs []AssessmentComponentInput) error {
+	if len(inputs) == 0 {
+		return errors.New("at least one assessment component is required")
+	}
+
+	names := make(map[string]bool, len(inputs))
+	total := 0.0
+	for _, input := range inputs {
+		name := strings.ToLower(strings.TrimSpace(input.Name))
+		switch {
+		case name == "":
+			return errors.New("every component needs a name")
+		case names[name]:
+			return fmt.Errorf("component %q is listed more than once", input.Name)
+		case !slices.Contains(ComponentTypes, strings.ToLower(strings.TrimSpace(input.Type))):
+			return fmt.Errorf("component type must be one of %s", strings.Join(ComponentTypes, ", "))
+		case input.Weight <= 0:
+			return fmt.Errorf("weight of %s must be positive", input.Name)
+		case input.MaxMarks <= 0:
+			return fmt.Errorf("max_marks of %s must be positive", input.Name)
+		}
+		names[name] = true
+		total += input.Weight
+	}
+	if math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("component weights add up to %.2f, not 100", total)
+	}
+	return nil
+}
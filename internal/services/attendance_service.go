@@ -0,0 +1,518 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Attendance statuses
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceLate    = "late"
+	AttendanceExcused = "excused"
+)
+
+// Per-student outcomes of an attendance submission
+const (
+	AttendanceRecorded       = "recorded"
+	AttendanceUnknownStudent = "unknown_student"
+	AttendanceNotOnRoster    = "not_on_roster"
+	AttendanceInvalidStatus  = "invalid_status"
+	AttendanceDuplicate      = "duplicate"
+)
+
+const attendanceDateLayout = "2006-01-02"
+
+type AttendanceService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	events      *EventPublisher
+	threshold   float64
+	minSessions int
+}
+
+func NewAttendanceService(db *gorm.DB, cfg *config.Config) *AttendanceService {
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(cfg.AttendanceThreshold), 64)
+	if err != nil || threshold < 0 || threshold > 100 {
+		log.Printf("ATTENDANCE_THRESHOLD %q is not a percentage between 0 and 100; using 75", cfg.AttendanceThreshold)
+		threshold = 75
+	}
+	minSessions, err := strconv.Atoi(strings.TrimSpace(cfg.AttendanceMinSessions))
+	if err != nil || minSessions < 0 {
+		log.Printf("ATTENDANCE_MIN_SESSIONS %q is not a whole number of sessions; using 4", cfg.AttendanceMinSessions)
+		minSessions = 4
+	}
+
+	return &AttendanceService{
+		db:          db,
+		cfg:         cfg,
+		events:      NewEventPublisher(db, cfg),
+		threshold:   threshold,
+		minSessions: minSessions,
+	}
+}
+
+// withDB returns a copy of the service that reads and writes through db (e.g. a transaction)
+func (s *AttendanceService) withDB(db *gorm.DB) *AttendanceService {
+	bound := *s
+	bound.db = db
+	return &bound
+}
+
+// AttendanceSubmission records the attendance of one lecture occurrence in bulk
+type AttendanceSubmission struct {
+	Date    string            `json:"date"` // YYYY-MM-DD, on the lecture's day of the week
+	Topic   string            `json:"topic"`
+	Records []AttendanceInput `json:"records"`
+}
+
+// AttendanceInput is one student's attendance, identified by student ID or registration number
+type AttendanceInput struct {
+	StudentID uint   `json:"student_id"`
+	RegNumber string `json:"reg_number"`
+	Status    string `json:"status"` // present, absent, late, excused
+	Note      string `json:"note"`
+}
+
+// AttendanceResult is the outcome of one student's attendance in a submission
+type AttendanceResult struct {
+	StudentID  uint   `json:"student_id,omitempty"`
+	RegNumber  string `json:"reg_number,omitempty"`
+	Attendance string `json:"attendance"`
+	Status     string `json:"status"` // recorded, unknown_student, not_on_roster, invalid_status, duplicate
+	Message    string `json:"message,omitempty"`
+}
+
+// AttendanceSummary is a student's attendance in one course offering. Sessions counts the sessions
+// held for the student's lectures; a session without a record for the student counts as absent.
+type AttendanceSummary struct {
+	EnrollmentID uint    `json:"enrollment_id"`
+	StudentID    uint    `json:"student_id"`
+	RegNumber    string  `json:"reg_number"`
+	Name         string  `json:"name"`
+	OfferingID   uint    `json:"offering_id"`
+	CourseID     uint    `json:"course_id"`
+	CourseCode   string  `json:"course_code"`
+	CourseName   string  `json:"course_name"`
+	SemesterID   uint    `json:"semester_id"`
+	Sessions     int     `json:"sessions"`
+	Present      int     `json:"present"`
+	Late         int     `json:"late"`
+	Absent       int     `json:"absent"`
+	Excused      int     `json:"excused"`
+	Percentage   float64 `json:"percentage"` // (present + late) / (sessions - excused)
+	ExamEligible bool    `json:"exam_eligible"`
+}
+
+// AttendanceReport is the attendance of every student in a course offering
+type AttendanceReport struct {
+	OfferingID  uint                       `json:"offering_id"`
+	CourseCode  string                     `json:"course_code"`
+	Threshold   float64                    `json:"threshold"`
+	MinSessions int                        `json:"min_sessions"`
+	Sessions    []models.AttendanceSession `json:"sessions"`
+	Students    []AttendanceSummary        `json:"students"`
+}
+
+// Submit records attendance for a lecture on a date, creating the session on first use and
+// replacing earlier records of the same students. Students must be on the lecture's roster (its
+// section, or the whole offering). Valid records are written in one transaction and reported per
+// student alongside the rejected ones; nothing is written when no record is valid. The submitted
+// students' attendance standing is refreshed in the same transaction.
+func (s *AttendanceService) Submit(lectureID uint, input AttendanceSubmission, actor Actor) (*models.AttendanceSession, []AttendanceResult, error) {
+	var lecture models.Lecture
+	if err := s.db.Preload("Semester").First(&lecture, lectureID).Error; err != nil {
+		return nil, nil, errors.New("lecture not found")
+	}
+	offering, err := s.lectureOffering(&lecture)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := attendanceAccess(s.db, offering, actor); err != nil {
+		return nil, nil, err
+	}
+	if len(input.Records) == 0 {
+		return nil, nil, errors.New("at least one attendance record is required")
+	}
+
+	date, err := time.Parse(attendanceDateLayout, strings.TrimSpace(input.Date))
+	if err != nil {
+		return nil, nil, errors.New("date must be in YYYY-MM-DD format")
+	}
+	day := date.Format(attendanceDateLayout)
+	switch {
+	case !strings.EqualFold(date.Weekday().String(), lecture.DayOfWeek):
+		return nil, nil, fmt.Errorf("%s is a %s; the lecture is held on %s", day, date.Weekday(), lecture.DayOfWeek)
+	case day < lecture.Semester.StartDate.Format(attendanceDateLayout) || day > lecture.Semester.EndDate.Format(attendanceDateLayout):
+		return nil, nil, fmt.Errorf("%s is outside %s", day, lecture.Semester.Name)
+	case day > time.Now().Format(attendanceDateLayout):
+		return nil, nil, errors.New("attendance cannot be recorded for a future date")
+	}
+
+	roster, err := s.roster(offering.ID, lecture.SectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]*models.Enrollment, len(roster))
+	byReg := make(map[string]*models.Enrollment, len(roster))
+	for i := range roster {
+		byID[roster[i].StudentID] = &roster[i]
+		byReg[strings.ToUpper(roster[i].Student.RegNumber)] = &roster[i]
+	}
+
+	results := make([]AttendanceResult, len(input.Records))
+	valid := make(map[int]*models.Enrollment, len(input.Records))
+	seen := make(map[uint]bool, len(input.Records))
+	for i, entry := range input.Records {
+		result := AttendanceResult{StudentID: entry.StudentID, RegNumber: entry.RegNumber}
+		result.Attendance = strings.ToLower(strings.TrimSpace(entry.Status))
+
+		enrollment, found, err := s.findOnRoster(entry, byID, byReg)
+		switch {
+		case err != nil:
+			return nil, nil, err
+		case !found:
+			result.Status, result.Message = AttendanceUnknownStudent, "student not found"
+		case enrollment == nil:
+			result.Status, result.Message = AttendanceNotOnRoster, "student is not on this lecture's roster"
+		case seen[enrollment.StudentID]:
+			result.Status, result.Message = AttendanceDuplicate, "student appears more than once"
+		default:
+			switch result.Attendance {
+			case AttendancePresent, AttendanceAbsent, AttendanceLate, AttendanceExcused:
+				result.Status = AttendanceRecorded
+				result.StudentID = enrollment.StudentID
+				result.RegNumber = enrollment.Student.RegNumber
+				seen[enrollment.StudentID] = true
+				valid[i] = enrollment
+			default:
+				result.Status = AttendanceInvalidStatus
+				result.Message = fmt.Sprintf("status must be %s, %s, %s or %s", AttendancePresent, AttendanceAbsent, AttendanceLate, AttendanceExcused)
+			}
+		}
+		results[i] = result
+	}
+	if len(valid) == 0 {
+		return nil, results, nil
+	}
+
+	var session models.AttendanceSession
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Where("lecture_id = ? AND date = ?", lecture.ID, day).FirstOrInit(&session).Error; err != nil {
+			return err
+		}
+		if session.ID == 0 {
+			session.LectureID = lecture.ID
+			session.Date = date
+			session.RecordedBy = actor.UserID
+			session.Source = actor.Source
+		}
+		session.OfferingID = offering.ID
+		session.SectionID = lecture.SectionID
+		if topic := strings.TrimSpace(input.Topic); topic != "" {
+			session.Topic = topic
+		}
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+
+		submitted := make([]*models.Enrollment, 0, len(valid))
+		for i, entry := range input.Records {
+			enrollment, ok := valid[i]
+			if !ok {
+				continue
+			}
+			submitted = append(submitted, enrollment)
+			var record models.AttendanceRecord
+			if err := tx.Where("session_id = ? AND student_id = ?", session.ID, enrollment.StudentID).
+				FirstOrInit(&record).Error; err != nil {
+				return err
+			}
+			record.SessionID = session.ID
+			record.StudentID = enrollment.StudentID
+			record.EnrollmentID = enrollment.ID
+			record.Status = results[i].Attendance
+			record.Note = strings.TrimSpace(entry.Note)
+			record.RecordedBy = actor.UserID
+			if err := tx.Save(&record).Error; err != nil {
+				return err
+			}
+		}
+		return s.withDB(tx).refreshStanding(submitted, outbox)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.db.Preload("Records", func(db *gorm.DB) *gorm.DB { return db.Order("student_id") }).
+		First(&session, session.ID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &session, results, nil
+}
+
+// Session returns an attendance session with its records
+func (s *AttendanceService) Session(sessionID uint, actor Actor) (*models.AttendanceSession, error) {
+	var session models.AttendanceSession
+	if err := s.db.Preload("Records", func(db *gorm.DB) *gorm.DB { return db.Order("student_id") }).
+		First(&session, sessionID).Error; err != nil {
+		return nil, errors.New("attendance session not found")
+	}
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, session.OfferingID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	if err := attendanceAccess(s.db, &offering, actor); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LectureSessions lists the sessions recorded for a lecture, oldest first
+func (s *AttendanceService) LectureSessions(lectureID uint, actor Actor) ([]models.AttendanceSession, error) {
+	var lecture models.Lecture
+	if err := s.db.First(&lecture, lectureID).Error; err != nil {
+		return nil, errors.New("lecture not found")
+	}
+	offering, err := s.lectureOffering(&lecture)
+	if err != nil {
+		return nil, err
+	}
+	if err := attendanceAccess(s.db, offering, actor); err != nil {
+		return nil, err
+	}
+
+	var sessions []models.AttendanceSession
+	if err := s.db.Preload("Records", func(db *gorm.DB) *gorm.DB { return db.Order("student_id") }).
+		Where("lecture_id = ?", lectureID).Order("date").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// OfferingReport returns every session of an offering and each enrolled student's attendance
+func (s *AttendanceService) OfferingReport(offeringID uint, actor Actor) (*AttendanceReport, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course").First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	if err := attendanceAccess(s.db, &offering, actor); err != nil {
+		return nil, err
+	}
+
+	report := &AttendanceReport{
+		OfferingID:  offering.ID,
+		CourseCode:  offering.Course.Code,
+		Threshold:   s.threshold,
+		MinSessions: s.minSessions,
+		Sessions:    []models.AttendanceSession{},
+		Students:    []AttendanceSummary{},
+	}
+	if err := s.db.Where("offering_id = ?", offeringID).Order("date, lecture_id").Find(&report.Sessions).Error; err != nil {
+		return nil, err
+	}
+	roster, err := s.roster(offeringID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roster {
+		summary, err := s.summary(&roster[i])
+		if err != nil {
+			return nil, err
+		}
+		report.Students = append(report.Students, *summary)
+	}
+	return report, nil
+}
+
+// StudentAttendance returns a student's attendance in each course of a semester (0 is the current one)
+func (s *AttendanceService) StudentAttendance(studentID, semesterID uint) ([]AttendanceSummary, error) {
+	if semesterID == 0 {
+		var semester models.Semester
+		if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+			return nil, errors.New("no current semester")
+		}
+		semesterID = semester.ID
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Preload("Student").Preload("Course").
+		Where("student_id = ? AND semester_id = ? AND status <> ? AND offering_id IS NOT NULL", studentID, semesterID, "dropped").
+		Order("course_id").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+
+	summaries := []AttendanceSummary{}
+	for i := range enrollments {
+		summary, err := s.summary(&enrollments[i])
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, *summary)
+	}
+	return summaries, nil
+}
+
+// Threshold is the attendance percentage a student needs to sit the final exam
+func (s *AttendanceService) Threshold() float64 {
+	return s.threshold
+}
+
+// refreshStanding recomputes the attendance of the given enrollments, flags those below the
+// threshold as ineligible for the final exam and notifies the LMS of each student who newly fell below it
+func (s *AttendanceService) refreshStanding(enrollments []*models.Enrollment, outbox *EventOutbox) error {
+	for _, enrollment := range enrollments {
+		summary, err := s.summary(enrollment)
+		if err != nil {
+			return err
+		}
+		wasIneligible := enrollment.ExamIneligible
+		if err := s.db.Model(enrollment).Updates(map[string]interface{}{
+			"attendance_rate": summary.Percentage,
+			"exam_ineligible": !summary.ExamEligible,
+		}).Error; err != nil {
+			return err
+		}
+		if !summary.ExamEligible && !wasIneligible {
+			if err := outbox.Publish(EventAttendanceBelow, attendanceEventData(summary, s.threshold)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// summary counts an enrollment's attendance; Student and Course must be loaded
+func (s *AttendanceService) summary(enrollment *models.Enrollment) (*AttendanceSummary, error) {
+	summary := &AttendanceSummary{
+		EnrollmentID: enrollment.ID,
+		StudentID:    enrollment.StudentID,
+		RegNumber:    enrollment.Student.RegNumber,
+		Name:         enrollment.Student.FirstName + " " + enrollment.Student.LastName,
+		CourseID:     enrollment.CourseID,
+		CourseCode:   enrollment.Course.Code,
+		CourseName:   enrollment.Course.Name,
+		SemesterID:   enrollment.SemesterID,
+		Percentage:   100,
+		ExamEligible: true,
+	}
+	if enrollment.OfferingID == nil {
+		return summary, nil
+	}
+	summary.OfferingID = *enrollment.OfferingID
+
+	// Sessions of the lectures the student is expected at: the whole offering's and their section's
+	sessions := func() *gorm.DB {
+		query := s.db.Model(&models.AttendanceSession{}).Where("offering_id = ?", *enrollment.OfferingID)
+		if enrollment.SectionID != nil {
+			return query.Where("(section_id IS NULL OR section_id = ?)", *enrollment.SectionID)
+		}
+		return query.Where("section_id IS NULL")
+	}
+
+	var held int64
+	if err := sessions().Count(&held).Error; err != nil {
+		return nil, err
+	}
+	var counts []struct {
+		Status string
+		Count  int
+	}
+	if err := s.db.Model(&models.AttendanceRecord{}).Select("status, COUNT(*) AS count").
+		Where("student_id = ? AND session_id IN (?)", enrollment.StudentID, sessions().Select("id")).
+		Group("status").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		switch count.Status {
+		case AttendancePresent:
+			summary.Present = count.Count
+		case AttendanceLate:
+			summary.Late = count.Count
+		case AttendanceExcused:
+			summary.Excused = count.Count
+		}
+	}
+	summary.Sessions = int(held)
+	summary.Absent = summary.Sessions - summary.Present - summary.Late - summary.Excused
+
+	// Excused sessions are left out; the threshold applies once enough sessions were expected
+	expected := summary.Sessions - summary.Excused
+	if expected > 0 {
+		summary.Percentage = math.Round(float64(summary.Present+summary.Late)/float64(expected)*10000) / 100
+	}
+	summary.ExamEligible = expected < s.minSessions || summary.Percentage >= s.threshold
+	return summary, nil
+}
+
+// roster returns an offering's active enrollments, limited to a section when one is given
+func (s *AttendanceService) roster(offeringID uint, sectionID *uint) ([]models.Enrollment, error) {
+	query := s.db.Preload("Student").Preload("Course").Where("offering_id = ? AND status <> ?", offeringID, "dropped")
+	if sectionID != nil {
+		query = query.Where("section_id = ?", *sectionID)
+	}
+	var enrollments []models.Enrollment
+	if err := query.Order("student_id").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// findOnRoster resolves a submitted student: found is false for an unknown student and the
+// enrollment is nil for a known student who is not on the roster
+func (s *AttendanceService) findOnRoster(entry AttendanceInput, byID map[uint]*models.Enrollment, byReg map[string]*models.Enrollment) (*models.Enrollment, bool, error) {
+	if regNumber := strings.ToUpper(strings.TrimSpace(entry.RegNumber)); regNumber != "" {
+		if enrollment, ok := byReg[regNumber]; ok {
+			return enrollment, true, nil
+		}
+		var count int64
+		if err := s.db.Model(&models.Student{}).Where("UPPER(reg_number) = ?", regNumber).Count(&count).Error; err != nil {
+			return nil, false, err
+		}
+		return nil, count > 0, nil
+	}
+	if entry.StudentID == 0 {
+		return nil, false, nil
+	}
+	if enrollment, ok := byID[entry.StudentID]; ok {
+		return enrollment, true, nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Student{}).Where("id = ?", entry.StudentID).Count(&count).Error; err != nil {
+		return nil, false, err
+	}
+	return nil, count > 0, nil
+}
+
+// lectureOffering returns the course offering a lecture belongs to
+func (s *AttendanceService) lectureOffering(lecture *models.Lecture) (*models.CourseOffering, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course").Where("course_id = ? AND semester_id = ?", lecture.CourseID, lecture.SemesterID).
+		First(&offering).Error; err != nil {
+		return nil, errors.New("the lecture's course has no offering this semester")
+	}
+	return &offering, nil
+}
+
+// attendanceAccess allows admins, LMS clients (including attendance apps) and the course lecturer to
+// record and read an offering's attendance. Client scopes are checked by the handler.
+func attendanceAccess(db *gorm.DB, offering *models.CourseOffering, actor Actor) error {
+	switch actor.UserType {
+	case "admin", "client":
+		return nil
+	case "faculty":
+		_, err := offeringRole(db, offering, actor, RoleLecturer)
+		return err
+	}
+	return errors.New("only lecturers, administrators and LMS clients can manage attendance")
+}
@@ -0,0 +1,653 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // CALENDAR_TIMEZONE resolves without the host's zoneinfo (e.g. in scratch images)
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/mwombeki6/mock-sims/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Calendar feed kinds, as they appear in feed URLs
+const (
+	FeedStudent = "students"
+	FeedFaculty = "faculty"
+	FeedVenue   = "venues"
+	FeedCourse  = "courses"
+)
+
+// ErrFeedForbidden rejects a feed token asking for another user's personal timetable
+var ErrFeedForbidden = errors.New("this feed token cannot read that timetable")
+
+const (
+	icsLocalLayout = "20060102T150405"
+	icsUTCLayout   = "20060102T150405Z"
+	icsDomain      = "mock-sims"
+)
+
+type CalendarService struct {
+	db       *gorm.DB
+	cfg      *config.Config
+	exams    *ExamService
+	location *time.Location
+}
+
+func NewCalendarService(db *gorm.DB, cfg *config.Config) *CalendarService {
+	location, err := time.LoadLocation(cfg.CalendarTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return &CalendarService{
+		db:       db,
+		cfg:      cfg,
+		exams:    NewExamService(db, cfg),
+		location: location,
+	}
+}
+
+// CalendarFeeds is a user's feed token with the subscription URLs it unlocks
+type CalendarFeeds struct {
+	Token      string     `json:"token"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Personal   string     `json:"personal,omitempty"` // The user's own student or lecturer timetable
+	Course     string     `json:"course"`             // Template; replace {code}
+	Venue      string     `json:"venue"`              // Template; replace {id}
+}
+
+// HolidayInput adds a holiday on which no lectures are held
+type HolidayInput struct {
+	Date string `json:"date"` // 2025-04-26
+	Name string `json:"name"`
+}
+
+// calendarEvent is a one-off event, or a weekly one when until is set
+type calendarEvent struct {
+	uid         string
+	summary     string
+	location    string
+	description string
+	category    string
+	start, end  time.Time
+	until       time.Time
+	exceptions  []time.Time
+	modified    time.Time
+}
+
+// Feeds returns the user's feed token and subscription URLs, issuing a token on first use
+func (s *CalendarService) Feeds(userID uint) (*CalendarFeeds, error) {
+	var token models.CalendarToken
+	err := s.db.Where("user_id = ?", userID).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.RotateToken(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.feeds(&token)
+}
+
+// RotateToken replaces the user's feed token, breaking existing subscriptions
+func (s *CalendarService) RotateToken(userID uint) (*CalendarFeeds, error) {
+	value, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, err
+	}
+
+	var token models.CalendarToken
+	if err := s.db.Where("user_id = ?", userID).First(&token).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	token.UserID = userID
+	token.Token = value
+	token.LastUsedAt = nil
+	if err := s.db.Save(&token).Error; err != nil {
+		return nil, err
+	}
+	return s.feeds(&token)
+}
+
+// RevokeToken deletes the user's feed token; subscriptions stop updating
+func (s *CalendarService) RevokeToken(userID uint) error {
+	result := s.db.Where("user_id = ?", userID).Delete(&models.CalendarToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no calendar feed token")
+	}
+	return nil
+}
+
+// Authenticate resolves a feed token to its active user
+func (s *CalendarService) Authenticate(value string) (*models.User, error) {
+	var token models.CalendarToken
+	if value == "" || s.db.Where("token = ?", value).First(&token).Error != nil {
+		return nil, errors.New("invalid calendar feed token")
+	}
+	var user models.User
+	if err := s.db.First(&user, token.UserID).Error; err != nil || !user.IsActive {
+		return nil, errors.New("invalid calendar feed token")
+	}
+
+	now := time.Now()
+	s.db.Model(&token).Update("last_used_at", &now)
+	return &user, nil
+}
+
+func (s *CalendarService) feeds(token *models.CalendarToken) (*CalendarFeeds, error) {
+	base := strings.TrimRight(s.cfg.CalendarBaseURL, "/") + "/calendar/"
+	query := ".ics?token=" + token.Token
+	feeds := &CalendarFeeds{
+		Token:      token.Token,
+		LastUsedAt: token.LastUsedAt,
+		Course:     base + FeedCourse + "/{code}" + query,
+		Venue:      base + FeedVenue + "/{id}" + query,
+	}
+
+	var student models.Student
+	var faculty models.Faculty
+	if s.db.Where("user_id = ?", token.UserID).First(&student).Error == nil {
+		feeds.Personal = fmt.Sprintf("%s%s/%d%s", base, FeedStudent, student.ID, query)
+	} else if s.db.Where("user_id = ?", token.UserID).First(&faculty).Error == nil {
+		feeds.Personal = fmt.Sprintf("%s%s/%d%s", base, FeedFaculty, faculty.ID, query)
+	}
+	return feeds, nil
+}
+
+// Feed renders the ICS calendar of a student's, lecturer's, venue's or course's lectures and exams in
+// a semester (0 is the current one). Weekly lectures recur from the semester's start to its end date
+// except on holidays; exam sessions are one-off events. Students and lecturers can only read their
+// own timetable, admins any; course and venue feeds are open to every token.
+func (s *CalendarService) Feed(user *models.User, kind, key string, semesterID uint) ([]byte, error) {
+	var semester models.Semester
+	query := s.db.Where("is_current = ?", true)
+	if semesterID != 0 {
+		query = s.db.Where("id = ?", semesterID)
+	}
+	if err := query.First(&semester).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	// Students, lecturers and venues are keyed by ID, courses by code
+	var id uint
+	if kind != FeedCourse {
+		parsed, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid ID")
+		}
+		id = uint(parsed)
+	}
+
+	var name string
+	var events []calendarEvent
+	var err error
+	switch kind {
+	case FeedStudent:
+		name, events, err = s.studentEvents(user, id, &semester)
+	case FeedFaculty:
+		name, events, err = s.facultyEvents(user, id, &semester)
+	case FeedVenue:
+		name, events, err = s.venueEvents(id, &semester)
+	case FeedCourse:
+		name, events, err = s.courseEvents(key, &semester)
+	default:
+		return nil, errors.New("unknown calendar feed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.render(fmt.Sprintf("%s - %s", name, semester.Name), &semester, events), nil
+}
+
+func (s *CalendarService) studentEvents(user *models.User, id uint, semester *models.Semester) (string, []calendarEvent, error) {
+	var student models.Student
+	if err := s.db.First(&student, id).Error; err != nil {
+		return "", nil, errors.New("student not found")
+	}
+	if user.UserType != "admin" && student.UserID != user.ID {
+		return "", nil, ErrFeedForbidden
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Where("student_id = ? AND semester_id = ? AND status <> ?", student.ID, semester.ID, "dropped").
+		Find(&enrollments).Error; err != nil {
+		return "", nil, err
+	}
+	var lectures []models.Lecture
+	if len(enrollments) > 0 {
+		if err := enrolledLectures(s.db, enrollments).Where("semester_id = ?", semester.ID).
+			Find(&lectures).Error; err != nil {
+			return "", nil, err
+		}
+	}
+	events, err := s.lectureEvents(lectures, semester)
+	if err != nil {
+		return "", nil, err
+	}
+
+	exams, err := s.exams.StudentTimetable(student.ID, semester.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, exam := range exams {
+		date, err := time.Parse(attendanceDateLayout, exam.Date)
+		if err != nil {
+			continue
+		}
+		event, ok := s.oneOff(date, exam.StartTime, exam.EndTime)
+		if !ok {
+			continue
+		}
+		event.uid = fmt.Sprintf("exam-%d-student-%d@%s", exam.ExamSessionID, student.ID, icsDomain)
+		event.summary = examSummary(exam.CourseCode, exam.CourseName, exam.Title)
+		event.location = exam.Venue
+		if exam.SeatNumber > 0 {
+			event.location = fmt.Sprintf("%s, seat %d", exam.Venue, exam.SeatNumber)
+		}
+		if !exam.Eligible {
+			event.description = "Not eligible to sit: attendance below the threshold"
+		}
+		event.category = "Exam"
+		events = append(events, event)
+	}
+
+	return fmt.Sprintf("%s %s", student.FirstName, student.LastName), events, nil
+}
+
+func (s *CalendarService) facultyEvents(user *models.User, id uint, semester *models.Semester) (string, []calendarEvent, error) {
+	var faculty models.Faculty
+	if err := s.db.First(&faculty, id).Error; err != nil {
+		return "", nil, errors.New("faculty member not found")
+	}
+	if user.UserType != "admin" && faculty.UserID != user.ID {
+		return "", nil, ErrFeedForbidden
+	}
+
+	var lectures []models.Lecture
+	if err := s.db.Where("faculty_id = ? AND semester_id = ?", faculty.ID, semester.ID).Find(&lectures).Error; err != nil {
+		return "", nil, err
+	}
+	events, err := s.lectureEvents(lectures, semester)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Exams of every course the lecturer teaches or is assigned to
+	courses := s.db.Model(&models.CourseAssignment{}).Select("course_id").
+		Where("faculty_id = ? AND semester_id = ?", faculty.ID, semester.ID)
+	taught := s.db.Model(&models.Lecture{}).Select("course_id").
+		Where("faculty_id = ? AND semester_id = ?", faculty.ID, semester.ID)
+	exams, err := s.examEvents(s.db.Where("offering_id IN (?) OR offering_id IN (?)",
+		s.db.Model(&models.CourseOffering{}).Select("id").Where("semester_id = ? AND course_id IN (?)", semester.ID, courses),
+		s.db.Model(&models.CourseOffering{}).Select("id").Where("semester_id = ? AND course_id IN (?)", semester.ID, taught)), semester)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s %s", faculty.FirstName, faculty.LastName), append(events, exams...), nil
+}
+
+func (s *CalendarService) venueEvents(id uint, semester *models.Semester) (string, []calendarEvent, error) {
+	var venue models.Venue
+	if err := s.db.First(&venue, id).Error; err != nil {
+		return "", nil, errors.New("venue not found")
+	}
+
+	var lectures []models.Lecture
+	if err := s.db.Where("venue_id = ? AND semester_id = ?", venue.ID, semester.ID).Find(&lectures).Error; err != nil {
+		return "", nil, err
+	}
+	events, err := s.lectureEvents(lectures, semester)
+	if err != nil {
+		return "", nil, err
+	}
+	exams, err := s.examEvents(s.db.Where("id IN (?)",
+		s.db.Model(&models.ExamVenue{}).Select("exam_session_id").Where("venue_id = ?", venue.ID)), semester)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return venueName(&venue), append(events, exams...), nil
+}
+
+func (s *CalendarService) courseEvents(key string, semester *models.Semester) (string, []calendarEvent, error) {
+	var course models.Course
+	if err := s.db.Where("code = ?", strings.ToUpper(key)).First(&course).Error; err != nil {
+		return "", nil, errors.New("course not found")
+	}
+
+	var lectures []models.Lecture
+	if err := s.db.Where("course_id = ? AND semester_id = ?", course.ID, semester.ID).Find(&lectures).Error; err != nil {
+		return "", nil, err
+	}
+	events, err := s.lectureEvents(lectures, semester)
+	if err != nil {
+		return "", nil, err
+	}
+	exams, err := s.examEvents(s.db.Where("offering_id IN (?)",
+		s.db.Model(&models.CourseOffering{}).Select("id").Where("course_id = ? AND semester_id = ?", course.ID, semester.ID)), semester)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s %s", course.Code, course.Name), append(events, exams...), nil
+}
+
+// lectureEvents turns weekly lectures into events recurring over the semester, skipping holidays
+func (s *CalendarService) lectureEvents(lectures []models.Lecture, semester *models.Semester) ([]calendarEvent, error) {
+	if len(lectures) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(lectures))
+	for i := range lectures {
+		ids[i] = lectures[i].ID
+	}
+	if err := s.db.Preload("Course").Preload("Faculty").Preload("Venue").
+		Where("id IN ?", ids).Order("id").Find(&lectures).Error; err != nil {
+		return nil, err
+	}
+
+	var sectionIDs []uint
+	for _, lecture := range lectures {
+		if lecture.SectionID != nil {
+			sectionIDs = append(sectionIDs, *lecture.SectionID)
+		}
+	}
+	sections := map[uint]string{}
+	if len(sectionIDs) > 0 {
+		var rows []models.CourseSection
+		if err := s.db.Where("id IN ?", sectionIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, section := range rows {
+			sections[section.ID] = section.Code
+		}
+	}
+
+	first := s.date(semester.StartDate)
+	last := s.date(semester.EndDate)
+	var holidays []models.Holiday
+	if err := s.db.Where("date BETWEEN ? AND ?", first.Format(attendanceDateLayout), last.Format(attendanceDateLayout)).
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	var events []calendarEvent
+	for _, lecture := range lectures {
+		weekday, ok := parseWeekday(lecture.DayOfWeek)
+		if !ok {
+			continue
+		}
+		event, ok := s.weekly(weekday, lecture.StartTime, lecture.EndTime, first, last, holidays)
+		if !ok {
+			continue
+		}
+		event.uid = fmt.Sprintf("lecture-%d@%s", lecture.ID, icsDomain)
+		event.summary = fmt.Sprintf("%s %s", lecture.Course.Code, lecture.Course.Name)
+		event.location = venueName(&lecture.Venue)
+		event.description = fmt.Sprintf("Lecturer: %s %s", lecture.Faculty.FirstName, lecture.Faculty.LastName)
+		if code, ok := sections[derefUint(lecture.SectionID)]; ok {
+			event.description += "\nSection: " + code
+		}
+		event.category = "Lecture"
+		event.modified = lecture.UpdatedAt
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// weekly builds an event recurring on a weekday from the first to the last day, with the holidays
+// that fall on it as exceptions
+func (s *CalendarService) weekly(weekday time.Weekday, startTime, endTime string, first, last time.Time, holidays []models.Holiday) (calendarEvent, bool) {
+	date := first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7)
+	if date.After(last) {
+		return calendarEvent{}, false
+	}
+	event, ok := s.oneOff(date, startTime, endTime)
+	if !ok {
+		return calendarEvent{}, false
+	}
+	event.until = time.Date(last.Year(), last.Month(), last.Day(), 23, 59, 59, 0, s.location)
+	for _, holiday := range holidays {
+		day := s.date(holiday.Date)
+		if day.Weekday() == weekday && !day.Before(date) && !day.After(last) {
+			event.exceptions = append(event.exceptions, time.Date(day.Year(), day.Month(), day.Day(),
+				event.start.Hour(), event.start.Minute(), 0, 0, s.location))
+		}
+	}
+	return event, true
+}
+
+// examEvents turns the semester's exam sessions matched by a condition into one-off events
+func (s *CalendarService) examEvents(condition *gorm.DB, semester *models.Semester) ([]calendarEvent, error) {
+	var sessions []models.ExamSession
+	if err := s.db.Preload("Offering.Course").Preload("Venues", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Venues.Venue").Where("semester_id = ?", semester.ID).Where(condition).
+		Order("date, start_time").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]calendarEvent, 0, len(sessions))
+	for _, session := range sessions {
+		event, ok := s.oneOff(s.date(session.Date), session.StartTime, session.EndTime)
+		if !ok {
+			continue
+		}
+		venues := make([]string, 0, len(session.Venues))
+		for _, venue := range session.Venues {
+			venues = append(venues, venueName(&venue.Venue))
+		}
+		event.uid = fmt.Sprintf("exam-%d@%s", session.ID, icsDomain)
+		event.summary = examSummary(session.Offering.Course.Code, session.Offering.Course.Name, session.Title)
+		event.location = strings.Join(venues, ", ")
+		event.description = session.Notes
+		event.category = "Exam"
+		event.modified = session.UpdatedAt
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// oneOff builds an event on a date from HH:MM start and end times
+func (s *CalendarService) oneOff(date time.Time, startTime, endTime string) (calendarEvent, bool) {
+	start, err := time.Parse(examTimeLayout, startTime)
+	if err != nil {
+		return calendarEvent{}, false
+	}
+	end, err := time.Parse(examTimeLayout, endTime)
+	if err != nil || !end.After(start) {
+		return calendarEvent{}, false
+	}
+	return calendarEvent{
+		start: time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, s.location),
+		end:   time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), 0, 0, s.location),
+	}, true
+}
+
+// date is the calendar day of a stored date, at midnight in the calendar time zone
+func (s *CalendarService) date(value time.Time) time.Time {
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, s.location)
+}
+
+// render writes an RFC 5545 calendar
+func (s *CalendarService) render(name string, semester *models.Semester, events []calendarEvent) []byte {
+	sort.SliceStable(events, func(a, b int) bool { return events[a].start.Before(events[b].start) })
+
+	zone := s.location.String()
+	now := time.Now().UTC().Format(icsUTCLayout)
+	// A single STANDARD offset, taken at the semester start, is enough for zones without daylight
+	// saving such as East Africa Time; clients also resolve the IANA TZID themselves.
+	abbreviation, offset := s.date(semester.StartDate).Zone()
+
+	var w icsWriter
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//MUST//Mock SIMS//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", icsText(name))
+	w.line("X-WR-TIMEZONE", zone)
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT6H")
+	w.line("X-PUBLISHED-TTL", "PT6H")
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", zone)
+	w.line("BEGIN", "STANDARD")
+	w.line("DTSTART", "19700101T000000")
+	w.line("TZOFFSETFROM", icsOffset(offset))
+	w.line("TZOFFSETTO", icsOffset(offset))
+	w.line("TZNAME", abbreviation)
+	w.line("END", "STANDARD")
+	w.line("END", "VTIMEZONE")
+
+	for _, event := range events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", event.uid)
+		w.line("DTSTAMP", now)
+		if !event.modified.IsZero() {
+			w.line("LAST-MODIFIED", event.modified.UTC().Format(icsUTCLayout))
+		}
+		w.line("DTSTART;TZID="+zone, event.start.Format(icsLocalLayout))
+		w.line("DTEND;TZID="+zone, event.end.Format(icsLocalLayout))
+		if !event.until.IsZero() {
+			w.line("RRULE", "FREQ=WEEKLY;UNTIL="+event.until.UTC().Format(icsUTCLayout))
+			if len(event.exceptions) > 0 {
+				dates := make([]string, len(event.exceptions))
+				for i, exception := range event.exceptions {
+					dates[i] = exception.Format(icsLocalLayout)
+				}
+				w.line("EXDATE;TZID="+zone, strings.Join(dates, ","))
+			}
+		}
+		w.line("SUMMARY", icsText(event.summary))
+		if event.location != "" {
+			w.line("LOCATION", icsText(event.location))
+		}
+		if event.description != "" {
+			w.line("DESCRIPTION", icsText(event.description))
+		}
+		w.line("CATEGORIES", event.category)
+		w.line("END", "VEVENT")
+	}
+	w.line("END", "VCALENDAR")
+	return []byte(w.String())
+}
+
+// Holidays lists the holidays within a semester, or every holiday when semesterID is 0
+func (s *CalendarService) Holidays(semesterID uint) ([]models.Holiday, error) {
+	query := s.db.Order("date")
+	if semesterID != 0 {
+		var semester models.Semester
+		if err := s.db.First(&semester, semesterID).Error; err != nil {
+			return nil, errors.New("semester not found")
+		}
+		query = query.Where("date BETWEEN ? AND ?",
+			semester.StartDate.Format(attendanceDateLayout), semester.EndDate.Format(attendanceDateLayout))
+	}
+
+	holidays := []models.Holiday{}
+	if err := query.Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// CreateHoliday adds a holiday; lectures falling on it drop out of the calendar feeds
+func (s *CalendarService) CreateHoliday(input HolidayInput) (*models.Holiday, error) {
+	date, err := time.Parse(attendanceDateLayout, input.Date)
+	if err != nil {
+		return nil, errors.New("date must be YYYY-MM-DD")
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Holiday{}).Where("date = ?", input.Date).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("a holiday already exists on that date")
+	}
+
+	holiday := models.Holiday{Date: date, Name: strings.TrimSpace(input.Name)}
+	if err := s.db.Create(&holiday).Error; err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// DeleteHoliday removes a holiday
+func (s *CalendarService) DeleteHoliday(id uint) error {
+	result := s.db.Delete(&models.Holiday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("holiday not found")
+	}
+	return nil
+}
+
+// icsWriter builds CRLF-terminated content lines folded at 75 octets
+type icsWriter struct {
+	strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	line := name + ":" + value
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 { // Never split a UTF-8 sequence
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// icsText escapes a TEXT property value
+func icsText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// icsOffset formats a UTC offset in seconds as +0300
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+func examSummary(code, name, title string) string {
+	if title == "" {
+		title = "Examination"
+	}
+	return fmt.Sprintf("%s %s: %s", code, name, title)
+}
+
+func parseWeekday(day string) (time.Weekday, bool) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+func derefUint(value *uint) uint {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mwombeki6/mock-sims/internal/models"
+)
+
+var testZone = time.FixedZone("EAT", 3*60*60)
+
+func testCalendar() *CalendarService {
+	return &CalendarService{location: testZone}
+}
+
+func calendarDay(year int, month time.Month, date int) time.Time {
+	return time.Date(year, month, date, 0, 0, 0, 0, testZone)
+}
+
+func TestWeekly(t *testing.T) {
+	s := testCalendar()
+	// A semester from Wednesday 5 March to Friday 18 July 2025
+	first, last := calendarDay(2025, time.March, 5), calendarDay(2025, time.July, 18)
+	holidays := []models.Holiday{
+		{Date: calendarDay(2025, time.March, 3), Name: "Before the semester"},
+		{Date: calendarDay(2025, time.April, 21), Name: "Easter Monday"},
+		{Date: calendarDay(2025, time.April, 22), Name: "A Tuesday"},
+		{Date: calendarDay(2025, time.July, 21), Name: "After the semester"},
+	}
+
+	tests := []struct {
+		name           string
+		weekday        time.Weekday
+		start, end     string
+		first, last    time.Time
+		wantOK         bool
+		wantStart      time.Time
+		wantExceptions []time.Time
+	}{
+		{
+			name:           "first Monday after the start, skipping Easter Monday",
+			weekday:        time.Monday,
+			start:          "08:00",
+			end:            "10:00",
+			first:          first,
+			last:           last,
+			wantOK:         true,
+			wantStart:      time.Date(2025, time.March, 10, 8, 0, 0, 0, testZone),
+			wantExceptions: []time.Time{time.Date(2025, time.April, 21, 8, 0, 0, 0, testZone)},
+		},
+		{
+			name:      "starts on the first day when it is the weekday",
+			weekday:   time.Wednesday,
+			start:     "14:00",
+			end:       "16:00",
+			first:     first,
+			last:      last,
+			wantOK:    true,
+			wantStart: time.Date(2025, time.March, 5, 14, 0, 0, 0, testZone),
+		},
+		{
+			name:    "weekday never falls in the range",
+			weekday: time.Monday,
+			start:   "08:00",
+			end:     "10:00",
+			first:   first,
+			last:    calendarDay(2025, time.March, 7),
+		},
+		{
+			name:    "end before start",
+			weekday: time.Monday,
+			start:   "10:00",
+			end:     "08:00",
+			first:   first,
+			last:    last,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, ok := s.weekly(test.weekday, test.start, test.end, test.first, test.last, holidays)
+			if ok != test.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !event.start.Equal(test.wantStart) {
+				t.Errorf("start = %v, want %v", event.start, test.wantStart)
+			}
+			if want := time.Date(2025, time.July, 18, 23, 59, 59, 0, testZone); !event.until.Equal(want) {
+				t.Errorf("until = %v, want %v", event.until, want)
+			}
+			if !reflect.DeepEqual(event.exceptions, test.wantExceptions) {
+				t.Errorf("exceptions = %v, want %v", event.exceptions, test.wantExceptions)
+			}
+		})
+	}
+}
+
+func TestRenderRecurrence(t *testing.T) {
+	s := testCalendar()
+	first, last := calendarDay(2025, time.March, 5), calendarDay(2025, time.July, 18)
+	event, ok := s.weekly(time.Monday, "08:00", "10:00", first, last,
+		[]models.Holiday{{Date: calendarDay(2025, time.April, 21)}, {Date: calendarDay(2025, time.May, 26)}})
+	if !ok {
+		t.Fatal("no event")
+	}
+	event.uid, event.summary, event.category = "lecture-1@mock-sims", "CS101 Programming", "Lecture"
+
+	ics := string(s.render("CS101", &models.Semester{StartDate: first}, []calendarEvent{event}))
+	for _, want := range []string{
+		"DTSTART;TZID=EAT:20250310T080000\r\n",
+		"DTEND;TZID=EAT:20250310T100000\r\n",
+		"RRULE:FREQ=WEEKLY;UNTIL=20250718T205959Z\r\n",
+		"EXDATE;TZID=EAT:20250421T080000,20250526T080000\r\n",
+		"TZOFFSETTO:+0300\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar is missing %q:\n%s", want, ics)
+		}
+	}
+}
+
+func TestICSWriterLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []int // Octets per physical line, CRLF excluded
+	}{
+		{"short", "CS101", []int{13}},
+		{"exactly 75 octets", strings.Repeat("a", 67), []int{75}},
+		{"folded", strings.Repeat("a", 100), []int{75, 34}},
+		// SUMMARY: is 8 octets, so the two-octet é spans octets 75 and 76 and moves to the next line
+		{"split before a UTF-8 sequence", strings.Repeat("a", 66) + "é" + strings.Repeat("b", 10), []int{74, 13}},
+		{"multibyte throughout", strings.Repeat("ü", 60), []int{74, 55}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var w icsWriter
+			w.line("SUMMARY", test.value)
+			out := w.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line is not CRLF-terminated: %q", out)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			var octets []int
+			for i, line := range lines {
+				octets = append(octets, len(line))
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, line)
+				}
+			}
+			if !reflect.DeepEqual(octets, test.want) {
+				t.Errorf("octets per line = %v, want %v", octets, test.want)
+			}
+			if unfolded := strings.ReplaceAll(out, "\r\n ", ""); unfolded != "SUMMARY:"+test.value+"\r\n" {
+				t.Errorf("unfolded = %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestICSText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"CS101 Programming", "CS101 Programming"},
+		{"Block A 101, Block B 2", `Block A 101\, Block B 2`},
+		{"Lab; bring coats", `Lab\; bring coats`},
+		{`C:\path`, `C:\\path`},
+		{"Lecturer: J Doe\nSection: A", `Lecturer: J Doe\nSection: A`},
+		{"one\r\ntwo", `one\ntwo`},
+		{`a\,b`, `a\\\,b`},
+	}
+	for _, test := range tests {
+		if got := icsText(test.value); got != test.want {
+			t.Errorf("icsText(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// ChangeFeedEntities are the entity types returned by the change feed when no filter is given
+var ChangeFeedEntities = []string{"enrollment", "grade", "student", "course", "payment"}
+
+// cursorPrefix versions the opaque cursor format so it can evolve without breaking clients
+const cursorPrefix = "v1:"
+
+// ErrInvalidCursor is returned when a client sends a cursor SIMS did not issue
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type ChangeFeedService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewChangeFeedService(db *gorm.DB, cfg *config.Config) *ChangeFeedService {
+	return &ChangeFeedService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Change is a single entry of the change feed
+type Change struct {
+	Sequence   uint                   `json:"-"` // Domain event ID, used to order live and replayed events
+	Cursor     string                 `json:"cursor"`
+	EventID    string                 `json:"event_id"`
+	Event      string                 `json:"event"`
+	Version    int                    `json:"version"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   uint                   `json:"entity_id"`
+	Operation  string                 `json:"operation"` // upsert, delete
+	OccurredAt string                 `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// ChangePage is one page of the change feed
+type ChangePage struct {
+	Changes    []Change `json:"changes"`
+	NextCursor string   `json:"next_cursor"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// EncodeCursor turns a domain event ID into an opaque cursor
+func EncodeCursor(eventID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(uint64(eventID), 10)))
+}
+
+// DecodeCursor returns the domain event ID behind a cursor (empty cursor means the beginning)
+func DecodeCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(raw), cursorPrefix), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	return uint(id), nil
+}
+
+// GetChanges returns changes recorded after the given cursor, oldest first
+func (s *ChangeFeedService) GetChanges(cursor string, entities []string, limit int) (*ChangePage, error) {
+	afterID, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entities) == 0 {
+		entities = ChangeFeedEntities
+	}
+
+	// Fetch one extra row to know whether another page exists
+	var events []models.DomainEvent
+	err = s.db.
+		Where("id > ? AND entity_type IN ?", afterID, entities).
+		Order("id ASC").
+		Limit(limit + 1).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChangePage{
+		Changes:    []Change{},
+		NextCursor: cursor,
+	}
+	if len(events) > limit {
+		page.HasMore = true
+		events = events[:limit]
+	}
+
+	for _, event := range events {
+		change, err := changeFromEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		page.Changes = append(page.Changes, change)
+		page.NextCursor = change.Cursor
+	}
+
+	// A client that starts from the beginning of an empty feed should still get a resumable cursor
+	if page.NextCursor == "" {
+		page.NextCursor = EncodeCursor(afterID)
+	}
+
+	return page, nil
+}
+
+// changeFromEvent converts a persisted event into a change feed entry
+func changeFromEvent(event models.DomainEvent) (Change, error) {
+	var payload WebhookPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return Change{}, err
+	}
+
+	operation := "upsert"
+	if event.Tombstone {
+		operation = "delete"
+	}
+
+	return Change{
+		Sequence:   event.ID,
+		Cursor:     EncodeCursor(event.ID),
+		EventID:    event.EventID,
+		Event:      event.Event,
+		Version:    event.Version,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		Operation:  operation,
+		OccurredAt: payload.Timestamp,
+		Data:       payload.Data,
+	}, nil
+}
+
+// ReplayRange resolves a live stream's Last-Event-ID into the persisted events to replay: those after
+// the cursor up to the newest event now. Call it after subscribing to the broker, which delivers
+// everything newer. Without a cursor the range is empty and the stream starts at the newest event.
+func (s *ChangeFeedService) ReplayRange(cursor string) (afterID, upTo uint, err error) {
+	afterID, err = DecodeCursor(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := s.db.Model(&models.DomainEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&upTo).Error; err != nil {
+		return 0, 0, err
+	}
+	if cursor == "" {
+		afterID = upTo
+	}
+
+	return afterID, upTo, nil
+}
+
+// ReplayEvents sends the events in (afterID, upTo] that match the filter, oldest first, a page at a
+// time so a long disconnect does not load every missed event at once
+func (s *ChangeFeedService) ReplayEvents(afterID, upTo uint, filter EventFilter, send func(Change) error) error {
+	for afterID < upTo {
+		var events []models.DomainEvent
+		err := filter.where(s.db.Where("id > ? AND id <= ?", afterID, upTo)).
+			Order("id ASC").
+			Limit(replayPageSize).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			afterID = event.ID
+			// LIKE treats "_" as a wildcard, so confirm the match exactly
+			if !filter.Matches(event.Event) {
+				continue
+			}
+			change, err := changeFromEvent(event)
+			if err != nil {
+				return err
+			}
+			if err := send(change); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < replayPageSize {
+			break
+		}
+	}
+
+	return nil
+}
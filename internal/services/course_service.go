@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
@@ -9,17 +11,67 @@ import (
 )
 
 type CourseService struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db        *gorm.DB
+	cfg       *config.Config
+	events    *EventPublisher
+	timetable *TimetableService
 }
 
 func NewCourseService(db *gorm.DB, cfg *config.Config) *CourseService {
 	return &CourseService{
-		db:  db,
-		cfg: cfg,
+		db:        db,
+		cfg:       cfg,
+		events:    NewEventPublisher(db, cfg),
+		timetable: NewTimetableService(db, cfg),
 	}
 }
 
+// ErrTimetableConflicts rejects a lecture that would clash with the semester's timetable
+var ErrTimetableConflicts = errors.New("the lecture conflicts with the timetable; send force to save it anyway")
+
+// CourseInput carries catalogue fields for creating or updating a course
+type CourseInput struct {
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Credits        int    `json:"credits"`
+	Level          int    `json:"level"`
+	Description    string `json:"description"`
+	DepartmentCode string `json:"department_code"`
+	RequiresLab    *bool  `json:"requires_lab"` // Timetable in lab venues; nil leaves it unchanged
+}
+
+// RescheduleLectureInput carries the new slot for a lecture (empty fields are left untouched)
+type RescheduleLectureInput struct {
+	DayOfWeek string `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	VenueID   uint   `json:"venue_id"`
+	Section   string `json:"section"` // Move the lecture to a section of its offering
+	Force     bool   `json:"force"`   // Save despite timetable conflicts
+}
+
+// LectureInput schedules a new weekly lecture for a course
+type LectureInput struct {
+	CourseCode string `json:"course_code"`
+	StaffID    string `json:"staff_id"`    // Defaults to the course's assigned lecturer
+	SemesterID uint   `json:"semester_id"` // Defaults to the current semester
+	Section    string `json:"section"`
+	DayOfWeek  string `json:"day_of_week"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	VenueID    uint   `json:"venue_id"`
+	Force      bool   `json:"force"` // Save despite timetable conflicts
+}
+
+// CourseAssignmentInput assigns a lecturer to a course for a semester
+type CourseAssignmentInput struct {
+	CourseCode string `json:"course_code"`
+	StaffID    string `json:"staff_id"`
+	SemesterID uint   `json:"semester_id"`
+	Role       string `json:"role"`
+	Section    string `json:"section"` // Section code; empty teaches every section
+}
+
 // ListCourses retrieves all courses with pagination
 func (s *CourseService) ListCourses(page, limit int) ([]models.Course, int64, error) {
 	var courses []models.Course
@@ -131,3 +183,341 @@ func (s *CourseService) SearchCourses(query string) ([]models.Course, error) {
 
 	return courses, nil
 }
+
+// CreateCourse adds a course to the catalogue and publishes course.created
+func (s *CourseService) CreateCourse(input CourseInput) (*models.Course, error) {
+	if input.Code == "" || input.Name == "" || input.Credits <= 0 || input.DepartmentCode == "" {
+		return nil, errors.New("code, name, credits and department_code are required")
+	}
+
+	var department models.Department
+	if err := s.db.Where("code = ?", input.DepartmentCode).First(&department).Error; err != nil {
+		return nil, errors.New("department not found")
+	}
+
+	course := models.Course{
+		Code:         input.Code,
+		Name:         input.Name,
+		Credits:      input.Credits,
+		Level:        input.Level,
+		Description:  input.Description,
+		DepartmentID: department.ID,
+	}
+	if input.RequiresLab != nil {
+		course.RequiresLab = *input.RequiresLab
+	}
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Create(&course).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventCourseCreated, courseEventData(&course))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+// UpdateCourse updates catalogue fields of a course and publishes course.updated
+func (s *CourseService) UpdateCourse(code string, input CourseInput) (*models.Course, error) {
+	var course models.Course
+	if err := s.db.Where("code = ?", code).First(&course).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+
+	if input.Name != "" {
+		course.Name = input.Name
+	}
+	if input.Credits > 0 {
+		course.Credits = input.Credits
+	}
+	if input.Level > 0 {
+		course.Level = input.Level
+	}
+	if input.Description != "" {
+		course.Description = input.Description
+	}
+	if input.DepartmentCode != "" {
+		var department models.Department
+		if err := s.db.Where("code = ?", input.DepartmentCode).First(&department).Error; err != nil {
+			return nil, errors.New("department not found")
+		}
+		course.DepartmentID = department.ID
+	}
+	if input.RequiresLab != nil {
+		course.RequiresLab = *input.RequiresLab
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Save(&course).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventCourseUpdated, courseEventData(&course))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
+// RescheduleLecture moves a lecture to a new slot and publishes lecture.rescheduled. The new slot is
+// checked against the semester's timetable like CreateLecture.
+func (s *CourseService) RescheduleLecture(lectureID uint, input RescheduleLectureInput) (*models.Lecture, []TimetableConflict, error) {
+	var lecture models.Lecture
+	if err := s.db.First(&lecture, lectureID).Error; err != nil {
+		return nil, nil, errors.New("lecture not found")
+	}
+	previous := lecture
+
+	if input.DayOfWeek != "" {
+		lecture.DayOfWeek = input.DayOfWeek
+	}
+	if input.StartTime != "" {
+		lecture.StartTime = input.StartTime
+	}
+	if input.EndTime != "" {
+		lecture.EndTime = input.EndTime
+	}
+	if input.VenueID != 0 {
+		var venue models.Venue
+		if err := s.db.First(&venue, input.VenueID).Error; err != nil {
+			return nil, nil, errors.New("venue not found")
+		}
+		lecture.VenueID = venue.ID
+	}
+
+	if input.Section != "" {
+		section, err := s.findSection(lecture.CourseID, lecture.SemesterID, input.Section)
+		if err != nil {
+			return nil, nil, err
+		}
+		lecture.SectionID = &section.ID
+	}
+
+	// The merged slot is normalised like CreateLecture's, so conflicts compare like for like
+	start, err := time.Parse(examTimeLayout, strings.TrimSpace(lecture.StartTime))
+	if err != nil {
+		return nil, nil, errors.New("start_time must be HH:MM")
+	}
+	end, err := time.Parse(examTimeLayout, strings.TrimSpace(lecture.EndTime))
+	if err != nil {
+		return nil, nil, errors.New("end_time must be HH:MM")
+	}
+	if !end.After(start) {
+		return nil, nil, errors.New("start_time must be before end_time")
+	}
+	weekday, ok := parseWeekday(lecture.DayOfWeek)
+	if !ok {
+		return nil, nil, errors.New("day_of_week must be a weekday name, e.g. Monday")
+	}
+	lecture.DayOfWeek = weekday.String()
+	lecture.StartTime = start.Format(examTimeLayout)
+	lecture.EndTime = end.Format(examTimeLayout)
+
+	conflicts, err := s.timetable.LectureConflicts(&lecture)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(conflicts) > 0 && !input.Force {
+		return nil, conflicts, ErrTimetableConflicts
+	}
+
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Save(&lecture).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventLectureRescheduled, lectureEventData(&lecture, &previous))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &lecture, conflicts, nil
+}
+
+// CreateLecture schedules a new weekly lecture after checking it against the semester's timetable.
+// Conflicts reject the lecture unless Force is set; forced lectures are returned with them.
+func (s *CourseService) CreateLecture(input LectureInput) (*models.Lecture, []TimetableConflict, error) {
+	var course models.Course
+	if err := s.db.Where("code = ?", strings.ToUpper(strings.TrimSpace(input.CourseCode))).First(&course).Error; err != nil {
+		return nil, nil, errors.New("course not found")
+	}
+
+	var semester models.Semester
+	if input.SemesterID != 0 {
+		if err := s.db.First(&semester, input.SemesterID).Error; err != nil {
+			return nil, nil, errors.New("semester not found")
+		}
+	} else if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+		return nil, nil, errors.New("no current semester")
+	}
+
+	var venue models.Venue
+	if err := s.db.First(&venue, input.VenueID).Error; err != nil {
+		return nil, nil, errors.New("venue not found")
+	}
+
+	start, err := time.Parse(examTimeLayout, input.StartTime)
+	if err != nil {
+		return nil, nil, errors.New("start_time must be HH:MM")
+	}
+	end, err := time.Parse(examTimeLayout, input.EndTime)
+	if err != nil {
+		return nil, nil, errors.New("end_time must be HH:MM")
+	}
+	if !end.After(start) {
+		return nil, nil, errors.New("start_time must be before end_time")
+	}
+	weekday, ok := parseWeekday(input.DayOfWeek)
+	if !ok {
+		return nil, nil, errors.New("day_of_week must be a weekday name, e.g. Monday")
+	}
+
+	lecture := models.Lecture{
+		CourseID:   course.ID,
+		SemesterID: semester.ID,
+		VenueID:    venue.ID,
+		DayOfWeek:  weekday.String(),
+		StartTime:  start.Format(examTimeLayout),
+		EndTime:    end.Format(examTimeLayout),
+	}
+	if input.Section != "" {
+		section, err := s.findSection(course.ID, semester.ID, input.Section)
+		if err != nil {
+			return nil, nil, err
+		}
+		lecture.SectionID = &section.ID
+	}
+
+	if input.StaffID != "" {
+		var faculty models.Faculty
+		if err := s.db.Where("staff_id = ?", input.StaffID).First(&faculty).Error; err != nil {
+			return nil, nil, errors.New("faculty member not found")
+		}
+		lecture.FacultyID = faculty.ID
+	} else {
+		var assignments []models.CourseAssignment
+		if err := s.db.Where("course_id = ? AND semester_id = ?", course.ID, semester.ID).
+			Order("id").Find(&assignments).Error; err != nil {
+			return nil, nil, err
+		}
+		var section *models.CourseSection
+		if lecture.SectionID != nil {
+			section = &models.CourseSection{ID: *lecture.SectionID}
+		}
+		if lecture.FacultyID = sectionLecturer(assignments, section); lecture.FacultyID == 0 {
+			return nil, nil, errors.New("no lecturer is assigned to the course; send staff_id")
+		}
+	}
+
+	conflicts, err := s.timetable.LectureConflicts(&lecture)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(conflicts) > 0 && !input.Force {
+		return nil, conflicts, ErrTimetableConflicts
+	}
+
+	if err := s.db.Create(&lecture).Error; err != nil {
+		return nil, nil, err
+	}
+	return &lecture, conflicts, nil
+}
+
+// AssignCourse assigns a lecturer to a course and publishes course_assignment.changed
+func (s *CourseService) AssignCourse(input CourseAssignmentInput) (*models.CourseAssignment, error) {
+	var course models.Course
+	if err := s.db.Where("code = ?", input.CourseCode).First(&course).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+
+	var faculty models.Faculty
+	if err := s.db.Where("staff_id = ?", input.StaffID).First(&faculty).Error; err != nil {
+		return nil, errors.New("faculty member not found")
+	}
+
+	var semester models.Semester
+	if input.SemesterID != 0 {
+		if err := s.db.First(&semester, input.SemesterID).Error; err != nil {
+			return nil, errors.New("semester not found")
+		}
+	} else if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+		return nil, errors.New("no current semester")
+	}
+
+	if input.Role == "" {
+		input.Role = "Lecturer"
+	}
+
+	assignment := models.CourseAssignment{
+		CourseID:   course.ID,
+		FacultyID:  faculty.ID,
+		SemesterID: semester.ID,
+		Role:       input.Role,
+	}
+	if input.Section != "" {
+		section, err := s.findSection(course.ID, semester.ID, input.Section)
+		if err != nil {
+			return nil, err
+		}
+		assignment.SectionID = &section.ID
+	}
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Create(&assignment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventCourseAssignmentChanged, courseAssignmentEventData(&assignment, "assigned"))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// RemoveCourseAssignment removes a lecturer assignment and publishes course_assignment.changed
+func (s *CourseService) RemoveCourseAssignment(assignmentID uint) error {
+	var assignment models.CourseAssignment
+	if err := s.db.First(&assignment, assignmentID).Error; err != nil {
+		return errors.New("course assignment not found")
+	}
+
+	return s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Delete(&assignment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventCourseAssignmentChanged, courseAssignmentEventData(&assignment, "removed"))
+	})
+}
+
+// DeleteCourse soft-deletes a course and publishes a course.deleted tombstone
+func (s *CourseService) DeleteCourse(code string) error {
+	var course models.Course
+	if err := s.db.Where("code = ?", code).First(&course).Error; err != nil {
+		return errors.New("course not found")
+	}
+
+	return s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Delete(&course).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventCourseDeleted, tombstoneEventData("course_id", course.ID, time.Now()))
+	})
+}
+
+// findSection looks up a section of a course offering by code
+func (s *CourseService) findSection(courseID, semesterID uint, code string) (*models.CourseSection, error) {
+	offering, err := findOrCreateOffering(s.db, courseID, semesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var section models.CourseSection
+	if err := s.db.Where("offering_id = ? AND code = ?", offering.ID, strings.ToUpper(code)).
+		First(&section).Error; err != nil {
+		return nil, errors.New("section not found")
+	}
+	return &section, nil
+}
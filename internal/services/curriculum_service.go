@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Curriculum course types
+const (
+	CourseTypeCore     = "core"
+	CourseTypeElective = "elective"
+	CourseTypeOptional = "optional"
+)
+
+type CurriculumService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewCurriculumService(db *gorm.DB, cfg *config.Config) *CurriculumService {
+	return &CurriculumService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Curriculum is a program's course structure by year of study and semester
+type Curriculum struct {
+	Program models.Program   `json:"program"`
+	Years   []CurriculumYear `json:"years"`
+}
+
+type CurriculumYear struct {
+	YearOfStudy int                  `json:"year_of_study"`
+	Semesters   []CurriculumSemester `json:"semesters"`
+}
+
+type CurriculumSemester struct {
+	SemesterNum    int                     `json:"semester_num"`
+	Core           []CurriculumCourse      `json:"core"`
+	ElectiveGroups []CurriculumElectiveSet `json:"elective_groups"`
+	Optional       []CurriculumCourse      `json:"optional"`
+	CoreCredits    int                     `json:"core_credits"`
+	MinCredits     int                     `json:"min_credits"` // Core credits plus each elective group's minimum
+}
+
+type CurriculumElectiveSet struct {
+	ID         uint               `json:"id"`
+	Name       string             `json:"name"`
+	MinCredits int                `json:"min_credits"`
+	Courses    []CurriculumCourse `json:"courses"`
+}
+
+type CurriculumCourse struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Credits int    `json:"credits"`
+	Level   int    `json:"level"`
+}
+
+// GetCurriculum returns the curriculum of a program grouped by year and semester
+func (s *CurriculumService) GetCurriculum(programCode string) (*Curriculum, error) {
+	var program models.Program
+	if err := s.db.Where("code = ?", strings.ToUpper(programCode)).First(&program).Error; err != nil {
+		return nil, errors.New("program not found")
+	}
+
+	var entries []models.ProgramCourse
+	if err := s.db.Preload("Course").Preload("ElectiveGroup").
+		Where("program_id = ?", program.ID).
+		Order("year_of_study, semester_num, course_id").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	// Entries are ordered by year and semester, so years and semesters are appended in order
+	curriculum := &Curriculum{Program: program, Years: []CurriculumYear{}}
+	for _, entry := range entries {
+		semester := curriculum.semester(entry.YearOfStudy, entry.SemesterNum)
+		course := CurriculumCourse{
+			Code:    entry.Course.Code,
+			Name:    entry.Course.Name,
+			Credits: entry.Course.Credits,
+			Level:   entry.Course.Level,
+		}
+
+		switch {
+		case entry.CourseType == CourseTypeElective && entry.ElectiveGroup != nil:
+			group := semester.electiveGroup(entry.ElectiveGroup)
+			group.Courses = append(group.Courses, course)
+		case entry.CourseType == CourseTypeOptional:
+			semester.Optional = append(semester.Optional, course)
+		default:
+			semester.Core = append(semester.Core, course)
+			semester.CoreCredits += course.Credits
+			semester.MinCredits += course.Credits
+		}
+	}
+
+	return curriculum, nil
+}
+
+// semester returns the entry for a year and semester, creating it in order
+func (c *Curriculum) semester(yearOfStudy, semesterNum int) *CurriculumSemester {
+	var year *CurriculumYear
+	for i := range c.Years {
+		if c.Years[i].YearOfStudy == yearOfStudy {
+			year = &c.Years[i]
+			break
+		}
+	}
+	if year == nil {
+		c.Years = append(c.Years, CurriculumYear{YearOfStudy: yearOfStudy})
+		year = &c.Years[len(c.Years)-1]
+	}
+
+	for i := range year.Semesters {
+		if year.Semesters[i].SemesterNum == semesterNum {
+			return &year.Semesters[i]
+		}
+	}
+	year.Semesters = append(year.Semesters, CurriculumSemester{
+		SemesterNum:    semesterNum,
+		Core:           []CurriculumCourse{},
+		ElectiveGroups: []CurriculumElectiveSet{},
+		Optional:       []CurriculumCourse{},
+	})
+	return &year.Semesters[len(year.Semesters)-1]
+}
+
+// electiveGroup returns the semester's entry for an elective group, creating it on first use
+func (s *CurriculumSemester) electiveGroup(group *models.ElectiveGroup) *CurriculumElectiveSet {
+	for i := range s.ElectiveGroups {
+		if s.ElectiveGroups[i].ID == group.ID {
+			return &s.ElectiveGroups[i]
+		}
+	}
+	s.ElectiveGroups = append(s.ElectiveGroups, CurriculumElectiveSet{
+		ID:         group.ID,
+		Name:       group.Name,
+		MinCredits: group.MinCredits,
+		Courses:    []CurriculumCourse{},
+	})
+	s.MinCredits += group.MinCredits
+	return &s.ElectiveGroups[len(s.ElectiveGroups)-1]
+}
@@ -0,0 +1,666 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/models"
+)
+
+// Domain event types published to the LMS
+const (
+	EventStudentCreated          = "student.created"
+	EventStudentUpdated          = "student.updated"
+	EventStudentStatusChanged    = "student.status_changed"
+	EventCourseCreated           = "course.created"
+	EventCourseUpdated           = "course.updated"
+	EventProgramUpdated          = "program.updated"
+	EventEnrollmentCreated       = "enrollment.created"
+	EventEnrollmentUpdated       = "enrollment.updated"
+	EventGradeSubmitted          = "grade.submitted"
+	EventLectureRescheduled      = "lecture.rescheduled"
+	EventCourseAssignmentChanged = "course_assignment.changed"
+	EventSemesterActivated       = "semester.activated"
+	EventPaymentReceived         = "payment.received"
+	EventWaitlistJoined          = "waitlist.joined"
+	EventWaitlistPromoted        = "waitlist.promoted"
+	EventResultsPublished        = "results.published"
+	EventAttendanceBelow         = "attendance.below_threshold"
+
+	// Tombstones published when a record is soft-deleted
+	EventStudentDeleted    = "student.deleted"
+	EventCourseDeleted     = "course.deleted"
+	EventEnrollmentDeleted = "enrollment.deleted"
+	EventPaymentDeleted    = "payment.deleted"
+)
+
+// EventDefinition describes a published event and the JSON schema of its payload
+type EventDefinition struct {
+	Type          string                 `json:"type"`
+	Version       int                    `json:"version"`
+	Entity        string                 `json:"entity"`
+	EntityIDField string                 `json:"entity_id_field"` // Data key holding the entity's ID
+	Tombstone     bool                   `json:"tombstone"`       // True when the event marks a deletion
+	Description   string                 `json:"description"`
+	Schema        map[string]interface{} `json:"schema"`
+}
+
+// eventCatalogue lists every event SIMS emits, in the order shown at /webhooks/events
+var eventCatalogue = []EventDefinition{
+	{
+		Type:          EventStudentCreated,
+		Version:       1,
+		Entity:        "student",
+		EntityIDField: "student_id",
+		Description:   "A student record was created",
+		Schema:        studentSchema(EventStudentCreated, 1),
+	},
+	{
+		Type:          EventStudentUpdated,
+		Version:       1,
+		Entity:        "student",
+		EntityIDField: "student_id",
+		Description:   "A student's profile or academic details changed",
+		Schema:        studentSchema(EventStudentUpdated, 1),
+	},
+	{
+		Type:          EventStudentStatusChanged,
+		Version:       1,
+		Entity:        "student",
+		EntityIDField: "student_id",
+		Description:   "A student's enrollment status changed (e.g. active to suspended)",
+		Schema: eventSchema(EventStudentStatusChanged, 1, []string{"student_id", "reg_number", "previous_status", "status"}, map[string]interface{}{
+			"student_id":      integerProp(),
+			"reg_number":      stringProp(),
+			"previous_status": stringProp(),
+			"status":          enumProp("active", "probation", "suspended", "graduated", "discontinued"),
+			"reason":          stringProp(),
+			"changed_at":      dateTimeProp(),
+		}),
+	},
+	{
+		Type:          EventCourseCreated,
+		Version:       1,
+		Entity:        "course",
+		EntityIDField: "course_id",
+		Description:   "A course was added to the catalogue",
+		Schema:        courseSchema(EventCourseCreated, 1),
+	},
+	{
+		Type:          EventCourseUpdated,
+		Version:       1,
+		Entity:        "course",
+		EntityIDField: "course_id",
+		Description:   "A catalogue course changed",
+		Schema:        courseSchema(EventCourseUpdated, 1),
+	},
+	{
+		Type:          EventProgramUpdated,
+		Version:       1,
+		Entity:        "program",
+		EntityIDField: "program_id",
+		Description:   "An academic program changed",
+		Schema: eventSchema(EventProgramUpdated, 1, []string{"program_id", "code", "name"}, map[string]interface{}{
+			"program_id":    integerProp(),
+			"department_id": integerProp(),
+			"code":          stringProp(),
+			"name":          stringProp(),
+			"degree_level":  stringProp(),
+			"nta_level":     integerProp(),
+			"duration":      integerProp(),
+			"tuition_fees":  integerProp(),
+		}),
+	},
+	{
+		Type:          EventEnrollmentCreated,
+		Version:       1,
+		Entity:        "enrollment",
+		EntityIDField: "enrollment_id",
+		Description:   "A student was enrolled in a course",
+		Schema:        enrollmentSchema(EventEnrollmentCreated, 1),
+	},
+	{
+		Type:          EventEnrollmentUpdated,
+		Version:       1,
+		Entity:        "enrollment",
+		EntityIDField: "enrollment_id",
+		Description:   "An enrollment's status changed (e.g. dropped, completed)",
+		Schema:        enrollmentSchema(EventEnrollmentUpdated, 1),
+	},
+	{
+		Type:          EventGradeSubmitted,
+		Version:       2, // v2 added outcome
+		Entity:        "grade",
+		EntityIDField: "grade_id",
+		Description:   "A final grade or outcome (ABS, I, DQ) was published for an enrollment",
+		Schema: eventSchema(EventGradeSubmitted, 2, []string{"grade_id", "enrollment_id", "total_marks", "letter_grade"}, map[string]interface{}{
+			"grade_id":      integerProp(),
+			"enrollment_id": integerProp(),
+			"student_id":    integerProp(),
+			"course_id":     integerProp(),
+			"ca_marks":      numberProp(),
+			"final_exam":    numberProp(),
+			"total_marks":   numberProp(),
+			"letter_grade":  stringProp(),
+			"grade_point":   numberProp(),
+			"outcome":       enumProp("", MarkerAbsent, MarkerIncomplete, MarkerDisqualified),
+			"submitted_at":  dateTimeProp(),
+		}),
+	},
+	{
+		Type:          EventLectureRescheduled,
+		Version:       1,
+		Entity:        "lecture",
+		EntityIDField: "lecture_id",
+		Description:   "A lecture moved to a different day, time or venue",
+		Schema: eventSchema(EventLectureRescheduled, 1, []string{"lecture_id", "course_id", "day_of_week", "start_time", "end_time"}, map[string]interface{}{
+			"lecture_id":  integerProp(),
+			"course_id":   integerProp(),
+			"faculty_id":  integerProp(),
+			"semester_id": integerProp(),
+			"section_id":  nullableIntegerProp(),
+			"venue_id":    integerProp(),
+			"day_of_week": stringProp(),
+			"start_time":  stringProp(),
+			"end_time":    stringProp(),
+			"previous": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"venue_id":    integerProp(),
+					"day_of_week": stringProp(),
+					"start_time":  stringProp(),
+					"end_time":    stringProp(),
+				},
+			},
+		}),
+	},
+	{
+		Type:          EventCourseAssignmentChanged,
+		Version:       1,
+		Entity:        "course_assignment",
+		EntityIDField: "assignment_id",
+		Description:   "A lecturer was assigned to or removed from a course",
+		Schema: eventSchema(EventCourseAssignmentChanged, 1, []string{"assignment_id", "course_id", "faculty_id", "semester_id", "action"}, map[string]interface{}{
+			"assignment_id": integerProp(),
+			"course_id":     integerProp(),
+			"faculty_id":    integerProp(),
+			"semester_id":   integerProp(),
+			"section_id":    nullableIntegerProp(),
+			"role":          stringProp(),
+			"action":        enumProp("assigned", "removed"),
+		}),
+	},
+	{
+		Type:          EventSemesterActivated,
+		Version:       1,
+		Entity:        "semester",
+		EntityIDField: "semester_id",
+		Description:   "A semester became the current semester",
+		Schema: eventSchema(EventSemesterActivated, 1, []string{"semester_id", "name", "academic_year"}, map[string]interface{}{
+			"semester_id":          integerProp(),
+			"name":                 stringProp(),
+			"academic_year":        stringProp(),
+			"semester_num":         integerProp(),
+			"start_date":           dateTimeProp(),
+			"end_date":             dateTimeProp(),
+			"previous_semester_id": integerProp(),
+		}),
+	},
+	{
+		Type:          EventPaymentReceived,
+		Version:       1,
+		Entity:        "payment",
+		EntityIDField: "payment_id",
+		Description:   "A tuition or fee payment was recorded",
+		Schema: eventSchema(EventPaymentReceived, 1, []string{"payment_id", "student_id", "invoice_number", "amount"}, map[string]interface{}{
+			"payment_id":     integerProp(),
+			"student_id":     integerProp(),
+			"invoice_number": stringProp(),
+			"amount":         numberProp(),
+			"payment_method": stringProp(),
+			"payment_date":   dateTimeProp(),
+			"academic_year":  stringProp(),
+			"semester":       stringProp(),
+			"status":         enumProp("completed", "partial", "pending", "failed"),
+		}),
+	},
+	{
+		Type:          EventWaitlistJoined,
+		Version:       1,
+		Entity:        "waitlist",
+		EntityIDField: "waitlist_entry_id",
+		Description:   "A student joined the waitlist of a full course offering",
+		Schema:        waitlistSchema(EventWaitlistJoined, 1),
+	},
+	{
+		Type:          EventWaitlistPromoted,
+		Version:       1,
+		Entity:        "waitlist",
+		EntityIDField: "waitlist_entry_id",
+		Description:   "A seat opened and a waitlisted student was enrolled",
+		Schema:        waitlistSchema(EventWaitlistPromoted, 1),
+	},
+	{
+		Type:          EventResultsPublished,
+		Version:       1,
+		Entity:        "offering",
+		EntityIDField: "offering_id",
+		Description:   "A course offering's approved results were published by Senate",
+		Schema: eventSchema(EventResultsPublished, 1, []string{"offering_id", "course_id", "semester_id", "published_at"}, map[string]interface{}{
+			"offering_id":  integerProp(),
+			"workflow_id":  integerProp(),
+			"course_id":    integerProp(),
+			"course_code":  stringProp(),
+			"semester_id":  integerProp(),
+			"grades":       integerProp(),
+			"published_at": dateTimeProp(),
+		}),
+	},
+	{
+		Type:          EventAttendanceBelow,
+		Version:       1,
+		Entity:        "attendance",
+		EntityIDField: "enrollment_id",
+		Description:   "A student's attendance in a course fell below the threshold for sitting the final exam",
+		Schema: eventSchema(EventAttendanceBelow, 1, []string{"enrollment_id", "student_id", "offering_id", "percentage", "threshold"}, map[string]interface{}{
+			"enrollment_id": integerProp(),
+			"student_id":    integerProp(),
+			"reg_number":    stringProp(),
+			"offering_id":   integerProp(),
+			"course_id":     integerProp(),
+			"course_code":   stringProp(),
+			"semester_id":   integerProp(),
+			"sessions":      integerProp(),
+			"attended":      integerProp(),
+			"excused":       integerProp(),
+			"percentage":    numberProp(),
+			"threshold":     numberProp(),
+			"exam_eligible": booleanProp(),
+			"flagged_at":    dateTimeProp(),
+		}),
+	},
+	{
+		Type:          EventStudentDeleted,
+		Version:       1,
+		Entity:        "student",
+		EntityIDField: "student_id",
+		Tombstone:     true,
+		Description:   "A student record was deleted",
+		Schema:        tombstoneSchema(EventStudentDeleted, 1, "student_id"),
+	},
+	{
+		Type:          EventCourseDeleted,
+		Version:       1,
+		Entity:        "course",
+		EntityIDField: "course_id",
+		Tombstone:     true,
+		Description:   "A course was removed from the catalogue",
+		Schema:        tombstoneSchema(EventCourseDeleted, 1, "course_id"),
+	},
+	{
+		Type:          EventEnrollmentDeleted,
+		Version:       1,
+		Entity:        "enrollment",
+		EntityIDField: "enrollment_id",
+		Tombstone:     true,
+		Description:   "An enrollment was deleted (entered in error)",
+		Schema:        tombstoneSchema(EventEnrollmentDeleted, 1, "enrollment_id"),
+	},
+	{
+		Type:          EventPaymentDeleted,
+		Version:       1,
+		Entity:        "payment",
+		EntityIDField: "payment_id",
+		Tombstone:     true,
+		Description:   "A payment record was voided",
+		Schema:        tombstoneSchema(EventPaymentDeleted, 1, "payment_id"),
+	},
+}
+
+// EventCatalogue returns all published event definitions
+func EventCatalogue() []EventDefinition {
+	return eventCatalogue
+}
+
+// LookupEvent finds an event definition by type
+func LookupEvent(eventType string) (*EventDefinition, bool) {
+	for i := range eventCatalogue {
+		if eventCatalogue[i].Type == eventType {
+			return &eventCatalogue[i], true
+		}
+	}
+	return nil, false
+}
+
+// ============================================================================
+// PAYLOAD BUILDERS
+// ============================================================================
+
+func studentEventData(student *models.Student) map[string]interface{} {
+	return map[string]interface{}{
+		"student_id":        student.ID,
+		"user_id":           student.UserID,
+		"reg_number":        student.RegNumber,
+		"first_name":        student.FirstName,
+		"middle_name":       student.MiddleName,
+		"last_name":         student.LastName,
+		"program_id":        student.ProgramID,
+		"year_of_study":     student.YearOfStudy,
+		"enrollment_status": student.EnrollmentStatus,
+		"payment_status":    student.PaymentStatus,
+		"admission_year":    student.AdmissionYear,
+	}
+}
+
+func studentStatusEventData(student *models.Student, previousStatus, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"student_id":      student.ID,
+		"reg_number":      student.RegNumber,
+		"previous_status": previousStatus,
+		"status":          student.EnrollmentStatus,
+		"reason":          reason,
+		"changed_at":      time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func courseEventData(course *models.Course) map[string]interface{} {
+	return map[string]interface{}{
+		"course_id":     course.ID,
+		"code":          course.Code,
+		"name":          course.Name,
+		"credits":       course.Credits,
+		"level":         course.Level,
+		"description":   course.Description,
+		"department_id": course.DepartmentID,
+	}
+}
+
+func programEventData(program *models.Program) map[string]interface{} {
+	return map[string]interface{}{
+		"program_id":    program.ID,
+		"department_id": program.DepartmentID,
+		"code":          program.Code,
+		"name":          program.Name,
+		"degree_level":  program.DegreeLevel,
+		"nta_level":     program.NTALevel,
+		"duration":      program.Duration,
+		"tuition_fees":  program.TuitionFees,
+	}
+}
+
+func enrollmentEventData(enrollment *models.Enrollment) map[string]interface{} {
+	return map[string]interface{}{
+		"enrollment_id": enrollment.ID,
+		"student_id":    enrollment.StudentID,
+		"course_id":     enrollment.CourseID,
+		"semester_id":   enrollment.SemesterID,
+		"offering_id":   enrollment.OfferingID,
+		"section_id":    enrollment.SectionID,
+		"status":        enrollment.Status,
+		"enrolled_at":   enrollment.EnrolledAt.UTC().Format(time.RFC3339),
+		"updated_at":    time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func gradeEventData(grade *models.Grade) map[string]interface{} {
+	data := map[string]interface{}{
+		"grade_id":      grade.ID,
+		"enrollment_id": grade.EnrollmentID,
+		"student_id":    grade.StudentID,
+		"course_id":     grade.CourseID,
+		"ca_marks":      grade.CAMarks,
+		"final_exam":    grade.FinalExam,
+		"total_marks":   grade.TotalMarks,
+		"letter_grade":  grade.LetterGrade,
+		"grade_point":   grade.GradePoint,
+		"outcome":       grade.Outcome,
+	}
+	if grade.SubmittedAt != nil {
+		data["submitted_at"] = grade.SubmittedAt.UTC().Format(time.RFC3339)
+	}
+	return data
+}
+
+func lectureEventData(lecture *models.Lecture, previous *models.Lecture) map[string]interface{} {
+	data := map[string]interface{}{
+		"lecture_id":  lecture.ID,
+		"course_id":   lecture.CourseID,
+		"faculty_id":  lecture.FacultyID,
+		"semester_id": lecture.SemesterID,
+		"section_id":  lecture.SectionID,
+		"venue_id":    lecture.VenueID,
+		"day_of_week": lecture.DayOfWeek,
+		"start_time":  lecture.StartTime,
+		"end_time":    lecture.EndTime,
+	}
+	if previous != nil {
+		data["previous"] = map[string]interface{}{
+			"venue_id":    previous.VenueID,
+			"day_of_week": previous.DayOfWeek,
+			"start_time":  previous.StartTime,
+			"end_time":    previous.EndTime,
+		}
+	}
+	return data
+}
+
+func courseAssignmentEventData(assignment *models.CourseAssignment, action string) map[string]interface{} {
+	return map[string]interface{}{
+		"assignment_id": assignment.ID,
+		"course_id":     assignment.CourseID,
+		"faculty_id":    assignment.FacultyID,
+		"semester_id":   assignment.SemesterID,
+		"section_id":    assignment.SectionID,
+		"role":          assignment.Role,
+		"action":        action,
+	}
+}
+
+func semesterEventData(semester *models.Semester, previousID uint) map[string]interface{} {
+	return map[string]interface{}{
+		"semester_id":          semester.ID,
+		"name":                 semester.Name,
+		"academic_year":        semester.AcademicYear,
+		"semester_num":         semester.SemesterNum,
+		"start_date":           semester.StartDate.UTC().Format(time.RFC3339),
+		"end_date":             semester.EndDate.UTC().Format(time.RFC3339),
+		"previous_semester_id": previousID,
+	}
+}
+
+func paymentEventData(payment *models.Payment) map[string]interface{} {
+	return map[string]interface{}{
+		"payment_id":     payment.ID,
+		"student_id":     payment.StudentID,
+		"invoice_number": payment.InvoiceNumber,
+		"amount":         payment.Amount,
+		"payment_method": payment.PaymentMethod,
+		"payment_date":   payment.PaymentDate.UTC().Format(time.RFC3339),
+		"academic_year":  payment.AcademicYear,
+		"semester":       payment.Semester,
+		"status":         payment.Status,
+	}
+}
+
+// waitlistEventData describes a waitlist entry; position is 0 and enrollmentID is set once promoted
+func waitlistEventData(entry *models.WaitlistEntry, position int, enrollmentID uint) map[string]interface{} {
+	data := map[string]interface{}{
+		"waitlist_entry_id": entry.ID,
+		"offering_id":       entry.OfferingID,
+		"student_id":        entry.StudentID,
+		"course_id":         entry.Offering.CourseID,
+		"semester_id":       entry.Offering.SemesterID,
+		"status":            entry.Status,
+		"position":          position,
+		"joined_at":         entry.JoinedAt.UTC().Format(time.RFC3339),
+	}
+	if entry.PromotedAt != nil {
+		data["promoted_at"] = entry.PromotedAt.UTC().Format(time.RFC3339)
+	}
+	if enrollmentID != 0 {
+		data["enrollment_id"] = enrollmentID
+	}
+	return data
+}
+
+// resultsPublishedEventData announces an offering's published results; grades is how many were published
+func resultsPublishedEventData(workflow *models.ResultsWorkflow, grades int) map[string]interface{} {
+	data := map[string]interface{}{
+		"offering_id": workflow.OfferingID,
+		"workflow_id": workflow.ID,
+		"course_id":   workflow.Offering.CourseID,
+		"course_code": workflow.Offering.Course.Code,
+		"semester_id": workflow.Offering.SemesterID,
+		"grades":      grades,
+	}
+	if workflow.PublishedAt != nil {
+		data["published_at"] = workflow.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	return data
+}
+
+// attendanceEventData reports a student's attendance standing in a course against the threshold
+func attendanceEventData(summary *AttendanceSummary, threshold float64) map[string]interface{} {
+	return map[string]interface{}{
+		"enrollment_id": summary.EnrollmentID,
+		"student_id":    summary.StudentID,
+		"reg_number":    summary.RegNumber,
+		"offering_id":   summary.OfferingID,
+		"course_id":     summary.CourseID,
+		"course_code":   summary.CourseCode,
+		"semester_id":   summary.SemesterID,
+		"sessions":      summary.Sessions,
+		"attended":      summary.Present + summary.Late,
+		"excused":       summary.Excused,
+		"percentage":    summary.Percentage,
+		"threshold":     threshold,
+		"exam_eligible": summary.ExamEligible,
+		"flagged_at":    time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// tombstoneEventData identifies a soft-deleted record
+func tombstoneEventData(idField string, id uint, deletedAt time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		idField:      id,
+		"deleted_at": deletedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ============================================================================
+// JSON SCHEMA HELPERS
+// ============================================================================
+
+// eventSchema wraps the data properties of an event in the common webhook envelope
+func eventSchema(eventType string, version int, required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"$id":      "https://sims.must.ac.tz/schemas/events/" + eventType + ".v" + strconv.Itoa(version) + ".json",
+		"title":    eventType,
+		"type":     "object",
+		"required": []string{"id", "event", "version", "timestamp", "data"},
+		"properties": map[string]interface{}{
+			"id":        map[string]string{"type": "string", "format": "uuid"},
+			"event":     map[string]interface{}{"const": eventType},
+			"version":   map[string]interface{}{"const": version},
+			"timestamp": dateTimeProp(),
+			"data": map[string]interface{}{
+				"type":       "object",
+				"required":   required,
+				"properties": properties,
+			},
+		},
+	}
+}
+
+func studentSchema(eventType string, version int) map[string]interface{} {
+	return eventSchema(eventType, version, []string{"student_id", "reg_number", "program_id"}, map[string]interface{}{
+		"student_id":        integerProp(),
+		"user_id":           integerProp(),
+		"reg_number":        stringProp(),
+		"first_name":        stringProp(),
+		"middle_name":       stringProp(),
+		"last_name":         stringProp(),
+		"program_id":        integerProp(),
+		"year_of_study":     integerProp(),
+		"enrollment_status": stringProp(),
+		"payment_status":    stringProp(),
+		"admission_year":    integerProp(),
+	})
+}
+
+func courseSchema(eventType string, version int) map[string]interface{} {
+	return eventSchema(eventType, version, []string{"course_id", "code", "name", "credits"}, map[string]interface{}{
+		"course_id":     integerProp(),
+		"code":          stringProp(),
+		"name":          stringProp(),
+		"credits":       integerProp(),
+		"level":         integerProp(),
+		"description":   stringProp(),
+		"department_id": integerProp(),
+	})
+}
+
+func enrollmentSchema(eventType string, version int) map[string]interface{} {
+	return eventSchema(eventType, version, []string{"enrollment_id", "student_id", "course_id", "semester_id", "status"}, map[string]interface{}{
+		"enrollment_id": integerProp(),
+		"student_id":    integerProp(),
+		"course_id":     integerProp(),
+		"semester_id":   integerProp(),
+		"offering_id":   nullableIntegerProp(),
+		"section_id":    nullableIntegerProp(),
+		"status":        enumProp("active", "dropped", "completed"),
+		"enrolled_at":   dateTimeProp(),
+		"updated_at":    dateTimeProp(),
+	})
+}
+
+func waitlistSchema(eventType string, version int) map[string]interface{} {
+	return eventSchema(eventType, version, []string{"waitlist_entry_id", "offering_id", "student_id", "course_id", "semester_id", "status"}, map[string]interface{}{
+		"waitlist_entry_id": integerProp(),
+		"offering_id":       integerProp(),
+		"student_id":        integerProp(),
+		"course_id":         integerProp(),
+		"semester_id":       integerProp(),
+		"status":            enumProp("waiting", "promoted"),
+		"position":          integerProp(),
+		"joined_at":         dateTimeProp(),
+		"promoted_at":       dateTimeProp(),
+		"enrollment_id":     integerProp(),
+	})
+}
+
+func tombstoneSchema(eventType string, version int, idField string) map[string]interface{} {
+	return eventSchema(eventType, version, []string{idField, "deleted_at"}, map[string]interface{}{
+		idField:      integerProp(),
+		"deleted_at": dateTimeProp(),
+	})
+}
+
+func integerProp() map[string]string {
+	return map[string]string{"type": "integer"}
+}
+
+func nullableIntegerProp() map[string]interface{} {
+	return map[string]interface{}{"type": []string{"integer", "null"}}
+}
+
+func numberProp() map[string]string {
+	return map[string]string{"type": "number"}
+}
+
+func booleanProp() map[string]string {
+	return map[string]string{"type": "boolean"}
+}
+
+func stringProp() map[string]string {
+	return map[string]string{"type": "string"}
+}
+
+func dateTimeProp() map[string]string {
+	return map[string]string{"type": "string", "format": "date-time"}
+}
+
+func enumProp(values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": values}
+}
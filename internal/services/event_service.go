@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// EventPublisher is the single entry point for domain events.
+// Every service mutation publishes through its outbox so webhooks, the change feed and live streams see the same events.
+type EventPublisher struct {
+	db             *gorm.DB
+	cfg            *config.Config
+	webhookService *WebhookService
+}
+
+func NewEventPublisher(db *gorm.DB, cfg *config.Config) *EventPublisher {
+	return &EventPublisher{
+		db:             db,
+		cfg:            cfg,
+		webhookService: NewWebhookService(db, cfg),
+	}
+}
+
+// EventOutbox collects the events of one transaction. They are written with the transaction's changes,
+// so the change feed never misses a committed change, and reach live streams and webhooks only once it
+// commits.
+type EventOutbox struct {
+	publisher *EventPublisher
+	tx        *gorm.DB
+	pending   []pendingEvent
+}
+
+type pendingEvent struct {
+	record  models.DomainEvent
+	payload WebhookPayload
+}
+
+// Transaction runs fn in a transaction on db with an outbox for its events, then dispatches them
+func (p *EventPublisher) Transaction(db *gorm.DB, fn func(tx *gorm.DB, outbox *EventOutbox) error) error {
+	outbox := &EventOutbox{publisher: p}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		outbox.tx = tx
+		return fn(tx, outbox)
+	})
+	if err != nil {
+		return err
+	}
+	p.dispatch(outbox.pending)
+	return nil
+}
+
+// Publish records a catalogued event in the outbox's transaction; an error rolls the transaction back
+func (o *EventOutbox) Publish(eventType string, data map[string]interface{}) error {
+	definition, ok := LookupEvent(eventType)
+	if !ok {
+		return fmt.Errorf("unknown event type: %s", eventType)
+	}
+
+	occurredAt := time.Now().UTC()
+	payload := WebhookPayload{
+		ID:        uuid.NewString(),
+		Event:     definition.Type,
+		Version:   definition.Version,
+		Timestamp: occurredAt.Format(time.RFC3339),
+		Data:      data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	record := models.DomainEvent{
+		EventID:    payload.ID,
+		Event:      definition.Type,
+		Version:    definition.Version,
+		EntityType: definition.Entity,
+		EntityID:   entityIDFromData(data, definition.EntityIDField),
+		Tombstone:  definition.Tombstone,
+		Payload:    string(body),
+		OccurredAt: occurredAt,
+	}
+	if err := o.tx.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	o.pending = append(o.pending, pendingEvent{record: record, payload: payload})
+	return nil
+}
+
+// dispatch fans committed events out to live stream subscribers and delivers them to webhooks in the
+// background; delivery failures are logged and never fail the mutation
+func (p *EventPublisher) dispatch(events []pendingEvent) {
+	for _, event := range events {
+		if change, err := changeFromEvent(event.record); err == nil {
+			eventBroker.broadcast(change)
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+	go func() {
+		for _, event := range events {
+			if err := p.webhookService.Deliver(event.payload); err != nil {
+				log.Printf("webhook delivery of %s %s failed: %v", event.payload.Event, event.payload.ID, err)
+			}
+		}
+	}()
+}
+
+// entityIDFromData extracts the entity's ID from event data built by the payload builders
+func entityIDFromData(data map[string]interface{}, field string) uint {
+	switch id := data[field].(type) {
+	case uint:
+		return id
+	case int:
+		return uint(id)
+	case int64:
+		return uint(id)
+	case float64:
+		return uint(id)
+	default:
+		return 0
+	}
+}
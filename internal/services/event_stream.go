@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// subscriberBuffer is how many events a slow live subscriber may lag before events are dropped
+const subscriberBuffer = 64
+
+// replayPageSize is how many persisted events a stream replay loads per query
+const replayPageSize = 500
+
+// EventBroker fans published events out to live stream subscribers (SSE, WebSocket).
+// It is process-wide because every service builds its own EventPublisher.
+type EventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[chan Change]struct{}
+}
+
+var eventBroker = &EventBroker{
+	subscribers: make(map[chan Change]struct{}),
+}
+
+// Broker returns the process-wide event broker
+func Broker() *EventBroker {
+	return eventBroker
+}
+
+// Subscribe registers a live subscriber; call the returned function to unsubscribe
+func (b *EventBroker) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// broadcast sends an event to every subscriber without blocking the publisher.
+// Subscribers that fall behind miss events and can catch up with Last-Event-ID.
+func (b *EventBroker) broadcast(change Change) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// EventFilter matches event types against patterns such as "grade.submitted" or "enrollment.*"
+type EventFilter struct {
+	patterns []string
+}
+
+// NewEventFilter parses a comma-separated list of event patterns (empty matches everything)
+func NewEventFilter(raw string) EventFilter {
+	var patterns []string
+	for _, pattern := range strings.Split(raw, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return EventFilter{patterns: patterns}
+}
+
+// Matches reports whether an event type passes the filter
+func (f EventFilter) Matches(eventType string) bool {
+	if len(f.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.patterns {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// where narrows a domain event query to the filter's patterns
+func (f EventFilter) where(query *gorm.DB) *gorm.DB {
+	var clauses []string
+	var args []interface{}
+	for _, pattern := range f.patterns {
+		switch {
+		case pattern == "*":
+			return query
+		case strings.HasSuffix(pattern, ".*"):
+			clauses = append(clauses, "event LIKE ?")
+			args = append(args, strings.TrimSuffix(pattern, "*")+"%")
+		default:
+			clauses = append(clauses, "event = ?")
+			args = append(args, pattern)
+		}
+	}
+	if len(clauses) == 0 {
+		return query
+	}
+	return query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+}
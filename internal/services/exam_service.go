@@ -0,0 +1,588 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+const examTimeLayout = "15:04"
+
+type ExamService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewExamService(db *gorm.DB, cfg *config.Config) *ExamService {
+	return &ExamService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// ExamSessionInput schedules an exam sitting. Venues are filled in the order given; without venues
+// the exam is placed in the free (non-lab) venues that seat every student in the fewest rooms.
+type ExamSessionInput struct {
+	Title           string           `json:"title"`
+	Date            string           `json:"date"`       // YYYY-MM-DD
+	StartTime       string           `json:"start_time"` // 09:00
+	DurationMinutes int              `json:"duration_minutes"`
+	Notes           string           `json:"notes"`
+	Venues          []ExamVenueInput `json:"venues"`
+}
+
+// ExamVenueInput places an exam in a venue; capacity defaults to the venue's capacity
+type ExamVenueInput struct {
+	VenueID  uint `json:"venue_id"`
+	Capacity int  `json:"capacity"`
+}
+
+// ExamSessionResult is a scheduled exam with the outcome of its seat allocation
+type ExamSessionResult struct {
+	Session *models.ExamSession `json:"session"`
+	Seated  int                 `json:"seated"`
+	Barred  int                 `json:"barred"` // Students not seated because their attendance is below the threshold
+	Clashes []ExamClash         `json:"clashes"`
+}
+
+// ExamSlot identifies an exam session in a clash
+type ExamSlot struct {
+	ExamSessionID uint   `json:"exam_session_id"`
+	CourseCode    string `json:"course_code"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+}
+
+// ExamClash is a student enrolled in two exam sessions that overlap
+type ExamClash struct {
+	StudentID uint     `json:"student_id"`
+	RegNumber string   `json:"reg_number"`
+	Date      string   `json:"date"`
+	First     ExamSlot `json:"first"`
+	Second    ExamSlot `json:"second"`
+}
+
+// ExamTimetableEntry is one exam on a student's exam timetable
+type ExamTimetableEntry struct {
+	ExamSessionID   uint   `json:"exam_session_id"`
+	CourseCode      string `json:"course_code"`
+	CourseName      string `json:"course_name"`
+	Title           string `json:"title"`
+	Date            string `json:"date"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Venue           string `json:"venue,omitempty"`
+	SeatNumber      int    `json:"seat_number,omitempty"`
+	Eligible        bool   `json:"eligible"` // False when attendance is below the threshold
+	Clash           bool   `json:"clash"`    // Overlaps another of the student's exams
+}
+
+// Create schedules an exam session for a course offering and seats its students
+func (s *ExamService) Create(offeringID uint, input ExamSessionInput) (*ExamSessionResult, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Semester").First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	if input.Date == "" || input.StartTime == "" || input.DurationMinutes == 0 {
+		return nil, errors.New("date, start_time and duration_minutes are required")
+	}
+
+	session := models.ExamSession{
+		OfferingID: offering.ID,
+		SemesterID: offering.SemesterID,
+		Title:      strings.TrimSpace(input.Title),
+		Notes:      strings.TrimSpace(input.Notes),
+	}
+	if session.Title == "" {
+		session.Title = "Final Examination"
+	}
+	if err := s.schedule(&session, &offering.Semester, input); err != nil {
+		return nil, err
+	}
+
+	var seated, barred int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		if err := s.placeVenues(tx, &session, input.Venues, true); err != nil {
+			return err
+		}
+		var err error
+		seated, barred, err = allocateSeats(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.result(&session, seated, barred)
+}
+
+// Update reschedules an exam session (empty fields are left untouched), optionally replacing its
+// venues, and seats its students again
+func (s *ExamService) Update(sessionID uint, input ExamSessionInput) (*ExamSessionResult, error) {
+	var session models.ExamSession
+	if err := s.db.Preload("Offering.Semester").First(&session, sessionID).Error; err != nil {
+		return nil, errors.New("exam session not found")
+	}
+	if title := strings.TrimSpace(input.Title); title != "" {
+		session.Title = title
+	}
+	if notes := strings.TrimSpace(input.Notes); notes != "" {
+		session.Notes = notes
+	}
+	if input.Date == "" {
+		input.Date = session.Date.Format(attendanceDateLayout)
+	}
+	if input.StartTime == "" {
+		input.StartTime = session.StartTime
+	}
+	if input.DurationMinutes == 0 {
+		input.DurationMinutes = session.DurationMinutes
+	}
+	if err := s.schedule(&session, &session.Offering.Semester, input); err != nil {
+		return nil, err
+	}
+
+	var seated, barred int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Offering").Save(&session).Error; err != nil {
+			return err
+		}
+		venues := input.Venues
+		if len(venues) == 0 {
+			// Keep the current venues, which must still be free at the new time
+			var current []models.ExamVenue
+			if err := tx.Where("exam_session_id = ?", session.ID).Order("position").Find(&current).Error; err != nil {
+				return err
+			}
+			for _, venue := range current {
+				venues = append(venues, ExamVenueInput{VenueID: venue.VenueID, Capacity: venue.Capacity})
+			}
+		}
+		if err := s.placeVenues(tx, &session, venues, false); err != nil {
+			return err
+		}
+		var err error
+		seated, barred, err = allocateSeats(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.result(&session, seated, barred)
+}
+
+// Delete removes an exam session with its venues and seats
+func (s *ExamService) Delete(sessionID uint) error {
+	var session models.ExamSession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		return errors.New("exam session not found")
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("exam_session_id = ?", session.ID).Delete(&models.ExamSeat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("exam_session_id = ?", session.ID).Delete(&models.ExamVenue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&session).Error
+	})
+}
+
+// Allocate seats the students of an exam session again, e.g. after late registrations
+func (s *ExamService) Allocate(sessionID uint) (*ExamSessionResult, error) {
+	var session models.ExamSession
+	if err := s.db.First(&session, sessionID).Error; err != nil {
+		return nil, errors.New("exam session not found")
+	}
+	var seated, barred int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		seated, barred, err = allocateSeats(tx, &session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.result(&session, seated, barred)
+}
+
+// Get returns an exam session with its venues and seating plan
+func (s *ExamService) Get(sessionID uint) (*models.ExamSession, error) {
+	var session models.ExamSession
+	if err := s.db.Preload("Offering.Course").
+		Preload("Venues", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Venues.Venue").
+		Preload("Venues.Seats", func(db *gorm.DB) *gorm.DB { return db.Order("seat_number") }).
+		First(&session, sessionID).Error; err != nil {
+		return nil, errors.New("exam session not found")
+	}
+	return &session, nil
+}
+
+// ForOffering lists an offering's exam sessions with their venues
+func (s *ExamService) ForOffering(offeringID uint) ([]models.ExamSession, error) {
+	var sessions []models.ExamSession
+	if err := s.db.Preload("Venues", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Venues.Venue").
+		Where("offering_id = ?", offeringID).Order("date, start_time").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// StudentTimetable lists the exams of a student's courses in a semester (0 is the current one)
+func (s *ExamService) StudentTimetable(studentID, semesterID uint) ([]ExamTimetableEntry, error) {
+	if semesterID == 0 {
+		var semester models.Semester
+		if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+			return nil, errors.New("no current semester")
+		}
+		semesterID = semester.ID
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Where("student_id = ? AND semester_id = ? AND status <> ? AND offering_id IS NOT NULL", studentID, semesterID, "dropped").
+		Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	offeringIDs := make([]uint, 0, len(enrollments))
+	ineligible := make(map[uint]bool, len(enrollments))
+	for _, enrollment := range enrollments {
+		offeringIDs = append(offeringIDs, *enrollment.OfferingID)
+		ineligible[*enrollment.OfferingID] = enrollment.ExamIneligible
+	}
+
+	entries := []ExamTimetableEntry{}
+	if len(offeringIDs) == 0 {
+		return entries, nil
+	}
+	var sessions []models.ExamSession
+	if err := s.db.Preload("Offering.Course").Where("offering_id IN ?", offeringIDs).
+		Order("date, start_time").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	clashes, err := s.clashes(semesterID, 0, studentID)
+	if err != nil {
+		return nil, err
+	}
+	clashing := make(map[uint]bool, len(clashes)*2)
+	for _, clash := range clashes {
+		clashing[clash.First.ExamSessionID] = true
+		clashing[clash.Second.ExamSessionID] = true
+	}
+
+	for _, session := range sessions {
+		entry := ExamTimetableEntry{
+			ExamSessionID:   session.ID,
+			CourseCode:      session.Offering.Course.Code,
+			CourseName:      session.Offering.Course.Name,
+			Title:           session.Title,
+			Date:            session.Date.Format(attendanceDateLayout),
+			StartTime:       session.StartTime,
+			EndTime:         session.EndTime,
+			DurationMinutes: session.DurationMinutes,
+			Eligible:        !ineligible[session.OfferingID],
+			Clash:           clashing[session.ID],
+		}
+		var seat models.ExamSeat
+		if err := s.db.Where("exam_session_id = ? AND student_id = ?", session.ID, studentID).First(&seat).Error; err == nil {
+			var venue models.ExamVenue
+			if err := s.db.Preload("Venue").First(&venue, seat.ExamVenueID).Error; err == nil {
+				entry.Venue = venue.Venue.Building + " " + venue.Venue.RoomNumber
+			}
+			entry.SeatNumber = seat.SeatNumber
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Clashes lists the students of a semester who are enrolled in two overlapping exam sessions
+func (s *ExamService) Clashes(semesterID uint) ([]ExamClash, error) {
+	return s.clashes(semesterID, 0, 0)
+}
+
+// clashes finds overlapping exam sessions shared by a student, optionally only those involving one
+// session or one student
+func (s *ExamService) clashes(semesterID, sessionID, studentID uint) ([]ExamClash, error) {
+	query := s.db.Table("exam_sessions AS first").
+		Select("first_enrollment.student_id, first.id AS first_id, second.id AS second_id").
+		Joins("JOIN exam_sessions AS second ON second.date = first.date AND second.id > first.id"+
+			" AND second.start_time < first.end_time AND first.start_time < second.end_time AND second.deleted_at IS NULL").
+		Joins("JOIN enrollments AS first_enrollment ON first_enrollment.offering_id = first.offering_id"+
+			" AND first_enrollment.status <> 'dropped' AND first_enrollment.deleted_at IS NULL").
+		Joins("JOIN enrollments AS second_enrollment ON second_enrollment.offering_id = second.offering_id"+
+			" AND second_enrollment.student_id = first_enrollment.student_id"+
+			" AND second_enrollment.status <> 'dropped' AND second_enrollment.deleted_at IS NULL").
+		Where("first.semester_id = ? AND first.deleted_at IS NULL", semesterID)
+	if sessionID != 0 {
+		query = query.Where("(first.id = ? OR second.id = ?)", sessionID, sessionID)
+	}
+	if studentID != 0 {
+		query = query.Where("first_enrollment.student_id = ?", studentID)
+	}
+
+	var rows []struct {
+		StudentID uint
+		FirstID   uint
+		SecondID  uint
+	}
+	if err := query.Order("first_enrollment.student_id, first.id, second.id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	clashes := []ExamClash{}
+	sessions := make(map[uint]*models.ExamSession)
+	students := make(map[uint]string)
+	slot := func(id uint) (*models.ExamSession, ExamSlot, error) {
+		session, ok := sessions[id]
+		if !ok {
+			session = &models.ExamSession{}
+			if err := s.db.Preload("Offering.Course").First(session, id).Error; err != nil {
+				return nil, ExamSlot{}, err
+			}
+			sessions[id] = session
+		}
+		return session, ExamSlot{
+			ExamSessionID: session.ID,
+			CourseCode:    session.Offering.Course.Code,
+			StartTime:     session.StartTime,
+			EndTime:       session.EndTime,
+		}, nil
+	}
+	for _, row := range rows {
+		session, first, err := slot(row.FirstID)
+		if err != nil {
+			return nil, err
+		}
+		_, second, err := slot(row.SecondID)
+		if err != nil {
+			return nil, err
+		}
+		regNumber, ok := students[row.StudentID]
+		if !ok {
+			var student models.Student
+			s.db.Select("reg_number").First(&student, row.StudentID)
+			regNumber = student.RegNumber
+			students[row.StudentID] = regNumber
+		}
+		clashes = append(clashes, ExamClash{
+			StudentID: row.StudentID,
+			RegNumber: regNumber,
+			Date:      session.Date.Format(attendanceDateLayout),
+			First:     first,
+			Second:    second,
+		})
+	}
+	return clashes, nil
+}
+
+// result reports a freshly allocated session with the clashes it is part of
+func (s *ExamService) result(session *models.ExamSession, seated, barred int) (*ExamSessionResult, error) {
+	result := &ExamSessionResult{Seated: seated, Barred: barred}
+	var err error
+	if result.Session, err = s.Get(session.ID); err != nil {
+		return nil, err
+	}
+	if result.Clashes, err = s.clashes(session.SemesterID, session.ID, 0); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// allocateSeats seats every student of the session's offering in its venues, in registration number
+// order, numbering seats from 1 in each venue. Students below the attendance threshold are not seated.
+func allocateSeats(tx *gorm.DB, session *models.ExamSession) (seated, barred int, err error) {
+	var venues []models.ExamVenue
+	if err := tx.Where("exam_session_id = ?", session.ID).Order("position").Find(&venues).Error; err != nil {
+		return 0, 0, err
+	}
+	var enrollments []models.Enrollment
+	if err := tx.Joins("Student").Where("enrollments.offering_id = ? AND enrollments.status <> ?", session.OfferingID, "dropped").
+		Order(`"Student".reg_number`).Find(&enrollments).Error; err != nil {
+		return 0, 0, err
+	}
+	var sitting []models.Enrollment
+	for _, enrollment := range enrollments {
+		if enrollment.ExamIneligible {
+			barred++
+			continue
+		}
+		sitting = append(sitting, enrollment)
+	}
+
+	capacity := 0
+	for _, venue := range venues {
+		capacity += venue.Capacity
+	}
+	if capacity < len(sitting) {
+		return 0, 0, fmt.Errorf("the exam venues seat %d but %d students sit the exam", capacity, len(sitting))
+	}
+
+	if err := tx.Where("exam_session_id = ?", session.ID).Delete(&models.ExamSeat{}).Error; err != nil {
+		return 0, 0, err
+	}
+	next := 0
+	for _, venue := range venues {
+		for seat := 1; seat <= venue.Capacity && next < len(sitting); seat++ {
+			if err := tx.Create(&models.ExamSeat{
+				ExamSessionID: session.ID,
+				ExamVenueID:   venue.ID,
+				SeatNumber:    seat,
+				StudentID:     sitting[next].StudentID,
+				EnrollmentID:  sitting[next].ID,
+			}).Error; err != nil {
+				return 0, 0, err
+			}
+			next++
+		}
+	}
+	return len(sitting), barred, nil
+}
+
+// schedule validates and applies an exam's date, start time and duration
+func (s *ExamService) schedule(session *models.ExamSession, semester *models.Semester, input ExamSessionInput) error {
+	date, err := time.Parse(attendanceDateLayout, strings.TrimSpace(input.Date))
+	if err != nil {
+		return errors.New("date must be in YYYY-MM-DD format")
+	}
+	if date.Format(attendanceDateLayout) < semester.StartDate.Format(attendanceDateLayout) {
+		return fmt.Errorf("the exam must be on or after the start of %s", semester.Name)
+	}
+	start, err := time.Parse(examTimeLayout, strings.TrimSpace(input.StartTime))
+	if err != nil {
+		return errors.New("start_time must be in HH:MM format")
+	}
+	if input.DurationMinutes <= 0 {
+		return errors.New("duration_minutes must be positive")
+	}
+	end := start.Add(time.Duration(input.DurationMinutes) * time.Minute)
+	if end.Day() != start.Day() {
+		return errors.New("the exam must end on the day it starts")
+	}
+
+	session.Date = date
+	session.StartTime = start.Format(examTimeLayout)
+	session.EndTime = end.Format(examTimeLayout)
+	session.DurationMinutes = input.DurationMinutes
+	return nil
+}
+
+// placeVenues replaces a session's venues. Venues must be free at the session's time; when none are
+// given and pick is set, free venues are chosen to seat every eligible student.
+func (s *ExamService) placeVenues(tx *gorm.DB, session *models.ExamSession, inputs []ExamVenueInput, pick bool) error {
+	day := session.Date.Format(attendanceDateLayout)
+	var busy []uint
+	if err := tx.Model(&models.ExamVenue{}).
+		Joins("JOIN exam_sessions ON exam_sessions.id = exam_venues.exam_session_id AND exam_sessions.deleted_at IS NULL").
+		Where("exam_sessions.date = ? AND exam_sessions.start_time < ? AND exam_sessions.end_time > ? AND exam_sessions.id <> ?",
+			day, session.EndTime, session.StartTime, session.ID).
+		Pluck("exam_venues.venue_id", &busy).Error; err != nil {
+		return err
+	}
+	taken := make(map[uint]bool, len(busy))
+	for _, id := range busy {
+		taken[id] = true
+	}
+
+	var venues []models.ExamVenue
+	if len(inputs) == 0 {
+		if !pick {
+			return errors.New("at least one venue is required")
+		}
+		var needed int64
+		if err := tx.Model(&models.Enrollment{}).
+			Where("offering_id = ? AND status <> ? AND exam_ineligible = ?", session.OfferingID, "dropped", false).
+			Count(&needed).Error; err != nil {
+			return err
+		}
+		picked, err := s.pickVenues(tx, taken, int(needed))
+		if err != nil {
+			return err
+		}
+		venues = picked
+	} else {
+		seen := make(map[uint]bool, len(inputs))
+		for _, input := range inputs {
+			var venue models.Venue
+			if err := tx.First(&venue, input.VenueID).Error; err != nil {
+				return fmt.Errorf("venue %d not found", input.VenueID)
+			}
+			switch {
+			case seen[venue.ID]:
+				return fmt.Errorf("venue %d is listed more than once", venue.ID)
+			case taken[venue.ID]:
+				return fmt.Errorf("%s %s already hosts an exam at that time", venue.Building, venue.RoomNumber)
+			case input.Capacity < 0 || input.Capacity > venue.Capacity:
+				return fmt.Errorf("%s %s seats at most %d", venue.Building, venue.RoomNumber, venue.Capacity)
+			}
+			seen[venue.ID] = true
+			capacity := input.Capacity
+			if capacity == 0 {
+				capacity = venue.Capacity
+			}
+			venues = append(venues, models.ExamVenue{VenueID: venue.ID, Capacity: capacity})
+		}
+	}
+
+	if err := tx.Where("exam_session_id = ?", session.ID).Delete(&models.ExamSeat{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("exam_session_id = ?", session.ID).Delete(&models.ExamVenue{}).Error; err != nil {
+		return err
+	}
+	for i := range venues {
+		venues[i].ExamSessionID = session.ID
+		venues[i].Position = i
+		if err := tx.Create(&venues[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pickVenues chooses free non-lab venues for needed students: the smallest venue that seats everyone
+// left, otherwise the largest free venue and repeat
+func (s *ExamService) pickVenues(tx *gorm.DB, taken map[uint]bool, needed int) ([]models.ExamVenue, error) {
+	var candidates []models.Venue
+	if err := tx.Where("venue_type <> ?", "Lab").Order("capacity DESC, id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	free := candidates[:0]
+	for _, venue := range candidates {
+		if !taken[venue.ID] && venue.Capacity > 0 {
+			free = append(free, venue)
+		}
+	}
+
+	var picked []models.ExamVenue
+	remaining := needed
+	if remaining == 0 {
+		remaining = 1
+	}
+	for remaining > 0 {
+		if len(free) == 0 {
+			return nil, fmt.Errorf("not enough free venues to seat %d students at that time", needed)
+		}
+		// free is sorted largest first, so the last venue that fits is the smallest that fits
+		choice := sort.Search(len(free), func(i int) bool { return free[i].Capacity < remaining }) - 1
+		if choice < 0 {
+			choice = 0
+		}
+		venue := free[choice]
+		picked = append(picked, models.ExamVenue{VenueID: venue.ID, Capacity: venue.Capacity})
+		remaining -= venue.Capacity
+		free = append(free[:choice], free[choice+1:]...)
+	}
+	return picked, nil
+}
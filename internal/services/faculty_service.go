@@ -2,7 +2,7 @@ package services
 
 import (
 	"errors"
-	"time"
+	"fmt"
 
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
@@ -10,16 +10,16 @@ import (
 )
 
 type FacultyService struct {
-	db             *gorm.DB
-	cfg            *config.Config
-	webhookService *WebhookService
+	db          *gorm.DB
+	cfg         *config.Config
+	assessments *AssessmentService
 }
 
 func NewFacultyService(db *gorm.DB, cfg *config.Config) *FacultyService {
 	return &FacultyService{
-		db:             db,
-		cfg:            cfg,
-		webhookService: NewWebhookService(db, cfg),
+		db:          db,
+		cfg:         cfg,
+		assessments: NewAssessmentService(db, cfg),
 	}
 }
 
@@ -77,110 +77,294 @@ func (s *FacultyService) GetCourseStudents(courseID uint, semesterID uint) ([]mo
 	return enrollments, nil
 }
 
-// SubmitCAMarks submits Continuous Assessment marks for students
-func (s *FacultyService) SubmitCAMarks(facultyID uint, courseID uint, marks []struct {
-	StudentID uint
-	CAMarks   float64
-}) error {
-	// Verify faculty is assigned to this course
-	var assignment models.CourseAssignment
-	err := s.db.
-		Where("faculty_id = ? AND course_id = ?", facultyID, courseID).
-		First(&assignment).Error
+// CA mark submission outcomes per student
+const (
+	CAMarkUpdated     = "updated"
+	CAMarkNotEnrolled = "not_enrolled" // No active enrollment in the course this semester
+	CAMarkNoOffering  = "no_offering"  // Enrolled, but the enrollment is not linked to a course offering
+	CAMarkNoComponent = "no_component" // The offering has no single coursework component to record into
+	CAMarkOutOfRange  = "out_of_range"
+)
+
+// CAMarkEntry is a student's continuous assessment mark, out of the coursework component's weight
+type CAMarkEntry struct {
+	StudentID uint    `json:"student_id"`
+	CAMarks   float64 `json:"ca_marks"`
+}
 
+// CAMarkResult is the outcome of one submitted CA mark
+type CAMarkResult struct {
+	StudentID uint    `json:"student_id"`
+	CAMarks   float64 `json:"ca_marks"`
+	Status    string  `json:"status"` // updated, not_enrolled, no_offering, no_component, out_of_range
+	Message   string  `json:"message,omitempty"`
+}
+
+// SubmitCAMarks submits Continuous Assessment marks for students enrolled in the course this semester.
+// The marks are recorded as the offering's single coursework component; courses split into
+// several coursework components take scores per component instead. Every mark gets an outcome;
+// the valid ones are written in a single transaction, so either all of them are recorded or none.
+func (s *FacultyService) SubmitCAMarks(facultyID uint, courseID uint, marks []CAMarkEntry, actor Actor) ([]CAMarkResult, error) {
+	semester, err := s.currentSemester()
 	if err != nil {
-		return errors.New("you are not assigned to this course")
+		return nil, err
 	}
 
-	var updatedGrades []models.Grade
+	// Verify faculty is assigned to this course this semester
+	var assignment models.CourseAssignment
+	if err := s.db.Where("faculty_id = ? AND course_id = ? AND semester_id = ?", facultyID, courseID, semester.ID).
+		First(&assignment).Error; err != nil {
+		return nil, errors.New("you are not assigned to this course this semester")
+	}
 
-	// Update grades for each student
+	type pendingMark struct {
+		enrollment models.Enrollment
+		component  *models.AssessmentComponent
+		score      float64
+	}
+	results := make([]CAMarkResult, 0, len(marks))
+	var pending []pendingMark
+	components := map[uint][]models.AssessmentComponent{}
 	for _, mark := range marks {
-		var grade models.Grade
-		var enrollment models.Enrollment
+		result := CAMarkResult{StudentID: mark.StudentID, CAMarks: mark.CAMarks}
 
-		// Find enrollment
-		err := s.db.
-			Where("student_id = ? AND course_id = ?", mark.StudentID, courseID).
-			First(&enrollment).Error
+		enrollment, err := s.currentEnrollment(mark.StudentID, courseID, semester.ID)
+		if err != nil {
+			result.Status = CAMarkNotEnrolled
+			if errors.Is(err, errNoOffering) {
+				result.Status = CAMarkNoOffering
+			}
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
 
+		available, err := s.components(components, *enrollment.OfferingID)
 		if err != nil {
-			continue // Skip if enrollment not found
+			return nil, err
+		}
+		component, err := singleComponent(available, false)
+		if err != nil {
+			result.Status = CAMarkNoComponent
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if mark.CAMarks < 0 || mark.CAMarks > component.Weight {
+			result.Status = CAMarkOutOfRange
+			result.Message = fmt.Sprintf("CA marks must be between 0 and %s", formatMarks(component.Weight))
+			results = append(results, result)
+			continue
 		}
 
-		// Find or create grade record
-		err = s.db.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error
-		now := time.Now()
+		// CA marks are out of the component's weight; scores are out of its maximum
+		pending = append(pending, pendingMark{
+			enrollment: *enrollment,
+			component:  component,
+			score:      mark.CAMarks / component.Weight * component.MaxMarks,
+		})
+		result.Status = CAMarkUpdated
+		results = append(results, result)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		assessments := s.assessments.withDB(tx)
+		for i := range pending {
+			if _, err := assessments.recordScore(pending[i].component, &pending[i].enrollment, pending[i].score, "", actor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Final exam submission outcomes per student
+const (
+	FinalExamRecorded    = "recorded"
+	FinalExamNotEnrolled = "not_enrolled" // No active enrollment in the course this semester
+	FinalExamNoOffering  = "no_offering"  // Enrolled, but the enrollment is not linked to a course offering
+	FinalExamNoComponent = "no_component" // The offering has no single final exam component to record into
+	FinalExamInvalid     = "invalid"      // Missing or out-of-range mark, or an unknown marker
+)
+
+// FinalExamMark is a student's final exam mark (out of the exam's weight) or a marker instead of one
+type FinalExamMark struct {
+	StudentID uint     `json:"student_id"`
+	FinalExam *float64 `json:"final_exam"`
+	Marker    string   `json:"marker"` // ABS, I or DQ
+}
+
+// FinalExamResult is the outcome of one submitted final exam mark, with the computed grade when it was recorded
+type FinalExamResult struct {
+	StudentID uint          `json:"student_id"`
+	FinalExam *float64      `json:"final_exam"`
+	Marker    string        `json:"marker,omitempty"`
+	Status    string        `json:"status"` // recorded, not_enrolled, no_offering, no_component, invalid
+	Message   string        `json:"message,omitempty"`
+	Grade     *models.Grade `json:"grade,omitempty"`
+}
+
+// SubmitFinalExam records final exam marks for students enrolled in the course this semester and
+// computes the totals, letter grades and grade points. Marks are validated against the exam's share of
+// the total (60 by default). Every mark gets an outcome; the valid ones are written in a single
+// transaction, so either all of them are recorded or none.
+func (s *FacultyService) SubmitFinalExam(facultyID uint, courseID uint, marks []FinalExamMark, actor Actor) ([]FinalExamResult, error) {
+	semester, err := s.currentSemester()
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify faculty is assigned to this course this semester
+	var assignment models.CourseAssignment
+	if err := s.db.Where("faculty_id = ? AND course_id = ? AND semester_id = ?", facultyID, courseID, semester.ID).
+		First(&assignment).Error; err != nil {
+		return nil, errors.New("you are not assigned to this course this semester")
+	}
+
+	type pendingMark struct {
+		result     int
+		enrollment models.Enrollment
+		component  *models.AssessmentComponent
+		score      ScoreInput
+	}
+	results := make([]FinalExamResult, 0, len(marks))
+	var pending []pendingMark
+	components := map[uint][]models.AssessmentComponent{}
+	for _, mark := range marks {
+		result := FinalExamResult{StudentID: mark.StudentID, FinalExam: mark.FinalExam, Marker: mark.Marker}
+
+		enrollment, err := s.currentEnrollment(mark.StudentID, courseID, semester.ID)
 		if err != nil {
-			// Create new grade record
-			grade = models.Grade{
-				EnrollmentID: enrollment.ID,
-				StudentID:    mark.StudentID,
-				CourseID:     courseID,
-				CAMarks:      mark.CAMarks,
-				SubmittedAt:  &now,
+			result.Status = FinalExamNotEnrolled
+			if errors.Is(err, errNoOffering) {
+				result.Status = FinalExamNoOffering
 			}
-			s.db.Create(&grade)
-		} else {
-			// Update existing grade
-			grade.CAMarks = mark.CAMarks
-			grade.SubmittedAt = &now
-			// Recalculate total if final exam marks exist
-			if grade.FinalExam > 0 {
-				grade.TotalMarks = grade.CAMarks + grade.FinalExam
-				grade.LetterGrade = s.calculateLetterGrade(grade.TotalMarks)
-				grade.GradePoint = s.calculateGradePoint(grade.LetterGrade)
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		available, err := s.components(components, *enrollment.OfferingID)
+		if err != nil {
+			return nil, err
+		}
+		component, err := singleComponent(available, true)
+		if err != nil {
+			result.Status = FinalExamNoComponent
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		score := ScoreInput{StudentID: mark.StudentID, Marker: mark.Marker}
+		if mark.Marker == "" {
+			if mark.FinalExam == nil {
+				result.Status = FinalExamInvalid
+				result.Message = "a final_exam mark or a marker is required"
+				results = append(results, result)
+				continue
 			}
-			s.db.Save(&grade)
+			if *mark.FinalExam < 0 || *mark.FinalExam > component.Weight {
+				result.Status = FinalExamInvalid
+				result.Message = fmt.Sprintf("final exam mark must be between 0 and %s", formatMarks(component.Weight))
+				results = append(results, result)
+				continue
+			}
+			// Marks are out of the exam's weight; scores are out of its maximum
+			score.Score = *mark.FinalExam / component.Weight * component.MaxMarks
+		}
+		if err := validateScore(&score, component.MaxMarks); err != nil {
+			result.Status = FinalExamInvalid
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
 		}
+		result.Marker = score.Marker
 
-		// Track updated grade for webhook notification
-		updatedGrades = append(updatedGrades, grade)
+		pending = append(pending, pendingMark{result: len(results), enrollment: *enrollment, component: component, score: score})
+		result.Status = FinalExamRecorded
+		results = append(results, result)
 	}
 
-	// Send webhook notifications for submitted grades (async, ignore errors)
-	go func(grades []models.Grade) {
-		for i := range grades {
-			grade := grades[i]
-			if grade.TotalMarks > 0 && grade.LetterGrade != "" {
-				s.webhookService.SendGradeSubmitted(&grade)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		assessments := s.assessments.withDB(tx)
+		for i := range pending {
+			grade, err := assessments.recordScore(pending[i].component, &pending[i].enrollment, pending[i].score.Score, pending[i].score.Marker, actor)
+			if err != nil {
+				return err
 			}
+			results[pending[i].result].Grade = grade
 		}
-	}(append([]models.Grade(nil), updatedGrades...))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	return results, nil
 }
 
-// calculateLetterGrade converts total marks to letter grade
-func (s *FacultyService) calculateLetterGrade(totalMarks float64) string {
-	if totalMarks >= 70 {
-		return "A"
-	} else if totalMarks >= 65 {
-		return "B+"
-	} else if totalMarks >= 60 {
-		return "B"
-	} else if totalMarks >= 50 {
-		return "C"
-	} else if totalMarks >= 40 {
-		return "D"
-	}
-	return "F"
+// currentSemester returns the semester marks are being submitted for
+func (s *FacultyService) currentSemester() (*models.Semester, error) {
+	var semester models.Semester
+	if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+		return nil, errors.New("no current semester")
+	}
+	return &semester, nil
+}
+
+// errNoOffering is returned for an enrollment that has no course offering to record marks against
+var errNoOffering = errors.New("enrollment is not linked to a course offering")
+
+// currentEnrollment returns a student's active enrollment in a course in the given semester
+func (s *FacultyService) currentEnrollment(studentID, courseID, semesterID uint) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	if err := s.db.Where("student_id = ? AND course_id = ? AND semester_id = ? AND status <> ?",
+		studentID, courseID, semesterID, "dropped").First(&enrollment).Error; err != nil {
+		return nil, errors.New("student is not enrolled in this course this semester")
+	}
+	if enrollment.OfferingID == nil {
+		return nil, errNoOffering
+	}
+	return &enrollment, nil
 }
 
-// calculateGradePoint converts letter grade to grade point
-func (s *FacultyService) calculateGradePoint(letterGrade string) float64 {
-	switch letterGrade {
-	case "A":
-		return 5.0
-	case "B+":
-		return 4.0
-	case "B":
-		return 3.0
-	case "C":
-		return 2.0
-	case "D":
-		return 1.0
+// components returns an offering's components, loading each offering once per submission
+func (s *FacultyService) components(loaded map[uint][]models.AssessmentComponent, offeringID uint) ([]models.AssessmentComponent, error) {
+	if components, ok := loaded[offeringID]; ok {
+		return components, nil
+	}
+	components, err := offeringComponents(s.db, offeringID)
+	if err != nil {
+		return nil, err
+	}
+	loaded[offeringID] = components
+	return components, nil
+}
+
+// singleComponent returns the only final exam component, or the only coursework component, of an offering
+func singleComponent(components []models.AssessmentComponent, finalExam bool) (*models.AssessmentComponent, error) {
+	var matching []models.AssessmentComponent
+	for _, component := range components {
+		if (component.Type == ComponentFinalExam) == finalExam {
+			matching = append(matching, component)
+		}
+	}
+	switch {
+	case len(matching) == 1:
+		return &matching[0], nil
+	case finalExam && len(matching) == 0:
+		return nil, errors.New("this course has no final exam component")
+	case finalExam:
+		return nil, errors.New("this course has several final exam components; submit scores per component")
+	case len(matching) == 0:
+		return nil, errors.New("this course has no coursework component")
 	default:
-		return 0.0
+		return nil, errors.New("this course has several coursework components; submit scores per component")
 	}
 }
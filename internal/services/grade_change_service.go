@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Grade change request statuses
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+type GradeChangeService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	events      *EventPublisher
+	assessments *AssessmentService
+	results     *ResultsService
+}
+
+func NewGradeChangeService(db *gorm.DB, cfg *config.Config) *GradeChangeService {
+	return &GradeChangeService{
+		db:          db,
+		cfg:         cfg,
+		events:      NewEventPublisher(db, cfg),
+		assessments: NewAssessmentService(db, cfg),
+		results:     NewResultsService(db, cfg),
+	}
+}
+
+// GradeChangeInput proposes new component scores (or markers) for a published grade. The change
+// always takes effect when it is approved; EffectiveDate is only recorded on the request.
+type GradeChangeInput struct {
+	Reason        string           `json:"reason"`
+	EffectiveDate *time.Time       `json:"effective_date"` // Informational, e.g. the appeal date; defaults to now, cannot be in the future
+	Scores        []ComponentScore `json:"scores"`
+}
+
+// ComponentScore is a new score, or marker (ABS, I, DQ), for one assessment component
+type ComponentScore struct {
+	ComponentID uint    `json:"component_id"`
+	Score       float64 `json:"score"`
+	Marker      string  `json:"marker"`
+}
+
+// History returns every recorded change of a grade, oldest first
+func (s *GradeChangeService) History(gradeID uint) ([]models.GradeHistory, error) {
+	var history []models.GradeHistory
+	if err := s.db.Where("grade_id = ?", gradeID).Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// CanView allows admins, the course lecturer and the head of department to see a grade's history and requests
+func (s *GradeChangeService) CanView(gradeID uint, actor Actor) error {
+	offering, err := s.gradeOffering(gradeID)
+	if err != nil {
+		return err
+	}
+	if _, err := offeringRole(s.db, offering, actor, RoleLecturer); err == nil {
+		return nil
+	}
+	_, err = offeringRole(s.db, offering, actor, RoleHOD)
+	return err
+}
+
+// Request files a change request for a published grade. Only the course lecturer or an admin may
+// request a change, and a grade can have one pending request at a time.
+func (s *GradeChangeService) Request(gradeID uint, input GradeChangeInput, actor Actor) (*models.GradeChangeRequest, error) {
+	var grade models.Grade
+	if err := s.db.First(&grade, gradeID).Error; err != nil {
+		return nil, errors.New("grade not found")
+	}
+	if grade.PublishedAt == nil {
+		return nil, errors.New("grade is not published; change the scores while results are in draft")
+	}
+	offering, err := s.gradeOffering(gradeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := offeringRole(s.db, offering, actor, RoleLecturer); err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, errors.New("a reason for the change is required")
+	}
+	effective := time.Now()
+	if input.EffectiveDate != nil {
+		if input.EffectiveDate.After(effective) {
+			return nil, errors.New("effective_date cannot be in the future")
+		}
+		effective = *input.EffectiveDate
+	}
+	if len(input.Scores) == 0 {
+		return nil, errors.New("at least one component score is required")
+	}
+
+	var pending int64
+	if err := s.db.Model(&models.GradeChangeRequest{}).Where("grade_id = ? AND status = ?", gradeID, ChangePending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.New("this grade already has a pending change request")
+	}
+
+	request := models.GradeChangeRequest{
+		GradeID:       gradeID,
+		RequestedBy:   actor.UserID,
+		Reason:        reason,
+		EffectiveDate: effective,
+		Status:        ChangePending,
+	}
+	seen := make(map[uint]bool, len(input.Scores))
+	for _, change := range input.Scores {
+		var component models.AssessmentComponent
+		if err := s.db.Where("id = ? AND offering_id = ?", change.ComponentID, offering.ID).First(&component).Error; err != nil {
+			return nil, fmt.Errorf("component %d is not part of this course offering", change.ComponentID)
+		}
+		if seen[component.ID] {
+			return nil, fmt.Errorf("component %d is listed more than once", component.ID)
+		}
+		seen[component.ID] = true
+
+		score := ScoreInput{StudentID: grade.StudentID, Score: change.Score, Marker: change.Marker}
+		if err := validateScore(&score, component.MaxMarks); err != nil {
+			return nil, err
+		}
+
+		var current models.AssessmentScore
+		if err := s.db.Where("component_id = ? AND enrollment_id = ?", component.ID, grade.EnrollmentID).
+			First(&current).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if current.Score == score.Score && current.Marker == score.Marker && current.ID != 0 {
+			return nil, fmt.Errorf("component %d already has this score", component.ID)
+		}
+		request.Items = append(request.Items, models.GradeChangeItem{
+			ComponentID: component.ID,
+			OldScore:    current.Score,
+			OldMarker:   current.Marker,
+			NewScore:    score.Score,
+			NewMarker:   score.Marker,
+		})
+	}
+
+	if err := s.db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ForGrade lists a grade's change requests, newest first
+func (s *GradeChangeService) ForGrade(gradeID uint) ([]models.GradeChangeRequest, error) {
+	var requests []models.GradeChangeRequest
+	if err := s.db.Preload("Items").Where("grade_id = ?", gradeID).Order("id DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// List returns change requests with an optional status. Admins see all of them; lecturers see the
+// ones they filed and the ones waiting on them as head of department.
+func (s *GradeChangeService) List(status string, actor Actor) ([]models.GradeChangeRequest, error) {
+	query := s.db.Preload("Items").Order("id DESC")
+	if status != "" {
+		query = query.Where("grade_change_requests.status = ?", status)
+	}
+
+	switch actor.UserType {
+	case "admin":
+	case "faculty":
+		var faculty models.Faculty
+		if err := s.db.Where("user_id = ?", actor.UserID).First(&faculty).Error; err != nil {
+			return nil, errors.New("faculty member not found")
+		}
+		headed := s.db.Model(&models.Grade{}).Select("grades.id").
+			Joins("JOIN courses ON courses.id = grades.course_id").
+			Joins("JOIN departments ON departments.id = courses.department_id").
+			Where("departments.head_faculty_id = ?", faculty.ID)
+		query = query.Where("grade_change_requests.requested_by = ? OR grade_change_requests.grade_id IN (?)", actor.UserID, headed)
+	default:
+		return nil, errors.New("only lecturers and administrators can view grade change requests")
+	}
+
+	var requests []models.GradeChangeRequest
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Decide approves or rejects a pending request. The head of the course's department (or an admin,
+// as Senate) decides, never the requester. Approval applies the new scores, recalculates the grade
+// (recording the history against the request), recomputes the student's results and notifies the LMS.
+func (s *GradeChangeService) Decide(requestID uint, approve bool, actor Actor, comment string) (*models.GradeChangeRequest, error) {
+	var request models.GradeChangeRequest
+	if err := s.db.Preload("Items").First(&request, requestID).Error; err != nil {
+		return nil, errors.New("grade change request not found")
+	}
+	if request.Status != ChangePending {
+		return nil, fmt.Errorf("request is already %s", request.Status)
+	}
+	if request.RequestedBy == actor.UserID {
+		return nil, errors.New("a change request must be decided by someone other than the requester")
+	}
+	comment = strings.TrimSpace(comment)
+	if !approve && comment == "" {
+		return nil, errors.New("a comment explaining the rejection is required")
+	}
+
+	offering, err := s.gradeOffering(request.GradeID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := offeringRole(s.db, offering, actor, RoleHOD)
+	if err != nil {
+		return nil, err
+	}
+	if role == "admin" {
+		role = RoleSenate // Admins decide grade changes on behalf of Senate
+	}
+
+	now := time.Now()
+	status := ChangeRejected
+	if approve {
+		status = ChangeApproved
+	}
+
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		result := tx.Model(&models.GradeChangeRequest{}).Where("id = ? AND status = ?", request.ID, ChangePending).
+			Updates(map[string]interface{}{
+				"status":        status,
+				"approved_by":   actor.UserID,
+				"approver_role": role,
+				"comment":       comment,
+				"decided_at":    now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the request was decided meanwhile; reload and try again")
+		}
+		if !approve {
+			return nil
+		}
+
+		var enrollment models.Enrollment
+		if err := tx.Joins("JOIN grades ON grades.enrollment_id = enrollments.id").
+			Where("grades.id = ?", request.GradeID).First(&enrollment).Error; err != nil {
+			return errors.New("enrollment of the grade not found")
+		}
+		for _, item := range request.Items {
+			score := models.AssessmentScore{ComponentID: item.ComponentID, EnrollmentID: enrollment.ID}
+			if err := tx.Where(&score).FirstOrInit(&score).Error; err != nil {
+				return err
+			}
+			score.StudentID = enrollment.StudentID
+			score.Score = item.NewScore
+			score.Marker = item.NewMarker
+			if err := tx.Save(&score).Error; err != nil {
+				return err
+			}
+		}
+
+		changer := Actor{UserID: actor.UserID, UserType: actor.UserType, Source: SourceChangeRequest}
+		grade, err := s.assessments.withDB(tx).recalculateGrade(&enrollment, changer, &request.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.results.withDB(tx).ComputeStudent(grade.StudentID); err != nil {
+			return err
+		}
+		return outbox.Publish(EventGradeSubmitted, gradeEventData(grade))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Items").First(&request, requestID).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// gradeOffering returns the offering of a grade with its course department loaded
+func (s *GradeChangeService) gradeOffering(gradeID uint) (*models.CourseOffering, error) {
+	var grade models.Grade
+	if err := s.db.First(&grade, gradeID).Error; err != nil {
+		return nil, errors.New("grade not found")
+	}
+	if grade.OfferingID == nil {
+		return nil, errors.New("grade is not linked to a course offering")
+	}
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course.Department").First(&offering, *grade.OfferingID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	return &offering, nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefaultGradingSchemes are the scales seeded for each program level.
+// The first one is also used when the database has no schemes at all.
+var DefaultGradingSchemes = []models.GradingScheme{
+	{
+		Code: "UG", Name: "Undergraduate (TCU)", DegreeLevel: "Bachelor", NTALevel: 8, PassMark: 50, IsDefault: true,
+		MinCGPA: 2.0, DiscontinueCGPA: 1.6,
+		DegreeClasses: "First Class:4.4,Upper Second Class:3.5,Lower Second Class:2.7,Pass:2.0",
+		Bands: []models.GradeBand{
+			{Letter: "A", MinMarks: 70, GradePoint: 5, Remark: "PASS"},
+			{Letter: "B+", MinMarks: 65, GradePoint: 4, Remark: "PASS"},
+			{Letter: "B", MinMarks: 60, GradePoint: 3, Remark: "PASS"},
+			{Letter: "C", MinMarks: 50, GradePoint: 2, Remark: "PASS"},
+			{Letter: "D", MinMarks: 40, GradePoint: 1, Remark: "SUPP"},
+			{Letter: "F", MinMarks: 0, GradePoint: 0, Remark: "RETAKE"},
+		},
+	},
+	{
+		Code: "NTA6", Name: "Ordinary Diploma (NACTVET NTA 6)", DegreeLevel: "Diploma", NTALevel: 6, PassMark: 50,
+		MinCGPA: 2.0, DiscontinueCGPA: 1.5,
+		DegreeClasses: "First Class:3.5,Second Class:3.0,Pass:2.0",
+		Bands: []models.GradeBand{
+			{Letter: "A", MinMarks: 80, GradePoint: 4, Remark: "PASS"},
+			{Letter: "B", MinMarks: 65, GradePoint: 3, Remark: "PASS"},
+			{Letter: "C", MinMarks: 50, GradePoint: 2, Remark: "PASS"},
+			{Letter: "D", MinMarks: 40, GradePoint: 1, Remark: "SUPP"},
+			{Letter: "F", MinMarks: 0, GradePoint: 0, Remark: "RETAKE"},
+		},
+	},
+	{
+		Code: "NTA5", Name: "Technician Certificate (NACTVET NTA 5)", DegreeLevel: "Certificate", NTALevel: 5, PassMark: 50,
+		MinCGPA: 2.0, DiscontinueCGPA: 1.5,
+		DegreeClasses: "First Class:3.5,Second Class:3.0,Pass:2.0",
+		Bands: []models.GradeBand{
+			{Letter: "A", MinMarks: 80, GradePoint: 4, Remark: "PASS"},
+			{Letter: "B", MinMarks: 65, GradePoint: 3, Remark: "PASS"},
+			{Letter: "C", MinMarks: 50, GradePoint: 2, Remark: "PASS"},
+			{Letter: "D", MinMarks: 40, GradePoint: 1, Remark: "SUPP"},
+			{Letter: "F", MinMarks: 0, GradePoint: 0, Remark: "RETAKE"},
+		},
+	},
+	{
+		Code: "PG", Name: "Postgraduate", DegreeLevel: "Masters", PassMark: 50,
+		MinCGPA: 3.0, DiscontinueCGPA: 2.0,
+		DegreeClasses: "Distinction:4.4,Credit:3.5,Pass:3.0",
+		Bands: []models.GradeBand{
+			{Letter: "A", MinMarks: 70, GradePoint: 5, Remark: "PASS"},
+			{Letter: "B+", MinMarks: 60, GradePoint: 4, Remark: "PASS"},
+			{Letter: "B", MinMarks: 50, GradePoint: 3, Remark: "PASS"},
+			{Letter: "C", MinMarks: 40, GradePoint: 2, Remark: "SUPP"},
+			{Letter: "F", MinMarks: 0, GradePoint: 0, Remark: "RETAKE"},
+		},
+	},
+	{
+		Code: "PHD", Name: "Doctoral", DegreeLevel: "PhD", PassMark: 50,
+		MinCGPA: 3.0, DiscontinueCGPA: 2.0, DegreeClasses: "Pass:3.0",
+		Bands: []models.GradeBand{
+			{Letter: "A", MinMarks: 70, GradePoint: 5, Remark: "PASS"},
+			{Letter: "B+", MinMarks: 60, GradePoint: 4, Remark: "PASS"},
+			{Letter: "B", MinMarks: 50, GradePoint: 3, Remark: "PASS"},
+			{Letter: "C", MinMarks: 40, GradePoint: 2, Remark: "SUPP"},
+			{Letter: "F", MinMarks: 0, GradePoint: 0, Remark: "RETAKE"},
+		},
+	},
+}
+
+type GradingService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewGradingService(db *gorm.DB, cfg *config.Config) *GradingService {
+	return &GradingService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// GradingSchemeInput defines or replaces a grading scheme and all of its bands
+type GradingSchemeInput struct {
+	Name            string             `json:"name"`
+	DegreeLevel     string             `json:"degree_level"`
+	NTALevel        int                `json:"nta_level"`
+	PassMark        float64            `json:"pass_mark"`
+	IsDefault       bool               `json:"is_default"`
+	MinCGPA         float64            `json:"min_cgpa"`
+	DiscontinueCGPA float64            `json:"discontinue_cgpa"`
+	DegreeClasses   string             `json:"degree_classes"`
+	Bands           []models.GradeBand `json:"bands"`
+}
+
+// ListSchemes returns every grading scheme with its bands, best grade first
+func (s *GradingService) ListSchemes() ([]models.GradingScheme, error) {
+	var schemes []models.GradingScheme
+	if err := s.db.Preload("Bands", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_marks DESC")
+	}).Order("code").Find(&schemes).Error; err != nil {
+		return nil, err
+	}
+	return schemes, nil
+}
+
+// GetScheme returns a grading scheme by code
+func (s *GradingService) GetScheme(code string) (*models.GradingScheme, error) {
+	var scheme models.GradingScheme
+	if err := s.db.Preload("Bands", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_marks DESC")
+	}).Where("code = ?", strings.ToUpper(code)).First(&scheme).Error; err != nil {
+		return nil, errors.New("grading scheme not found")
+	}
+	return &scheme, nil
+}
+
+// SaveScheme creates a grading scheme or replaces an existing one with the same code.
+// Existing grades keep their letters until they are next recalculated.
+func (s *GradingService) SaveScheme(code string, input GradingSchemeInput) (*models.GradingScheme, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || strings.TrimSpace(input.Name) == "" {
+		return nil, errors.New("code and name are required")
+	}
+	if err := validateBands(input.PassMark, input.Bands); err != nil {
+		return nil, err
+	}
+	if err := validateResultsThresholds(input); err != nil {
+		return nil, err
+	}
+
+	scheme := models.GradingScheme{Code: code}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(models.GradingScheme{Code: code}).FirstOrCreate(&scheme).Error; err != nil {
+			return err
+		}
+
+		scheme.Name = strings.TrimSpace(input.Name)
+		scheme.DegreeLevel = strings.TrimSpace(input.DegreeLevel)
+		scheme.NTALevel = input.NTALevel
+		scheme.PassMark = input.PassMark
+		scheme.IsDefault = input.IsDefault
+		scheme.MinCGPA = input.MinCGPA
+		scheme.DiscontinueCGPA = input.DiscontinueCGPA
+		scheme.DegreeClasses = strings.TrimSpace(input.DegreeClasses)
+		if err := tx.Save(&scheme).Error; err != nil {
+			return err
+		}
+
+		// Only one scheme can be the fallback
+		if scheme.IsDefault {
+			if err := tx.Model(&models.GradingScheme{}).Where("id <> ?", scheme.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("scheme_id = ?", scheme.ID).Delete(&models.GradeBand{}).Error; err != nil {
+			return err
+		}
+		for _, band := range input.Bands {
+			band.ID = 0
+			band.SchemeID = scheme.ID
+			band.Letter = strings.ToUpper(strings.TrimSpace(band.Letter))
+			if err := tx.Create(&band).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetScheme(code)
+}
+
+// SchemeForProgram picks the scheme for a program's level: an exact degree level and NTA level
+// match first, then the degree level alone, then the NTA level alone, then the default scheme
+func (s *GradingService) SchemeForProgram(program *models.Program) (*models.GradingScheme, error) {
+	schemes, err := s.ListSchemes()
+	if err != nil {
+		return nil, err
+	}
+	if len(schemes) == 0 {
+		scheme := DefaultGradingSchemes[0]
+		return &scheme, nil
+	}
+
+	var best *models.GradingScheme
+	bestScore := -1
+	for i := range schemes {
+		scheme := &schemes[i]
+		levelMatch := scheme.DegreeLevel != "" && strings.EqualFold(scheme.DegreeLevel, program.DegreeLevel)
+		ntaMatch := scheme.NTALevel != 0 && scheme.NTALevel == program.NTALevel
+		if (scheme.DegreeLevel != "" && !levelMatch) || (scheme.NTALevel != 0 && !ntaMatch) {
+			continue
+		}
+
+		score := 0
+		switch {
+		case levelMatch && ntaMatch:
+			score = 3
+		case levelMatch:
+			score = 2
+		case ntaMatch:
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = scheme, score
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+
+	for i := range schemes {
+		if schemes[i].IsDefault {
+			return &schemes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no grading scheme for %s (NTA level %d)", program.DegreeLevel, program.NTALevel)
+}
+
+// SchemeForProgramCode returns the grading scheme used by a program
+func (s *GradingService) SchemeForProgramCode(code string) (*models.GradingScheme, error) {
+	var program models.Program
+	if err := s.db.Where("code = ?", strings.ToUpper(code)).First(&program).Error; err != nil {
+		return nil, errors.New("program not found")
+	}
+	return s.SchemeForProgram(&program)
+}
+
+// SchemeForStudent returns the grading scheme of a student's program
+func (s *GradingService) SchemeForStudent(studentID uint) (*models.GradingScheme, error) {
+	var student models.Student
+	if err := s.db.Preload("Program").First(&student, studentID).Error; err != nil {
+		return nil, errors.New("student not found")
+	}
+	return s.SchemeForProgram(&student.Program)
+}
+
+// GradeFor returns the band a total falls in (bands must be ordered best first)
+func GradeFor(scheme *models.GradingScheme, total float64) models.GradeBand {
+	for _, band := range scheme.Bands {
+		if total >= band.MinMarks {
+			return band
+		}
+	}
+	return models.GradeBand{Letter: "F", Remark: "RETAKE"}
+}
+
+// bandOf returns the scheme's band for a letter grade
+func bandOf(scheme *models.GradingScheme, letter string) (models.GradeBand, bool) {
+	for _, band := range scheme.Bands {
+		if band.Letter == letter {
+			return band, true
+		}
+	}
+	return models.GradeBand{}, false
+}
+
+// passes reports whether a letter grade is at or above the scheme's pass mark
+func passes(scheme *models.GradingScheme, letter string) bool {
+	band, ok := bandOf(scheme, letter)
+	return ok && band.MinMarks >= scheme.PassMark
+}
+
+// validateResultsThresholds checks the probation and discontinuation CGPAs and that every degree
+// class entry parses
+func validateResultsThresholds(input GradingSchemeInput) error {
+	if input.MinCGPA < 0 || input.DiscontinueCGPA < 0 {
+		return errors.New("min_cgpa and discontinue_cgpa cannot be negative")
+	}
+	if input.MinCGPA > 0 && input.DiscontinueCGPA > input.MinCGPA {
+		return errors.New("discontinue_cgpa cannot be above min_cgpa")
+	}
+	entries := 0
+	for _, entry := range strings.Split(input.DegreeClasses, ",") {
+		if strings.TrimSpace(entry) != "" {
+			entries++
+		}
+	}
+	if len(parseDegreeClasses(input.DegreeClasses)) != entries {
+		return errors.New("degree_classes must look like \"First Class:4.4,Upper Second Class:3.5\"")
+	}
+	return nil
+}
+
+// validateBands checks a scheme covers 0-100 with distinct letters and boundaries
+func validateBands(passMark float64, bands []models.GradeBand) error {
+	if len(bands) == 0 {
+		return errors.New("at least one grade band is required")
+	}
+	if passMark < 0 || passMark > 100 {
+		return errors.New("pass_mark must be between 0 and 100")
+	}
+
+	letters := make(map[string]bool, len(bands))
+	marks := make(map[float64]bool, len(bands))
+	lowest := 100.0
+	for _, band := range bands {
+		letter := strings.ToUpper(strings.TrimSpace(band.Letter))
+		switch {
+		case letter == "":
+			return errors.New("every band needs a letter")
+		case letters[letter]:
+			return fmt.Errorf("letter %s is used more than once", letter)
+		case band.MinMarks < 0 || band.MinMarks > 100:
+			return fmt.Errorf("min_marks for %s must be between 0 and 100", letter)
+		case marks[band.MinMarks]:
+			return fmt.Errorf("two bands start at %.2f marks", band.MinMarks)
+		case band.GradePoint < 0:
+			return fmt.Errorf("grade_point for %s cannot be negative", letter)
+		}
+		letters[letter] = true
+		marks[band.MinMarks] = true
+		if band.MinMarks < lowest {
+			lowest = band.MinMarks
+		}
+	}
+	if lowest != 0 {
+		return errors.New("the lowest band must start at 0 marks")
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Marks sheet formats
+const (
+	SheetCSV  = "csv"
+	SheetXLSX = "xlsx"
+)
+
+// Fixed marks sheet columns; every other column is an assessment component
+const (
+	columnRegNumber = "reg_number"
+	columnName      = "name"
+)
+
+// componentHeader matches a component column such as "Test 1 (max 50)"
+var componentHeader = regexp.MustCompile(`^(.*?)\s*\(max [0-9.]+\)$`)
+
+type MarksSheetService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	assessments *AssessmentService
+}
+
+func NewMarksSheetService(db *gorm.DB, cfg *config.Config) *MarksSheetService {
+	return &MarksSheetService{
+		db:          db,
+		cfg:         cfg,
+		assessments: NewAssessmentService(db, cfg),
+	}
+}
+
+// MarksSheet is a generated marks template
+type MarksSheet struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// MarksRowError is a problem with one row (or cell) of an uploaded marks sheet
+type MarksRowError struct {
+	Row       int    `json:"row"` // 1-based spreadsheet row; the header is row 1
+	RegNumber string `json:"reg_number,omitempty"`
+	Column    string `json:"column,omitempty"`
+	Message   string `json:"message"`
+}
+
+// MarksUploadResult reports what an upload recorded, or would record in a preview
+type MarksUploadResult struct {
+	OfferingID uint            `json:"offering_id"`
+	Preview    bool            `json:"preview"`
+	Committed  bool            `json:"committed"`
+	Rows       int             `json:"rows"`   // Student rows read
+	Scores     int             `json:"scores"` // Scores and markers recorded (or to be recorded)
+	Errors     []MarksRowError `json:"errors"`
+}
+
+// sheetScore is one parsed cell of a marks sheet
+type sheetScore struct {
+	enrollment *models.Enrollment
+	component  *models.AssessmentComponent
+	score      ScoreInput
+}
+
+// Template builds a marks sheet for an offering: one row per enrolled student (reg number and name)
+// and one column per assessment component, pre-filled with the scores and markers recorded so far
+func (s *MarksSheetService) Template(offeringID uint, format string) (*MarksSheet, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course").Preload("Semester").First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	components, rows, err := s.assessments.OfferingScores(offeringID)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{columnRegNumber, columnName}
+	for _, component := range components {
+		header = append(header, fmt.Sprintf("%s (max %s)", component.Name, formatMarks(component.MaxMarks)))
+	}
+	records := [][]string{header}
+	for _, row := range rows {
+		record := []string{row.RegNumber, row.Name}
+		for _, component := range components {
+			cell := ""
+			if marker, ok := row.Markers[component.Name]; ok {
+				cell = marker
+			} else if score, ok := row.Scores[component.Name]; ok {
+				cell = formatMarks(score)
+			}
+			record = append(record, cell)
+		}
+		records = append(records, record)
+	}
+
+	name := fmt.Sprintf("%s_%s_marks", offering.Course.Code, strings.ReplaceAll(offering.Semester.Name, "/", "-"))
+	name = strings.NewReplacer(" ", "_").Replace(name)
+
+	switch format {
+	case SheetCSV, "":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.WriteAll(records); err != nil {
+			return nil, err
+		}
+		return &MarksSheet{Filename: name + ".csv", ContentType: "text/csv", Data: buf.Bytes()}, nil
+	case SheetXLSX:
+		data, err := writeXLSX(records)
+		if err != nil {
+			return nil, err
+		}
+		return &MarksSheet{
+			Filename:    name + ".xlsx",
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Data:        data,
+		}, nil
+	}
+	return nil, fmt.Errorf("format must be %s or %s", SheetCSV, SheetXLSX)
+}
+
+// Upload parses a marks sheet and validates every row: reg numbers must be enrolled in the offering and
+// each cell must be a score within the component's maximum or a marker (ABS, I, DQ); empty cells are
+// left unchanged. A preview, or a sheet with any error, records nothing. Otherwise every score is
+// recorded in one transaction, so the upload either applies completely or not at all.
+func (s *MarksSheetService) Upload(offeringID uint, format string, file io.Reader, preview bool, actor Actor) (*MarksUploadResult, error) {
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	if err := resultsEditable(s.db, offeringID); err != nil {
+		return nil, err
+	}
+
+	records, err := readSheet(format, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the marks sheet is empty")
+	}
+
+	result := &MarksUploadResult{OfferingID: offeringID, Preview: preview, Errors: []MarksRowError{}}
+	scores, err := s.parse(offeringID, records, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Scores = len(scores)
+	if preview || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		assessments := s.assessments.withDB(tx)
+		for _, parsed := range scores {
+			if _, err := assessments.recordScore(parsed.component, parsed.enrollment, parsed.score.Score, parsed.score.Marker, actor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// parse maps the header to components and validates each row, collecting row errors in result
+func (s *MarksSheetService) parse(offeringID uint, records [][]string, result *MarksUploadResult) ([]sheetScore, error) {
+	components, err := offeringComponents(s.db, offeringID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.AssessmentComponent, len(components))
+	for i := range components {
+		byName[strings.ToLower(components[i].Name)] = &components[i]
+	}
+
+	// Header: reg_number, an optional name and one column per component
+	type column struct {
+		index     int
+		component *models.AssessmentComponent
+	}
+	regColumn := -1
+	var columns []column
+	mapped := make(map[uint]bool, len(components))
+	for i, cell := range records[0] {
+		title := strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+		switch strings.ToLower(title) {
+		case columnRegNumber:
+			regColumn = i
+			continue
+		case columnName, "":
+			continue
+		}
+		if match := componentHeader.FindStringSubmatch(title); match != nil {
+			title = match[1]
+		}
+		component, ok := byName[strings.ToLower(title)]
+		switch {
+		case !ok:
+			result.Errors = append(result.Errors, MarksRowError{Row: 1, Column: cell, Message: "unknown assessment component"})
+		case mapped[component.ID]:
+			result.Errors = append(result.Errors, MarksRowError{Row: 1, Column: cell, Message: "component appears in more than one column"})
+		default:
+			mapped[component.ID] = true
+			columns = append(columns, column{index: i, component: component})
+		}
+	}
+	if regColumn < 0 {
+		return nil, errors.New("the marks sheet needs a reg_number column")
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Preload("Student").Where("offering_id = ? AND status <> ?", offeringID, "dropped").
+		Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	enrolled := make(map[string]*models.Enrollment, len(enrollments))
+	for i := range enrollments {
+		enrolled[strings.ToUpper(enrollments[i].Student.RegNumber)] = &enrollments[i]
+	}
+
+	var scores []sheetScore
+	seen := make(map[string]int, len(records))
+	for r, record := range records[1:] {
+		row := r + 2
+		if blankRecord(record) {
+			continue
+		}
+		result.Rows++
+
+		regNumber := strings.ToUpper(strings.TrimSpace(cellAt(record, regColumn)))
+		switch {
+		case regNumber == "":
+			result.Errors = append(result.Errors, MarksRowError{Row: row, Message: "reg_number is missing"})
+			continue
+		case seen[regNumber] > 0:
+			result.Errors = append(result.Errors, MarksRowError{Row: row, RegNumber: regNumber,
+				Message: fmt.Sprintf("reg_number already appears on row %d", seen[regNumber])})
+			continue
+		}
+		seen[regNumber] = row
+
+		enrollment, ok := enrolled[regNumber]
+		if !ok {
+			result.Errors = append(result.Errors, MarksRowError{Row: row, RegNumber: regNumber,
+				Message: "student is not enrolled in this course offering"})
+			continue
+		}
+
+		for _, col := range columns {
+			component := col.component
+			raw := strings.TrimSpace(cellAt(record, col.index))
+			if raw == "" {
+				continue
+			}
+			score := ScoreInput{StudentID: enrollment.StudentID}
+			if value, err := strconv.ParseFloat(raw, 64); err == nil {
+				score.Score = value
+			} else {
+				score.Marker = raw
+			}
+			if err := validateScore(&score, component.MaxMarks); err != nil {
+				result.Errors = append(result.Errors, MarksRowError{Row: row, RegNumber: regNumber, Column: component.Name,
+					Message: fmt.Sprintf("%q must be a score between 0 and %s or one of %s, %s, %s",
+						raw, formatMarks(component.MaxMarks), MarkerAbsent, MarkerIncomplete, MarkerDisqualified)})
+				continue
+			}
+			scores = append(scores, sheetScore{enrollment: enrollment, component: component, score: score})
+		}
+	}
+	return scores, nil
+}
+
+// readSheet reads every row of a CSV file or the first worksheet of an XLSX workbook
+func readSheet(format string, file io.Reader) ([][]string, error) {
+	switch format {
+	case SheetCSV:
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV file: %w", err)
+		}
+		return records, nil
+	case SheetXLSX:
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX file: %w", err)
+		}
+		defer workbook.Close()
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("the workbook has no worksheets")
+		}
+		return workbook.GetRows(sheets[0])
+	}
+	return nil, fmt.Errorf("format must be %s or %s", SheetCSV, SheetXLSX)
+}
+
+// writeXLSX writes records to a single-sheet workbook
+func writeXLSX(records [][]string) ([]byte, error) {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	sheet := workbook.GetSheetName(0)
+
+	for r, record := range records {
+		cell, err := excelize.CoordinatesToCellName(1, r+1)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(record))
+		for i, value := range record {
+			// Scores are written as numbers so they stay editable as numbers
+			if number, err := strconv.ParseFloat(value, 64); err == nil && r > 0 && i > 1 {
+				values[i] = number
+			} else {
+				values[i] = value
+			}
+		}
+		if err := workbook.SetSheetRow(sheet, cell, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	// Room for reg numbers and names
+	if err := workbook.SetColWidth(sheet, "A", "B", 24); err != nil {
+		return nil, err
+	}
+
+	buf, err := workbook.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cellAt(record []string, index int) string {
+	if index < len(record) {
+		return record[index]
+	}
+	return ""
+}
+
+func blankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
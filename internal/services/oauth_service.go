@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,6 +12,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrInvalidScope is returned when a client requests a scope it was not registered with
+var ErrInvalidScope = errors.New("requested scope exceeds the client's registered scopes")
+
 type OAuthService struct {
 	db  *gorm.DB
 	cfg *config.Config
@@ -170,6 +174,56 @@ func (s *OAuthService) ExchangeCodeForToken(code, clientID, clientSecret, redire
 	return &token, nil
 }
 
+// IssueClientCredentialsToken creates an access token for a confidential client acting on its own behalf.
+// Client tokens are not tied to a user, so UserID is left as 0.
+func (s *OAuthService) IssueClientCredentialsToken(clientID, clientSecret, scope string) (*models.OAuthAccessToken, error) {
+	var client models.OAuthClient
+	if err := s.db.Where("client_id = ? AND is_active = ?", clientID, true).First(&client).Error; err != nil {
+		return nil, errors.New("invalid client credentials")
+	}
+
+	if !utils.CheckPassword(client.ClientSecret, clientSecret) {
+		return nil, errors.New("invalid client credentials")
+	}
+
+	// A client may narrow its registered scopes but never widen them
+	var registered []string
+	for _, allowed := range strings.Split(client.Scopes, ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" {
+			registered = append(registered, allowed)
+		}
+	}
+	requested := strings.Fields(scope)
+	for _, name := range requested {
+		if !slices.Contains(registered, name) {
+			return nil, ErrInvalidScope
+		}
+	}
+	if len(requested) == 0 {
+		requested = registered
+	}
+	scope = strings.Join(requested, " ")
+
+	accessToken, err := utils.GenerateRandomAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := models.OAuthAccessToken{
+		Token:     accessToken,
+		ClientID:  clientID,
+		UserID:    0,
+		Scopes:    scope,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+
+	if err := s.db.Create(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
 // ValidateAccessToken checks if access token is valid
 func (s *OAuthService) ValidateAccessToken(token string) (*models.OAuthAccessToken, *models.User, error) {
 	var accessToken models.OAuthAccessToken
@@ -182,6 +236,11 @@ func (s *OAuthService) ValidateAccessToken(token string) (*models.OAuthAccessTok
 		return nil, nil, errors.New("access token has expired")
 	}
 
+	// Client credential tokens have no user
+	if accessToken.UserID == 0 {
+		return &accessToken, nil, nil
+	}
+
 	// Get user
 	var user models.User
 	if err := s.db.Where("id = ?", accessToken.UserID).First(&user).Error; err != nil {
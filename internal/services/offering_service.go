@@ -0,0 +1,624 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Capacity sources reported with offering seats
+const (
+	CapacityExplicit  = "explicit"
+	CapacitySections  = "sections"
+	CapacityVenue     = "venue"
+	CapacityUnlimited = "unlimited"
+)
+
+// DefaultSectionCode is the section every offering starts with
+const DefaultSectionCode = "A"
+
+type OfferingService struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	events *EventPublisher
+}
+
+func NewOfferingService(db *gorm.DB, cfg *config.Config) *OfferingService {
+	return &OfferingService{
+		db:     db,
+		cfg:    cfg,
+		events: NewEventPublisher(db, cfg),
+	}
+}
+
+// OfferingSeats is the seat count of a course offering
+type OfferingSeats struct {
+	OfferingID     uint   `json:"offering_id"`
+	CourseCode     string `json:"course_code"`
+	SemesterID     uint   `json:"semester_id"`
+	Capacity       *int   `json:"capacity"` // nil when unlimited
+	CapacitySource string `json:"capacity_source"`
+	Enrolled       int    `json:"enrolled"`
+	Available      *int   `json:"available"` // nil when unlimited
+	Waitlisted     int    `json:"waitlisted"`
+}
+
+// Full reports whether no seats are left
+func (o *OfferingSeats) Full() bool {
+	return o.Available != nil && *o.Available <= 0
+}
+
+// SectionInput creates or updates a section; ProgramCode "" leaves the section open to all programs
+type SectionInput struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	ProgramCode string `json:"program_code"`
+	Capacity    *int   `json:"capacity"`
+}
+
+// OfferingDetail is an offering with its sections
+type OfferingDetail struct {
+	ID         uint            `json:"id"`
+	CourseCode string          `json:"course_code"`
+	CourseName string          `json:"course_name"`
+	Credits    int             `json:"credits"`
+	SemesterID uint            `json:"semester_id"`
+	Semester   string          `json:"semester"`
+	LMSShellID string          `json:"lms_shell_id"`
+	Seats      *OfferingSeats  `json:"seats"`
+	Sections   []SectionDetail `json:"sections"`
+}
+
+// SectionDetail is a section with its lecturers, lectures and roster size
+type SectionDetail struct {
+	ID         uint              `json:"id"`
+	Code       string            `json:"code"`
+	Name       string            `json:"name"`
+	Program    string            `json:"program,omitempty"` // Program code for a program stream
+	Capacity   *int              `json:"capacity"`
+	Enrolled   int               `json:"enrolled"`
+	LMSShellID string            `json:"lms_shell_id"`
+	Lecturers  []SectionLecturer `json:"lecturers"`
+	Lectures   []models.Lecture  `json:"lectures"`
+}
+
+type SectionLecturer struct {
+	AssignmentID uint   `json:"assignment_id"`
+	StaffID      string `json:"staff_id"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+}
+
+// ListOfferings lists the offerings of a semester (current when 0), optionally for one course
+func (s *OfferingService) ListOfferings(semesterID uint, courseCode string) ([]OfferingDetail, error) {
+	if semesterID == 0 {
+		var semester models.Semester
+		if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+			return nil, errors.New("no current semester")
+		}
+		semesterID = semester.ID
+	}
+
+	query := s.db.Preload("Course").Preload("Semester").
+		Joins("JOIN courses ON courses.id = course_offerings.course_id").
+		Where("course_offerings.semester_id = ?", semesterID).
+		Order("courses.code")
+	if courseCode != "" {
+		query = query.Where("courses.code = ?", strings.ToUpper(courseCode))
+	}
+
+	var offerings []models.CourseOffering
+	if err := query.Find(&offerings).Error; err != nil {
+		return nil, err
+	}
+
+	details := make([]OfferingDetail, 0, len(offerings))
+	for i := range offerings {
+		detail, err := s.describe(&offerings[i])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, *detail)
+	}
+	return details, nil
+}
+
+// GetOffering returns an offering with its sections, lecturers and lectures
+func (s *OfferingService) GetOffering(offeringID uint) (*OfferingDetail, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course").Preload("Semester").First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	return s.describe(&offering)
+}
+
+// CreateSection adds a section (stream) to an offering
+func (s *OfferingService) CreateSection(offeringID uint, input SectionInput) (*models.CourseSection, error) {
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	if input.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	if input.Capacity != nil && *input.Capacity < 0 {
+		return nil, errors.New("capacity cannot be negative")
+	}
+
+	var existing int64
+	s.db.Model(&models.CourseSection{}).Where("offering_id = ? AND code = ?", offering.ID, input.Code).Count(&existing)
+	if existing > 0 {
+		return nil, fmt.Errorf("section %s already exists", input.Code)
+	}
+
+	section := models.CourseSection{
+		OfferingID: offering.ID,
+		Code:       input.Code,
+		Name:       input.Name,
+		Capacity:   input.Capacity,
+	}
+	if section.Name == "" {
+		section.Name = "Section " + section.Code
+	}
+	if input.ProgramCode != "" {
+		programID, err := s.programID(input.ProgramCode)
+		if err != nil {
+			return nil, err
+		}
+		section.ProgramID = &programID
+	}
+
+	if err := s.db.Create(&section).Error; err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// UpdateSection changes a section's name, program stream or capacity
+func (s *OfferingService) UpdateSection(sectionID uint, input SectionInput) (*models.CourseSection, error) {
+	var section models.CourseSection
+	if err := s.db.First(&section, sectionID).Error; err != nil {
+		return nil, errors.New("section not found")
+	}
+	if input.Capacity != nil && *input.Capacity < 0 {
+		return nil, errors.New("capacity cannot be negative")
+	}
+
+	updates := map[string]interface{}{"capacity": input.Capacity}
+	if input.Name != "" {
+		updates["name"] = input.Name
+	}
+	switch input.ProgramCode {
+	case "":
+	case "*":
+		updates["program_id"] = nil // Open the section to every program
+	default:
+		programID, err := s.programID(input.ProgramCode)
+		if err != nil {
+			return nil, err
+		}
+		updates["program_id"] = programID
+	}
+
+	if err := s.db.Model(&section).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&section, sectionID).Error; err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// DeleteSection removes an empty section; an offering always keeps at least one
+func (s *OfferingService) DeleteSection(sectionID uint) error {
+	var section models.CourseSection
+	if err := s.db.First(&section, sectionID).Error; err != nil {
+		return errors.New("section not found")
+	}
+
+	var enrolled, siblings int64
+	s.db.Model(&models.Enrollment{}).Where("section_id = ?", section.ID).Count(&enrolled)
+	if enrolled > 0 {
+		return errors.New("section has enrolled students; move them first")
+	}
+	s.db.Model(&models.CourseSection{}).Where("offering_id = ?", section.OfferingID).Count(&siblings)
+	if siblings <= 1 {
+		return errors.New("an offering needs at least one section")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Lecture{}).Where("section_id = ?", section.ID).Update("section_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CourseAssignment{}).Where("section_id = ?", section.ID).Update("section_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&section).Error
+	})
+}
+
+// SectionRoster lists the active enrollments of a section
+func (s *OfferingService) SectionRoster(sectionID uint) (*models.CourseSection, []models.Enrollment, error) {
+	var section models.CourseSection
+	if err := s.db.Preload("Offering.Course").Preload("Offering.Semester").First(&section, sectionID).Error; err != nil {
+		return nil, nil, errors.New("section not found")
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Preload("Student.Program").
+		Where("section_id = ? AND status = ?", section.ID, "active").
+		Order("id").Find(&enrollments).Error; err != nil {
+		return nil, nil, err
+	}
+	return &section, enrollments, nil
+}
+
+// MoveEnrollment moves an enrollment (and its grade) to another section of the same offering
+func (s *OfferingService) MoveEnrollment(enrollmentID, sectionID uint, actor Actor) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	if err := s.db.First(&enrollment, enrollmentID).Error; err != nil {
+		return nil, errors.New("enrollment not found")
+	}
+	var section models.CourseSection
+	if err := s.db.First(&section, sectionID).Error; err != nil {
+		return nil, errors.New("section not found")
+	}
+	if enrollment.OfferingID == nil || *enrollment.OfferingID != section.OfferingID {
+		return nil, errors.New("section belongs to a different course offering")
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Model(&enrollment).Update("section_id", section.ID).Error; err != nil {
+			return err
+		}
+		enrollment.SectionID = &section.ID
+		if err := outbox.Publish(EventEnrollmentUpdated, enrollmentEventData(&enrollment)); err != nil {
+			return err
+		}
+
+		var grade models.Grade
+		if err := tx.Where("enrollment_id = ?", enrollment.ID).First(&grade).Error; err != nil {
+			return nil // Not graded yet
+		}
+		before := grade
+		grade.SectionID = &section.ID
+		if err := tx.Model(&grade).Update("section_id", section.ID).Error; err != nil {
+			return err
+		}
+		return recordGradeHistory(tx, &before, &grade, actor, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+func (s *OfferingService) describe(offering *models.CourseOffering) (*OfferingDetail, error) {
+	seats, err := offeringSeats(s.db, offering)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []models.CourseSection
+	if err := s.db.Preload("Program").Where("offering_id = ?", offering.ID).Order("code").Find(&sections).Error; err != nil {
+		return nil, err
+	}
+
+	var assignments []models.CourseAssignment
+	if err := s.db.Preload("Faculty").
+		Where("course_id = ? AND semester_id = ?", offering.CourseID, offering.SemesterID).
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	detail := &OfferingDetail{
+		ID:         offering.ID,
+		CourseCode: offering.Course.Code,
+		CourseName: offering.Course.Name,
+		Credits:    offering.Course.Credits,
+		SemesterID: offering.SemesterID,
+		Semester:   offering.Semester.Name,
+		LMSShellID: LMSShellID(offering, nil),
+		Seats:      seats,
+		Sections:   []SectionDetail{},
+	}
+
+	for i := range sections {
+		section := &sections[i]
+		var enrolled int64
+		s.db.Model(&models.Enrollment{}).Where("section_id = ? AND status = ?", section.ID, "active").Count(&enrolled)
+
+		var lectures []models.Lecture
+		if err := s.db.Preload("Venue").Where("section_id = ?", section.ID).
+			Order("day_of_week, start_time").Find(&lectures).Error; err != nil {
+			return nil, err
+		}
+
+		sectionDetail := SectionDetail{
+			ID:         section.ID,
+			Code:       section.Code,
+			Name:       section.Name,
+			Capacity:   section.Capacity,
+			Enrolled:   int(enrolled),
+			LMSShellID: LMSShellID(offering, section),
+			Lecturers:  []SectionLecturer{},
+			Lectures:   lectures,
+		}
+		if section.Program != nil {
+			sectionDetail.Program = section.Program.Code
+		}
+
+		// Offering-wide assignments (no section) teach every section
+		for _, assignment := range assignments {
+			if assignment.SectionID != nil && *assignment.SectionID != section.ID {
+				continue
+			}
+			sectionDetail.Lecturers = append(sectionDetail.Lecturers, SectionLecturer{
+				AssignmentID: assignment.ID,
+				StaffID:      assignment.Faculty.StaffID,
+				Name:         assignment.Faculty.FirstName + " " + assignment.Faculty.LastName,
+				Role:         assignment.Role,
+			})
+		}
+
+		detail.Sections = append(detail.Sections, sectionDetail)
+	}
+
+	return detail, nil
+}
+
+func (s *OfferingService) programID(code string) (uint, error) {
+	var program models.Program
+	if err := s.db.Where("code = ?", strings.ToUpper(code)).First(&program).Error; err != nil {
+		return 0, errors.New("program not found")
+	}
+	return program.ID, nil
+}
+
+// LMSShellID is the stable identifier of the LMS course shell for an offering or one of its sections,
+// e.g. CS101-2024/2025-S1 or CS101-2024/2025-S1-A
+func LMSShellID(offering *models.CourseOffering, section *models.CourseSection) string {
+	id := fmt.Sprintf("%s-%s-S%d", offering.Course.Code, offering.Semester.AcademicYear, offering.Semester.SemesterNum)
+	if section != nil {
+		id += "-" + section.Code
+	}
+	return id
+}
+
+// findOffering returns the offering of a course in a semester with its course loaded, or nil when the
+// course has no offering yet. Unlike findOrCreateOffering it never writes.
+func findOffering(db *gorm.DB, courseID, semesterID uint) (*models.CourseOffering, error) {
+	var offering models.CourseOffering
+	err := db.Preload("Course").Where("course_id = ? AND semester_id = ?", courseID, semesterID).First(&offering).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &offering, nil
+}
+
+// findOrCreateOffering returns the offering of a course in a semester with its course and semester loaded.
+// New offerings get a default section.
+func findOrCreateOffering(db *gorm.DB, courseID, semesterID uint) (*models.CourseOffering, error) {
+	offering := models.CourseOffering{CourseID: courseID, SemesterID: semesterID}
+	if err := db.Where(&offering).FirstOrCreate(&offering).Error; err != nil {
+		return nil, err
+	}
+	if err := db.First(&offering.Course, courseID).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+	if err := db.First(&offering.Semester, semesterID).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	section := models.CourseSection{OfferingID: offering.ID}
+	var sections int64
+	db.Model(&section).Where("offering_id = ?", offering.ID).Count(&sections)
+	if sections == 0 {
+		section.Code = DefaultSectionCode
+		section.Name = "Section " + DefaultSectionCode
+		if err := db.Create(&section).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &offering, nil
+}
+
+// ErrSectionsFull is returned when every section an enrollment could join is at capacity
+var ErrSectionsFull = errors.New("every section of the course is full")
+
+// ErrCourseFull is returned when an offering has no seat left by the time an enrollment takes one
+var ErrCourseFull = errors.New("the course is full")
+
+// lockOffering returns the offering of a course in a semester like findOrCreateOffering and locks its
+// row until the transaction ends, so enrollments into the offering are placed one at a time
+func lockOffering(tx *gorm.DB, courseID, semesterID uint) (*models.CourseOffering, error) {
+	offering, err := findOrCreateOffering(tx, courseID, semesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.CourseOffering{}, offering.ID).Error; err != nil {
+		return nil, err
+	}
+	return offering, nil
+}
+
+// reserveSeat re-checks, under the offering's row lock, that a course still has a free seat. Callers
+// enforcing capacity run it in the enrolling transaction, so two registrations (or a registration and
+// a waitlist promotion) cannot both take the last seat.
+func reserveSeat(tx *gorm.DB, courseID, semesterID uint) error {
+	offering, err := lockOffering(tx, courseID, semesterID)
+	if err != nil {
+		return err
+	}
+	seats, err := offeringSeats(tx, offering)
+	if err != nil {
+		return err
+	}
+	if seats.Full() {
+		return ErrCourseFull
+	}
+	return nil
+}
+
+// placeEnrollment attaches an enrollment to its offering and picks a section with a free seat: the
+// student's program stream when there is one, otherwise an open section, preferring the one with the
+// most free seats. It returns ErrSectionsFull when none of them has room. The offering stays locked
+// for the rest of the transaction.
+func placeEnrollment(db *gorm.DB, enrollment *models.Enrollment, programID uint) error {
+	offering, err := lockOffering(db, enrollment.CourseID, enrollment.SemesterID)
+	if err != nil {
+		return err
+	}
+	enrollment.OfferingID = &offering.ID
+
+	if enrollment.SectionID != nil {
+		return nil
+	}
+
+	var sections []models.CourseSection
+	if err := db.Where("offering_id = ?", offering.ID).Order("code").Find(&sections).Error; err != nil {
+		return err
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+
+	var stream, open []models.CourseSection
+	for _, section := range sections {
+		switch {
+		case section.ProgramID != nil && *section.ProgramID == programID:
+			stream = append(stream, section)
+		case section.ProgramID == nil:
+			open = append(open, section)
+		}
+	}
+	// Other programs' streams are only used when the offering has nothing else
+	tiers := [][]models.CourseSection{stream, open}
+	if len(stream) == 0 && len(open) == 0 {
+		tiers = [][]models.CourseSection{sections}
+	}
+
+	for _, candidates := range tiers {
+		var best uint
+		bestFree := 0
+		for _, section := range candidates {
+			free := 1 << 30 // Uncapped sections always have room
+			if section.Capacity != nil {
+				var enrolled int64
+				db.Model(&models.Enrollment{}).Where("section_id = ? AND status = ?", section.ID, "active").Count(&enrolled)
+				free = *section.Capacity - int(enrolled)
+			}
+			if free > bestFree {
+				best, bestFree = section.ID, free
+			}
+		}
+		if best != 0 {
+			enrollment.SectionID = &best
+			return nil
+		}
+	}
+	return ErrSectionsFull
+}
+
+// enrolledLectures scopes lectures to the sections of the given enrollments. Lectures without a
+// section are shared by the whole offering; enrollments without a section see every lecture.
+func enrolledLectures(db *gorm.DB, enrollments []models.Enrollment) *gorm.DB {
+	var courseIDs, unsectioned, sectionIDs []uint
+	for _, enrollment := range enrollments {
+		courseIDs = append(courseIDs, enrollment.CourseID)
+		if enrollment.SectionID == nil {
+			unsectioned = append(unsectioned, enrollment.CourseID)
+		} else {
+			sectionIDs = append(sectionIDs, *enrollment.SectionID)
+		}
+	}
+	if len(unsectioned) == 0 {
+		unsectioned = []uint{0}
+	}
+	if len(sectionIDs) == 0 {
+		sectionIDs = []uint{0}
+	}
+	return db.Where("lectures.course_id IN ? AND (lectures.section_id IS NULL OR lectures.section_id IN ? OR lectures.course_id IN ?)",
+		courseIDs, sectionIDs, unsectioned)
+}
+
+// offeringSeats counts an offering's seats. The cap is, in order: the offering's explicit capacity,
+// the sum of its section capacities (when every section has one), the smallest venue used by its
+// lectures that semester, or unlimited.
+func offeringSeats(db *gorm.DB, offering *models.CourseOffering) (*OfferingSeats, error) {
+	seats := &OfferingSeats{
+		OfferingID:     offering.ID,
+		CourseCode:     offering.Course.Code,
+		SemesterID:     offering.SemesterID,
+		Capacity:       offering.Capacity,
+		CapacitySource: CapacityExplicit,
+	}
+
+	if seats.Capacity == nil {
+		var sections []models.CourseSection
+		if err := db.Where("offering_id = ?", offering.ID).Find(&sections).Error; err != nil {
+			return nil, err
+		}
+		total, capped := 0, len(sections) > 0
+		for _, section := range sections {
+			if section.Capacity == nil {
+				capped = false
+				break
+			}
+			total += *section.Capacity
+		}
+		if capped {
+			seats.Capacity = &total
+			seats.CapacitySource = CapacitySections
+		}
+	}
+
+	if seats.Capacity == nil {
+		var venueCapacity *int
+		if err := db.Model(&models.Lecture{}).
+			Joins("JOIN venues ON venues.id = lectures.venue_id").
+			Where("lectures.course_id = ? AND lectures.semester_id = ?", offering.CourseID, offering.SemesterID).
+			Select("MIN(venues.capacity)").Scan(&venueCapacity).Error; err != nil {
+			return nil, err
+		}
+		seats.Capacity = venueCapacity
+		seats.CapacitySource = CapacityVenue
+		if venueCapacity == nil {
+			seats.CapacitySource = CapacityUnlimited
+		}
+	}
+
+	var enrolled, waitlisted int64
+	if err := db.Model(&models.Enrollment{}).
+		Where("course_id = ? AND semester_id = ? AND status = ?", offering.CourseID, offering.SemesterID, "active").
+		Count(&enrolled).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.WaitlistEntry{}).
+		Where("offering_id = ? AND status = ?", offering.ID, WaitlistWaiting).
+		Count(&waitlisted).Error; err != nil {
+		return nil, err
+	}
+	seats.Enrolled = int(enrolled)
+	seats.Waitlisted = int(waitlisted)
+
+	if seats.Capacity != nil {
+		available := *seats.Capacity - seats.Enrolled
+		if available < 0 {
+			available = 0
+		}
+		seats.Available = &available
+	}
+	return seats, nil
+}
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Requirement types
+const (
+	RequirementPrerequisite = "prerequisite"
+	RequirementCorequisite  = "corequisite"
+)
+
+type PrerequisiteService struct {
+	db      *gorm.DB
+	cfg     *config.Config
+	grading *GradingService
+}
+
+func NewPrerequisiteService(db *gorm.DB, cfg *config.Config) *PrerequisiteService {
+	return &PrerequisiteService{
+		db:      db,
+		cfg:     cfg,
+		grading: NewGradingService(db, cfg),
+	}
+}
+
+// PrerequisiteInput adds a requirement to a course
+type PrerequisiteInput struct {
+	RequiredCourseCode string `json:"required_course_code"`
+	Type               string `json:"type"`      // prerequisite (default) or corequisite
+	MinGrade           string `json:"min_grade"` // Optional minimum letter grade
+	GroupNo            int    `json:"group_no"`  // Same non-zero group = "any of"
+}
+
+// PrerequisiteGraph is the transitive requirement graph of a course
+type PrerequisiteGraph struct {
+	Course     string             `json:"course"`
+	Nodes      []PrerequisiteNode `json:"nodes"`
+	Edges      []PrerequisiteEdge `json:"edges"`
+	Groups     []RequirementGroup `json:"requirements"` // Direct requirements of the course
+	RequiredBy []PrerequisiteNode `json:"required_by"`
+}
+
+type PrerequisiteNode struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Level   int    `json:"level"`
+	Credits int    `json:"credits"`
+}
+
+// PrerequisiteEdge points from a course to a course it requires
+type PrerequisiteEdge struct {
+	ID       uint   `json:"id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Type     string `json:"type"`
+	MinGrade string `json:"min_grade,omitempty"`
+	GroupNo  int    `json:"group_no,omitempty"`
+}
+
+// RequirementGroup is satisfied when any one of its courses is satisfied
+type RequirementGroup struct {
+	Type     string   `json:"type"`
+	AnyOf    []string `json:"any_of"`
+	MinGrade string   `json:"min_grade,omitempty"`
+}
+
+// AddPrerequisite attaches a prerequisite or corequisite to a course
+func (s *PrerequisiteService) AddPrerequisite(courseCode string, input PrerequisiteInput) (*models.CoursePrerequisite, error) {
+	var course, required models.Course
+	if err := s.db.Where("code = ?", strings.ToUpper(courseCode)).First(&course).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+	if err := s.db.Where("code = ?", strings.ToUpper(input.RequiredCourseCode)).First(&required).Error; err != nil {
+		return nil, errors.New("required course not found")
+	}
+	if course.ID == required.ID {
+		return nil, errors.New("a course cannot require itself")
+	}
+
+	if input.Type == "" {
+		input.Type = RequirementPrerequisite
+	}
+	if input.Type != RequirementPrerequisite && input.Type != RequirementCorequisite {
+		return nil, errors.New("type must be prerequisite or corequisite")
+	}
+	input.MinGrade = strings.ToUpper(strings.TrimSpace(input.MinGrade))
+	if input.MinGrade != "" {
+		if err := s.validateMinGrade(&course, input.MinGrade); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prerequisite chains must stay acyclic
+	if input.Type == RequirementPrerequisite {
+		upstream, err := s.upstreamCourseIDs(required.ID)
+		if err != nil {
+			return nil, err
+		}
+		if upstream[course.ID] {
+			return nil, fmt.Errorf("%s already requires %s; this would create a cycle", required.Code, course.Code)
+		}
+	}
+
+	prerequisite := models.CoursePrerequisite{
+		CourseID:         course.ID,
+		RequiredCourseID: required.ID,
+		Type:             input.Type,
+		MinGrade:         input.MinGrade,
+		GroupNo:          input.GroupNo,
+	}
+	if err := s.db.Create(&prerequisite).Error; err != nil {
+		return nil, err
+	}
+	prerequisite.RequiredCourse = required
+
+	return &prerequisite, nil
+}
+
+// RemovePrerequisite deletes a requirement from a course
+func (s *PrerequisiteService) RemovePrerequisite(courseCode string, prerequisiteID uint) error {
+	result := s.db.
+		Where("id = ? AND course_id = (?)", prerequisiteID,
+			s.db.Model(&models.Course{}).Select("id").Where("code = ?", strings.ToUpper(courseCode))).
+		Delete(&models.CoursePrerequisite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("prerequisite not found")
+	}
+	return nil
+}
+
+// CheckRequirements returns the unmet requirements for a student taking a course in a semester.
+// concurrent holds other course IDs being enrolled in the same request (they satisfy corequisites).
+func (s *PrerequisiteService) CheckRequirements(studentID, courseID, semesterID uint, concurrent map[uint]bool) ([]string, error) {
+	var requirements []models.CoursePrerequisite
+	if err := s.db.Preload("RequiredCourse").Where("course_id = ?", courseID).
+		Order("group_no, id").Find(&requirements).Error; err != nil {
+		return nil, err
+	}
+	if len(requirements) == 0 {
+		return nil, nil
+	}
+
+	passed, scheme, err := s.bestGrades(studentID, semesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var current []uint
+	if err := s.db.Model(&models.Enrollment{}).
+		Where("student_id = ? AND semester_id = ? AND status = ?", studentID, semesterID, "active").
+		Pluck("course_id", &current).Error; err != nil {
+		return nil, err
+	}
+	taking := make(map[uint]bool, len(current)+len(concurrent))
+	for _, id := range current {
+		taking[id] = true
+	}
+	for id := range concurrent {
+		taking[id] = true
+	}
+
+	var unmet []string
+	for _, group := range groupRequirements(requirements) {
+		satisfied := false
+		for _, requirement := range group {
+			if meetsGrade(scheme, passed[requirement.RequiredCourseID], requirement.MinGrade) ||
+				(requirement.Type == RequirementCorequisite && taking[requirement.RequiredCourseID]) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			unmet = append(unmet, describeGroup(group))
+		}
+	}
+
+	return unmet, nil
+}
+
+// GetPrerequisiteGraph returns the transitive requirements of a course and the courses that require it
+func (s *PrerequisiteService) GetPrerequisiteGraph(course *models.Course) (*PrerequisiteGraph, error) {
+	graph := &PrerequisiteGraph{
+		Course:     course.Code,
+		Nodes:      []PrerequisiteNode{courseNode(course)},
+		Edges:      []PrerequisiteEdge{},
+		Groups:     []RequirementGroup{},
+		RequiredBy: []PrerequisiteNode{},
+	}
+
+	codes := map[uint]string{course.ID: course.Code}
+	queue := []uint{course.ID}
+	for len(queue) > 0 {
+		courseID := queue[0]
+		queue = queue[1:]
+
+		var requirements []models.CoursePrerequisite
+		if err := s.db.Preload("RequiredCourse").Where("course_id = ?", courseID).
+			Order("group_no, id").Find(&requirements).Error; err != nil {
+			return nil, err
+		}
+
+		if courseID == course.ID {
+			for _, group := range groupRequirements(requirements) {
+				graph.Groups = append(graph.Groups, requirementGroup(group))
+			}
+		}
+
+		for _, requirement := range requirements {
+			graph.Edges = append(graph.Edges, PrerequisiteEdge{
+				ID:       requirement.ID,
+				From:     codes[courseID],
+				To:       requirement.RequiredCourse.Code,
+				Type:     requirement.Type,
+				MinGrade: requirement.MinGrade,
+				GroupNo:  requirement.GroupNo,
+			})
+			if _, seen := codes[requirement.RequiredCourseID]; !seen {
+				codes[requirement.RequiredCourseID] = requirement.RequiredCourse.Code
+				graph.Nodes = append(graph.Nodes, courseNode(&requirement.RequiredCourse))
+				queue = append(queue, requirement.RequiredCourseID)
+			}
+		}
+	}
+
+	var dependents []models.Course
+	if err := s.db.
+		Joins("JOIN course_prerequisites ON course_prerequisites.course_id = courses.id AND course_prerequisites.deleted_at IS NULL").
+		Where("course_prerequisites.required_course_id = ?", course.ID).
+		Distinct().Order("courses.code").Find(&dependents).Error; err != nil {
+		return nil, err
+	}
+	for i := range dependents {
+		graph.RequiredBy = append(graph.RequiredBy, courseNode(&dependents[i]))
+	}
+
+	return graph, nil
+}
+
+// upstreamCourseIDs returns every course reachable through prerequisite links from a course
+func (s *PrerequisiteService) upstreamCourseIDs(courseID uint) (map[uint]bool, error) {
+	seen := map[uint]bool{courseID: true}
+	queue := []uint{courseID}
+	for len(queue) > 0 {
+		var next []uint
+		if err := s.db.Model(&models.CoursePrerequisite{}).
+			Where("course_id IN ? AND type = ?", queue, RequirementPrerequisite).
+			Pluck("required_course_id", &next).Error; err != nil {
+			return nil, err
+		}
+		queue = queue[:0]
+		for _, id := range next {
+			if !seen[id] {
+				seen[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return seen, nil
+}
+
+// bestGrades returns the student's best published passing letter grade per course outside the given
+// semester, ranked by the student's grading scheme, along with that scheme
+func (s *PrerequisiteService) bestGrades(studentID, semesterID uint) (map[uint]string, *models.GradingScheme, error) {
+	var grades []models.Grade
+	if err := s.db.
+		Joins("JOIN enrollments ON enrollments.id = grades.enrollment_id").
+		Where("grades.student_id = ? AND enrollments.semester_id <> ? AND grades.letter_grade <> ''", studentID, semesterID).
+		Where("grades.published_at IS NOT NULL").
+		Find(&grades).Error; err != nil {
+		return nil, nil, err
+	}
+
+	scheme, err := s.grading.SchemeForStudent(studentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	best := make(map[uint]string, len(grades))
+	for _, grade := range grades {
+		if !passes(scheme, grade.LetterGrade) {
+			continue // Fails under the student's grading scheme (e.g. a diploma D)
+		}
+		if current, ok := best[grade.CourseID]; !ok || !meetsGrade(scheme, current, grade.LetterGrade) {
+			best[grade.CourseID] = grade.LetterGrade
+		}
+	}
+	return best, scheme, nil
+}
+
+// meetsGrade reports whether a letter grade is a pass at or above the minimum under a scheme.
+// Bands are ranked by their lower bound, which validateBands keeps distinct; a minimum the scheme
+// does not define cannot be met.
+func meetsGrade(scheme *models.GradingScheme, letter, minGrade string) bool {
+	band, ok := bandOf(scheme, letter)
+	if !ok || band.MinMarks < scheme.PassMark {
+		return false
+	}
+	if minGrade == "" {
+		return true
+	}
+	minimum, ok := bandOf(scheme, minGrade)
+	return ok && band.MinMarks >= minimum.MinMarks
+}
+
+// validateMinGrade checks a minimum grade is a letter of every scheme the course is graded under:
+// the schemes of the programs that include it, or the default scheme when none do
+func (s *PrerequisiteService) validateMinGrade(course *models.Course, minGrade string) error {
+	var programs []models.Program
+	if err := s.db.Model(course).Association("Programs").Find(&programs); err != nil {
+		return err
+	}
+	if len(programs) == 0 {
+		programs = []models.Program{{}}
+	}
+
+	checked := map[string]bool{}
+	for i := range programs {
+		scheme, err := s.grading.SchemeForProgram(&programs[i])
+		if err != nil {
+			return err
+		}
+		if checked[scheme.Code] {
+			continue
+		}
+		checked[scheme.Code] = true
+		if _, ok := bandOf(scheme, minGrade); !ok {
+			return fmt.Errorf("min_grade %s is not a grade of the %s grading scheme", minGrade, scheme.Code)
+		}
+	}
+	return nil
+}
+
+// groupRequirements splits requirements into "any of" groups (GroupNo 0 rows stand alone)
+func groupRequirements(requirements []models.CoursePrerequisite) [][]models.CoursePrerequisite {
+	var groups [][]models.CoursePrerequisite
+	index := map[string]int{}
+	for _, requirement := range requirements {
+		if requirement.GroupNo == 0 {
+			groups = append(groups, []models.CoursePrerequisite{requirement})
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", requirement.Type, requirement.GroupNo)
+		if i, ok := index[key]; ok {
+			groups[i] = append(groups[i], requirement)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, []models.CoursePrerequisite{requirement})
+	}
+	return groups
+}
+
+func requirementGroup(group []models.CoursePrerequisite) RequirementGroup {
+	result := RequirementGroup{Type: group[0].Type, MinGrade: group[0].MinGrade}
+	for _, requirement := range group {
+		result.AnyOf = append(result.AnyOf, requirement.RequiredCourse.Code)
+	}
+	sort.Strings(result.AnyOf)
+	return result
+}
+
+// describeGroup renders an unmet requirement group as a human readable reason
+func describeGroup(group []models.CoursePrerequisite) string {
+	var codes []string
+	for _, requirement := range group {
+		code := requirement.RequiredCourse.Code
+		if requirement.MinGrade != "" {
+			code += " (min grade " + requirement.MinGrade + ")"
+		}
+		codes = append(codes, code)
+	}
+
+	courses := codes[0]
+	if len(codes) > 1 {
+		courses = "one of " + strings.Join(codes, ", ")
+	}
+
+	if group[0].Type == RequirementCorequisite {
+		return "corequisite not met: " + courses + " must be passed or taken in the same semester"
+	}
+	return "prerequisite not met: requires a pass in " + courses
+}
+
+func courseNode(course *models.Course) PrerequisiteNode {
+	return PrerequisiteNode{
+		Code:    course.Code,
+		Name:    course.Name,
+		Level:   course.Level,
+		Credits: course.Credits,
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Registration rule names (enable them with REGISTRATION_RULES)
+const (
+	RuleEnrollmentStatus = "enrollment_status"
+	RulePaymentStatus    = "payment_status"
+	RuleDuplicate        = "duplicate"
+	RulePrerequisite     = "prerequisite"
+	RuleTimetableClash   = "timetable_clash"
+	RuleCreditLimit      = "credit_limit"
+	RuleCapacity         = "capacity"
+)
+
+// RuleViolation is one rule failure for a requested course.
+// Non-blocking violations are warnings: the enrollment is still created.
+type RuleViolation struct {
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Blocking bool   `json:"blocking"`
+}
+
+// CreditLimit is the allowed semester credit load for a degree level
+type CreditLimit struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// RegistrationRules validates a student's requested courses for a semester
+type RegistrationRules struct {
+	db                     *gorm.DB
+	cfg                    *config.Config
+	prerequisites          *PrerequisiteService
+	enabled                map[string]bool
+	creditLimits           map[string]CreditLimit
+	enforceMinCredits      bool
+	blockedStatuses        map[string]bool
+	blockedPaymentStatuses map[string]bool
+}
+
+func NewRegistrationRules(db *gorm.DB, cfg *config.Config) *RegistrationRules {
+	return &RegistrationRules{
+		db:                     db,
+		cfg:                    cfg,
+		prerequisites:          NewPrerequisiteService(db, cfg),
+		enabled:                csvSet(cfg.RegistrationRules, false),
+		creditLimits:           parseCreditLimits(cfg.RegistrationCreditLimits),
+		enforceMinCredits:      cfg.RegistrationEnforceMinCredits == "true",
+		blockedStatuses:        csvSet(cfg.RegistrationBlockedStatuses, true),
+		blockedPaymentStatuses: csvSet(cfg.RegistrationBlockedPaymentStatuses, true),
+	}
+}
+
+// Evaluate checks requested courses in order and returns the violations for each one (same index as courses).
+// Courses that pass count towards the load and timetable seen by the courses after them.
+func (r *RegistrationRules) Evaluate(student *models.Student, semesterID uint, courses []models.Course) ([][]RuleViolation, error) {
+	violations := make([][]RuleViolation, len(courses))
+
+	// Student-level rules apply to every requested course
+	var studentViolations []RuleViolation
+	if r.enabled[RuleEnrollmentStatus] && r.blockedStatuses[strings.ToLower(student.EnrollmentStatus)] {
+		studentViolations = append(studentViolations, RuleViolation{
+			Rule:     RuleEnrollmentStatus,
+			Message:  fmt.Sprintf("students with enrollment status %q cannot register", student.EnrollmentStatus),
+			Blocking: true,
+		})
+	}
+	if r.enabled[RulePaymentStatus] && r.blockedPaymentStatuses[strings.ToLower(student.PaymentStatus)] {
+		studentViolations = append(studentViolations, RuleViolation{
+			Rule:     RulePaymentStatus,
+			Message:  fmt.Sprintf("registration is blocked while fees are %s", student.PaymentStatus),
+			Blocking: true,
+		})
+	}
+
+	// Current load and timetable from the student's active enrollments
+	var current []models.Enrollment
+	if err := r.db.Preload("Course").
+		Where("student_id = ? AND semester_id = ? AND status = ?", student.ID, semesterID, "active").
+		Find(&current).Error; err != nil {
+		return nil, err
+	}
+	enrolled := make(map[uint]bool, len(current))
+	load := 0
+	for _, enrollment := range current {
+		enrolled[enrollment.CourseID] = true
+		load += enrollment.Course.Credits
+	}
+
+	var schedule []models.Lecture
+	if r.enabled[RuleTimetableClash] && len(current) > 0 {
+		if err := enrolledLectures(r.db, current).Preload("Course").
+			Where("semester_id = ?", semesterID).
+			Find(&schedule).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var passed map[uint]string
+	if r.enabled[RuleDuplicate] {
+		var err error
+		if passed, _, err = r.prerequisites.bestGrades(student.ID, semesterID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Courses requested together satisfy each other's corequisites
+	concurrent := make(map[uint]bool, len(courses))
+	for _, course := range courses {
+		concurrent[course.ID] = true
+	}
+
+	limit, hasLimit := r.creditLimits[strings.ToLower(student.Program.DegreeLevel)]
+	requested := make(map[uint]bool, len(courses))
+	var accepted []int
+
+	for i, course := range courses {
+		itemViolations := append([]RuleViolation{}, studentViolations...)
+
+		if r.enabled[RuleDuplicate] {
+			switch {
+			case requested[course.ID]:
+				itemViolations = append(itemViolations, blocking(RuleDuplicate, course.Code+" is requested more than once"))
+			case enrolled[course.ID]:
+				itemViolations = append(itemViolations, blocking(RuleDuplicate, "already enrolled in "+course.Code+" this semester"))
+			case passed[course.ID] != "":
+				itemViolations = append(itemViolations, blocking(RuleDuplicate, course.Code+" has already been passed"))
+			}
+		}
+		requested[course.ID] = true
+
+		if r.enabled[RulePrerequisite] {
+			unmet, err := r.prerequisites.CheckRequirements(student.ID, course.ID, semesterID, concurrent)
+			if err != nil {
+				return nil, err
+			}
+			for _, reason := range unmet {
+				itemViolations = append(itemViolations, blocking(RulePrerequisite, reason))
+			}
+		}
+
+		var lectures []models.Lecture
+		if r.enabled[RuleTimetableClash] {
+			if err := r.db.Where("semester_id = ? AND course_id = ?", semesterID, course.ID).
+				Find(&lectures).Error; err != nil {
+				return nil, err
+			}
+			for _, clash := range findClashes(lectures, schedule) {
+				itemViolations = append(itemViolations, blocking(RuleTimetableClash, clash))
+			}
+		}
+
+		// Checking rules must not write, so a course without an offering yet has no seat limit
+		if r.enabled[RuleCapacity] {
+			offering, err := findOffering(r.db, course.ID, semesterID)
+			if err != nil {
+				return nil, err
+			}
+			if offering != nil {
+				seats, err := offeringSeats(r.db, offering)
+				if err != nil {
+					return nil, err
+				}
+				if seats.Full() {
+					itemViolations = append(itemViolations, blocking(RuleCapacity,
+						fmt.Sprintf("%s is full (%d of %d seats taken)", course.Code, seats.Enrolled, *seats.Capacity)))
+				}
+			}
+		}
+
+		if r.enabled[RuleCreditLimit] && hasLimit && load+course.Credits > limit.Max {
+			itemViolations = append(itemViolations, blocking(RuleCreditLimit,
+				fmt.Sprintf("%s would bring the semester load to %d credits (maximum %d for %s)",
+					course.Code, load+course.Credits, limit.Max, student.Program.DegreeLevel)))
+		}
+
+		violations[i] = itemViolations
+		if !hasBlocking(itemViolations) {
+			accepted = append(accepted, i)
+			load += course.Credits
+			for _, lecture := range lectures {
+				lecture.Course = course
+				schedule = append(schedule, lecture)
+			}
+		}
+	}
+
+	// The minimum load can only be judged on the final total
+	if r.enabled[RuleCreditLimit] && hasLimit && len(accepted) > 0 && load < limit.Min {
+		for _, i := range accepted {
+			violations[i] = append(violations[i], RuleViolation{
+				Rule: RuleCreditLimit,
+				Message: fmt.Sprintf("semester load of %d credits is below the minimum of %d for %s",
+					load, limit.Min, student.Program.DegreeLevel),
+				Blocking: r.enforceMinCredits,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// CreditLimits returns the configured credit limits keyed by lower-cased degree level
+func (r *RegistrationRules) CreditLimits() map[string]CreditLimit {
+	return r.creditLimits
+}
+
+// findClashes describes every overlap between a course's lectures and an existing timetable
+func findClashes(lectures, schedule []models.Lecture) []string {
+	var clashes []string
+	for _, lecture := range lectures {
+		for _, other := range schedule {
+			if lecture.DayOfWeek == other.DayOfWeek && lecture.StartTime < other.EndTime && other.StartTime < lecture.EndTime {
+				clashes = append(clashes, fmt.Sprintf("clashes with %s on %s %s-%s",
+					other.Course.Code, other.DayOfWeek, other.StartTime, other.EndTime))
+			}
+		}
+	}
+	return clashes
+}
+
+func blocking(rule, message string) RuleViolation {
+	return RuleViolation{Rule: rule, Message: message, Blocking: true}
+}
+
+// onlyCapacity reports whether every blocking violation is a full offering (the student can be waitlisted)
+func onlyCapacity(violations []RuleViolation) bool {
+	full := false
+	for _, violation := range violations {
+		if violation.Blocking {
+			if violation.Rule != RuleCapacity {
+				return false
+			}
+			full = true
+		}
+	}
+	return full
+}
+
+// hasBlocking reports whether any violation prevents the enrollment
+func hasBlocking(violations []RuleViolation) bool {
+	for _, violation := range violations {
+		if violation.Blocking {
+			return true
+		}
+	}
+	return false
+}
+
+// violationMessages joins violation messages for single-line reasons
+func violationMessages(violations []RuleViolation) string {
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// csvSet parses a comma-separated list into a set
+func csvSet(raw string, lower bool) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if lower {
+			item = strings.ToLower(item)
+		}
+		if item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// parseCreditLimits parses "Bachelor:12-54,Diploma:12-48"; malformed entries are ignored
+func parseCreditLimits(raw string) map[string]CreditLimit {
+	limits := make(map[string]CreditLimit)
+	for _, entry := range strings.Split(raw, ",") {
+		level, bounds, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		minRaw, maxRaw, ok := strings.Cut(bounds, "-")
+		if !ok {
+			continue
+		}
+		min, minErr := strconv.Atoi(strings.TrimSpace(minRaw))
+		max, maxErr := strconv.Atoi(strings.TrimSpace(maxRaw))
+		if minErr != nil || maxErr != nil || max < min {
+			continue
+		}
+		limits[strings.ToLower(strings.TrimSpace(level))] = CreditLimit{Min: min, Max: max}
+	}
+	return limits
+}
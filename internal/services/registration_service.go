@@ -0,0 +1,387 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Registration phases reported for a semester
+const (
+	PhaseClosed       = "closed"
+	PhaseRegistration = "registration"
+	PhaseAddDrop      = "add_drop"
+)
+
+// Per-course registration outcomes
+const (
+	RegistrationRegistered        = "registered"
+	RegistrationAlreadyRegistered = "already_registered"
+	RegistrationWaitlisted        = "waitlisted"
+	RegistrationRejected          = "rejected"
+)
+
+// ErrRegistrationClosed is returned outside the registration and add/drop windows
+var ErrRegistrationClosed = errors.New("registration is closed for the current semester")
+
+type RegistrationService struct {
+	db       *gorm.DB
+	cfg      *config.Config
+	events   *EventPublisher
+	rules    *RegistrationRules
+	waitlist *WaitlistService
+}
+
+func NewRegistrationService(db *gorm.DB, cfg *config.Config) *RegistrationService {
+	return &RegistrationService{
+		db:       db,
+		cfg:      cfg,
+		events:   NewEventPublisher(db, cfg),
+		rules:    NewRegistrationRules(db, cfg),
+		waitlist: NewWaitlistService(db, cfg),
+	}
+}
+
+// RegistrationWindowInput sets a semester's registration and add/drop windows
+type RegistrationWindowInput struct {
+	RegistrationOpensAt  *time.Time `json:"registration_opens_at"`
+	RegistrationClosesAt *time.Time `json:"registration_closes_at"`
+	AddDropOpensAt       *time.Time `json:"add_drop_opens_at"`
+	AddDropClosesAt      *time.Time `json:"add_drop_closes_at"`
+}
+
+// AvailableCourse is a program course with its curriculum placement and the student's registration state
+type AvailableCourse struct {
+	Course        models.Course `json:"course"`
+	YearOfStudy   int           `json:"year_of_study"`
+	SemesterNum   int           `json:"semester_num"`
+	CourseType    string        `json:"course_type"`
+	ElectiveGroup string        `json:"elective_group,omitempty"`
+	Registered    bool          `json:"registered"`
+	Status        string        `json:"status,omitempty"` // Enrollment status when the student has one
+}
+
+// RegistrationResult reports the outcome for one requested course
+type RegistrationResult struct {
+	CourseCode       string          `json:"course_code"`
+	Status           string          `json:"status"`
+	Reason           string          `json:"reason,omitempty"`
+	EnrollmentID     uint            `json:"enrollment_id,omitempty"`
+	WaitlistPosition int             `json:"waitlist_position,omitempty"`
+	Violations       []RuleViolation `json:"violations,omitempty"`
+}
+
+// RegistrationPhase returns which window is open for a semester at the given time
+func RegistrationPhase(semester *models.Semester, at time.Time) string {
+	if windowContains(semester.AddDropOpensAt, semester.AddDropClosesAt, at) {
+		return PhaseAddDrop
+	}
+	if windowContains(semester.RegistrationOpensAt, semester.RegistrationClosesAt, at) {
+		return PhaseRegistration
+	}
+	return PhaseClosed
+}
+
+func windowContains(opensAt, closesAt *time.Time, at time.Time) bool {
+	if opensAt == nil || closesAt == nil {
+		return false
+	}
+	return !at.Before(*opensAt) && !at.After(*closesAt)
+}
+
+// SetRegistrationWindow updates a semester's registration windows
+func (s *RegistrationService) SetRegistrationWindow(semesterID uint, input RegistrationWindowInput) (*models.Semester, error) {
+	var semester models.Semester
+	if err := s.db.First(&semester, semesterID).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	if (input.RegistrationOpensAt == nil) != (input.RegistrationClosesAt == nil) ||
+		(input.AddDropOpensAt == nil) != (input.AddDropClosesAt == nil) {
+		return nil, errors.New("each window needs both an opening and a closing time")
+	}
+	if input.RegistrationOpensAt != nil && !input.RegistrationClosesAt.After(*input.RegistrationOpensAt) {
+		return nil, errors.New("registration window must close after it opens")
+	}
+	if input.AddDropOpensAt != nil && !input.AddDropClosesAt.After(*input.AddDropOpensAt) {
+		return nil, errors.New("add/drop window must close after it opens")
+	}
+
+	semester.RegistrationOpensAt = input.RegistrationOpensAt
+	semester.RegistrationClosesAt = input.RegistrationClosesAt
+	semester.AddDropOpensAt = input.AddDropOpensAt
+	semester.AddDropClosesAt = input.AddDropClosesAt
+
+	if err := s.db.Save(&semester).Error; err != nil {
+		return nil, err
+	}
+
+	return &semester, nil
+}
+
+// GetAvailableCourses lists the courses of the student's program for their year of study and the
+// current semester, plus any other program course they already hold an enrollment in this semester
+func (s *RegistrationService) GetAvailableCourses(studentID uint) (*models.Semester, []AvailableCourse, error) {
+	student, semester, err := s.loadContext(studentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var enrollments []models.Enrollment
+	if err := s.db.Where("student_id = ? AND semester_id = ?", student.ID, semester.ID).
+		Find(&enrollments).Error; err != nil {
+		return nil, nil, err
+	}
+	enrolledCourses := make([]uint, len(enrollments))
+	for i, enrollment := range enrollments {
+		enrolledCourses[i] = enrollment.CourseID
+	}
+
+	query := s.db.Preload("Course").Preload("ElectiveGroup").Where("program_id = ?", student.ProgramID)
+	if len(enrolledCourses) > 0 {
+		query = query.Where("((year_of_study = ? AND semester_num = ?) OR course_id IN ?)",
+			student.YearOfStudy, semester.SemesterNum, enrolledCourses)
+	} else {
+		query = query.Where("year_of_study = ? AND semester_num = ?", student.YearOfStudy, semester.SemesterNum)
+	}
+
+	var entries []models.ProgramCourse
+	if err := query.Order("year_of_study, semester_num, course_type, course_id").Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+	statusByCourse := make(map[uint]string, len(enrollments))
+	for _, enrollment := range enrollments {
+		statusByCourse[enrollment.CourseID] = enrollment.Status
+	}
+
+	available := make([]AvailableCourse, 0, len(entries))
+	for _, entry := range entries {
+		status := statusByCourse[entry.CourseID]
+		course := AvailableCourse{
+			Course:      entry.Course,
+			YearOfStudy: entry.YearOfStudy,
+			SemesterNum: entry.SemesterNum,
+			CourseType:  entry.CourseType,
+			Registered:  status == "active",
+			Status:      status,
+		}
+		if entry.ElectiveGroup != nil {
+			course.ElectiveGroup = entry.ElectiveGroup.Name
+		}
+		available = append(available, course)
+	}
+
+	return semester, available, nil
+}
+
+// Register enrolls the student in the given courses for the current semester.
+// Each course is checked by the registration rules and gets its own result; full courses waitlist the student.
+func (s *RegistrationService) Register(studentID uint, courseCodes []string) ([]RegistrationResult, error) {
+	student, semester, err := s.loadContext(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWindow(semester); err != nil {
+		return nil, err
+	}
+
+	codes := normalizeCourseCodes(courseCodes)
+	results := make([]RegistrationResult, len(codes))
+	existing := make(map[int]*models.Enrollment)
+	var courses []models.Course
+	var indexes []int
+
+	for i, code := range codes {
+		results[i] = RegistrationResult{CourseCode: code, Status: RegistrationRejected}
+
+		var course models.Course
+		if err := s.db.Where("code = ?", code).First(&course).Error; err != nil {
+			results[i].Reason = "course not found"
+			continue
+		}
+
+		var offered int64
+		s.db.Model(&models.ProgramCourse{}).
+			Where("program_id = ? AND course_id = ?", student.ProgramID, course.ID).
+			Count(&offered)
+		if offered == 0 {
+			results[i].Reason = "course is not offered to your program"
+			continue
+		}
+
+		var enrollment models.Enrollment
+		if err := s.db.Where("student_id = ? AND course_id = ? AND semester_id = ?", student.ID, course.ID, semester.ID).
+			First(&enrollment).Error; err == nil {
+			if enrollment.Status == "active" {
+				results[i].Status = RegistrationAlreadyRegistered
+				results[i].EnrollmentID = enrollment.ID
+				continue
+			}
+			existing[i] = &enrollment
+		}
+
+		courses = append(courses, course)
+		indexes = append(indexes, i)
+	}
+
+	violations, err := s.rules.Evaluate(student, semester.ID, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range indexes {
+		results[i].Violations = violations[j]
+		if hasBlocking(violations[j]) {
+			results[i].Reason = violationMessages(violations[j])
+
+			// A full course is the only obstacle: queue the student for the next seat
+			if onlyCapacity(violations[j]) {
+				if _, position, err := s.waitlist.Join(student.ID, courses[j].ID, semester.ID); err == nil {
+					results[i].Status = RegistrationWaitlisted
+					results[i].WaitlistPosition = position
+				}
+			}
+			continue
+		}
+
+		enrollment, err := s.enroll(student, semester, &courses[j], existing[i])
+		if errors.Is(err, ErrSectionsFull) || errors.Is(err, ErrCourseFull) {
+			// The last seat went to a concurrent registration, or the seats left are in sections the
+			// student may not join
+			results[i].Reason = courses[j].Code + ": " + err.Error()
+			if _, position, err := s.waitlist.Join(student.ID, courses[j].ID, semester.ID); err == nil {
+				results[i].Status = RegistrationWaitlisted
+				results[i].WaitlistPosition = position
+			}
+			continue
+		}
+		if err != nil {
+			results[i].Reason = err.Error()
+			continue
+		}
+		results[i].Status = RegistrationRegistered
+		results[i].EnrollmentID = enrollment.ID
+	}
+
+	return results, nil
+}
+
+// enroll creates an enrollment, or reactivates a previously dropped one
+func (s *RegistrationService) enroll(student *models.Student, semester *models.Semester, course *models.Course, enrollment *models.Enrollment) (*models.Enrollment, error) {
+	event := EventEnrollmentUpdated
+	if enrollment != nil {
+		enrollment.Status = "active"
+		enrollment.EnrolledAt = time.Now()
+	} else {
+		event = EventEnrollmentCreated
+		enrollment = &models.Enrollment{
+			StudentID:  student.ID,
+			CourseID:   course.ID,
+			SemesterID: semester.ID,
+			Status:     "active",
+			EnrolledAt: time.Now(),
+		}
+	}
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if s.rules.enabled[RuleCapacity] {
+			if err := reserveSeat(tx, course.ID, semester.ID); err != nil {
+				return err
+			}
+		}
+		if err := placeEnrollment(tx, enrollment, student.ProgramID); err != nil {
+			return err
+		}
+		if err := tx.Save(enrollment).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(event, enrollmentEventData(enrollment))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// Drop marks the student's enrollment in a course as dropped, publishes enrollment.updated and promotes the waitlist
+func (s *RegistrationService) Drop(studentID uint, courseCode string) (*models.Enrollment, error) {
+	student, semester, err := s.loadContext(studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWindow(semester); err != nil {
+		return nil, err
+	}
+
+	var enrollment models.Enrollment
+	if err := s.db.
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Where("enrollments.student_id = ? AND enrollments.semester_id = ? AND courses.code = ?",
+			student.ID, semester.ID, strings.ToUpper(courseCode)).
+		First(&enrollment).Error; err != nil {
+		return nil, errors.New("you are not registered for this course")
+	}
+	if enrollment.Status != "active" {
+		return nil, errors.New("only active enrollments can be dropped")
+	}
+
+	enrollment.Status = "dropped"
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Model(&enrollment).Update("status", enrollment.Status).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventEnrollmentUpdated, enrollmentEventData(&enrollment))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The freed seat goes to the head of the waitlist; the drop stands even if the promotion fails
+	if _, err := s.waitlist.FillOpenSeats(enrollment.CourseID, enrollment.SemesterID); err != nil {
+		log.Printf("waitlist promotion for course %d in semester %d failed: %v", enrollment.CourseID, enrollment.SemesterID, err)
+	}
+
+	return &enrollment, nil
+}
+
+// normalizeCourseCodes upper-cases, trims and de-duplicates requested course codes
+func normalizeCourseCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized
+}
+
+// loadContext loads the student and the current semester
+func (s *RegistrationService) loadContext(studentID uint) (*models.Student, *models.Semester, error) {
+	var student models.Student
+	if err := s.db.Preload("Program").First(&student, studentID).Error; err != nil {
+		return nil, nil, errors.New("student not found")
+	}
+
+	var semester models.Semester
+	if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+		return nil, nil, errors.New("no current semester")
+	}
+
+	return &student, &semester, nil
+}
+
+func (s *RegistrationService) checkWindow(semester *models.Semester) error {
+	if RegistrationPhase(semester, time.Now()) == PhaseClosed {
+		return ErrRegistrationClosed
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Academic standings
+const (
+	StandingPass          = "pass"
+	StandingSupplementary = "supplementary" // Passed overall but must resit failed courses
+	StandingProbation     = "probation"
+	StandingDiscontinued  = "discontinued"
+)
+
+// DegreeClass is a classification awarded from a minimum CGPA
+type DegreeClass struct {
+	Name    string  `json:"name"`
+	MinCGPA float64 `json:"min_cgpa"`
+}
+
+// ResultsService computes semester and cumulative GPAs and classifies academic standing
+type ResultsService struct {
+	db             *gorm.DB
+	cfg            *config.Config
+	grading        *GradingService
+	minCGPA        float64
+	discontinueGPA float64
+	maxProbations  int
+	degreeClasses  []DegreeClass
+}
+
+func NewResultsService(db *gorm.DB, cfg *config.Config) *ResultsService {
+	minCGPA, _ := strconv.ParseFloat(cfg.ResultsMinCGPA, 64)
+	discontinueGPA, _ := strconv.ParseFloat(cfg.ResultsDiscontinueCGPA, 64)
+	maxProbations, _ := strconv.Atoi(cfg.ResultsMaxProbations)
+
+	return &ResultsService{
+		db:             db,
+		cfg:            cfg,
+		grading:        NewGradingService(db, cfg),
+		minCGPA:        minCGPA,
+		discontinueGPA: discontinueGPA,
+		maxProbations:  maxProbations,
+		degreeClasses:  parseDegreeClasses(cfg.ResultsDegreeClasses),
+	}
+}
+
+// withDB returns a copy of the service bound to db, e.g. a transaction
+func (s *ResultsService) withDB(db *gorm.DB) *ResultsService {
+	bound := *s
+	bound.db = db
+	return &bound
+}
+
+// AcademicRecord is a student's semester-by-semester results history
+type AcademicRecord struct {
+	StudentID     uint                    `json:"student_id"`
+	RegNumber     string                  `json:"reg_number"`
+	Program       string                  `json:"program"`
+	DegreeLevel   string                  `json:"degree_level"`
+	CGPA          float64                 `json:"cgpa"`
+	CreditsEarned int                     `json:"credits_earned"`
+	Standing      string                  `json:"standing"`
+	DegreeClass   string                  `json:"degree_class"`
+	Semesters     []models.SemesterResult `json:"semesters"`
+}
+
+// gradedCourse is one graded enrollment as used by the GPA calculation
+type gradedCourse struct {
+	SemesterID  uint
+	CourseID    uint
+	Credits     int
+	LetterGrade string
+	GradePoint  float64
+}
+
+// ComputeStudent recomputes every semester result of a student from published grades, stores the
+// history and keeps Student.GPA in step with the latest CGPA
+func (s *ResultsService) ComputeStudent(studentID uint) ([]models.SemesterResult, error) {
+	var graded []gradedCourse
+	if err := s.db.Table("grades").
+		Joins("JOIN enrollments ON enrollments.id = grades.enrollment_id").
+		Joins("JOIN semesters ON semesters.id = enrollments.semester_id").
+		Joins("JOIN courses ON courses.id = grades.course_id").
+		Where("grades.student_id = ? AND grades.deleted_at IS NULL AND grades.letter_grade <> ''", studentID).
+		Where("grades.published_at IS NOT NULL").                     // Provisional grades wait for publication
+		Where("COALESCE(grades.outcome, '') <> ?", MarkerIncomplete). // Incomplete grades wait to be resolved
+		Order("semesters.start_date, semesters.id, grades.id").
+		Select("enrollments.semester_id, grades.course_id, courses.credits, grades.letter_grade, grades.grade_point").
+		Scan(&graded).Error; err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.grading.SchemeForStudent(studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var results []models.SemesterResult
+	best := make(map[uint]gradedCourse) // Best attempt per course so far (retakes replace earlier grades)
+	probations := 0
+
+	for start := 0; start < len(graded); {
+		end := start
+		for end < len(graded) && graded[end].SemesterID == graded[start].SemesterID {
+			end++
+		}
+
+		result := models.SemesterResult{
+			StudentID:  studentID,
+			SemesterID: graded[start].SemesterID,
+			ComputedAt: now,
+		}
+		for _, course := range graded[start:end] {
+			result.CreditsAttempted += course.Credits
+			result.QualityPoints += course.GradePoint * float64(course.Credits)
+			if passes(scheme, course.LetterGrade) {
+				result.CreditsEarned += course.Credits
+			} else {
+				result.FailedCourses++
+			}
+			if previous, ok := best[course.CourseID]; !ok || course.GradePoint >= previous.GradePoint {
+				best[course.CourseID] = course
+			}
+		}
+		start = end
+
+		for _, course := range best {
+			result.CumulativeCredits += course.Credits
+			result.CumulativePoints += course.GradePoint * float64(course.Credits)
+			if passes(scheme, course.LetterGrade) {
+				result.CumulativeEarned += course.Credits
+			}
+		}
+		result.GPA = gpa(result.QualityPoints, result.CreditsAttempted)
+		result.CGPA = gpa(result.CumulativePoints, result.CumulativeCredits)
+		result.QualityPoints = round2(result.QualityPoints)
+		result.CumulativePoints = round2(result.CumulativePoints)
+
+		result.Standing = s.standing(scheme, result.CGPA, result.FailedCourses, &probations)
+		result.DegreeClass = s.DegreeClassFor(scheme, result.CGPA)
+		results = append(results, result)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		semesterIDs := make([]uint, 0, len(results))
+		for i := range results {
+			semesterIDs = append(semesterIDs, results[i].SemesterID)
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "student_id"}, {Name: "semester_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"credits_attempted", "credits_earned", "quality_points", "gpa",
+					"cumulative_credits", "cumulative_earned", "cumulative_points", "cgpa", "failed_courses",
+					"standing", "degree_class", "computed_at", "updated_at", "deleted_at",
+				}),
+			}).Create(&results[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		// Semesters whose grades were all removed no longer have a result
+		stale := tx.Unscoped().Where("student_id = ?", studentID)
+		if len(semesterIDs) > 0 {
+			stale = stale.Where("semester_id NOT IN ?", semesterIDs)
+		}
+		if err := stale.Delete(&models.SemesterResult{}).Error; err != nil {
+			return err
+		}
+
+		cgpa := 0.0
+		if len(results) > 0 {
+			cgpa = results[len(results)-1].CGPA
+		}
+		return tx.Model(&models.Student{}).Where("id = ?", studentID).Update("gpa", cgpa).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ComputeSemester recomputes the results of every student graded in a semester and returns how many were updated
+func (s *ResultsService) ComputeSemester(semesterID uint) (int, error) {
+	var semester models.Semester
+	if err := s.db.First(&semester, semesterID).Error; err != nil {
+		return 0, errors.New("semester not found")
+	}
+
+	var studentIDs []uint
+	if err := s.db.Model(&models.Grade{}).
+		Joins("JOIN enrollments ON enrollments.id = grades.enrollment_id").
+		Where("enrollments.semester_id = ?", semesterID).
+		Distinct().Pluck("grades.student_id", &studentIDs).Error; err != nil {
+		return 0, err
+	}
+
+	for _, studentID := range studentIDs {
+		if _, err := s.ComputeStudent(studentID); err != nil {
+			return 0, err
+		}
+	}
+	return len(studentIDs), nil
+}
+
+// GetAcademicRecord returns a student's stored results history, oldest semester first
+func (s *ResultsService) GetAcademicRecord(studentID uint) (*AcademicRecord, error) {
+	var student models.Student
+	if err := s.db.Preload("Program").First(&student, studentID).Error; err != nil {
+		return nil, errors.New("student not found")
+	}
+
+	var results []models.SemesterResult
+	if err := s.db.Preload("Semester").
+		Joins("JOIN semesters ON semesters.id = semester_results.semester_id").
+		Where("semester_results.student_id = ?", studentID).
+		Order("semesters.start_date, semesters.id").
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	record := &AcademicRecord{
+		StudentID:   student.ID,
+		RegNumber:   student.RegNumber,
+		Program:     student.Program.Name,
+		DegreeLevel: student.Program.DegreeLevel,
+		Semesters:   results,
+	}
+	if len(results) > 0 {
+		latest := results[len(results)-1]
+		record.CGPA = latest.CGPA
+		record.Standing = latest.Standing
+		record.DegreeClass = latest.DegreeClass
+		record.CreditsEarned = latest.CumulativeEarned // A retaken course counts once
+	}
+	return record, nil
+}
+
+// DegreeClassFor classifies a CGPA on a grading scheme's scale; below its lowest class it has no classification
+func (s *ResultsService) DegreeClassFor(scheme *models.GradingScheme, cgpa float64) string {
+	for _, class := range s.DegreeClasses(scheme) {
+		if cgpa >= class.MinCGPA {
+			return class.Name
+		}
+	}
+	return ""
+}
+
+// DegreeClasses returns a grading scheme's classifications, highest first, falling back to
+// RESULTS_DEGREE_CLASSES when the scheme defines none
+func (s *ResultsService) DegreeClasses(scheme *models.GradingScheme) []DegreeClass {
+	if classes := parseDegreeClasses(scheme.DegreeClasses); len(classes) > 0 {
+		return classes
+	}
+	return s.degreeClasses
+}
+
+// standing classifies one semester against the scheme's CGPA thresholds. probations counts
+// consecutive probation semesters and is updated for the next semester.
+func (s *ResultsService) standing(scheme *models.GradingScheme, cgpa float64, failed int, probations *int) string {
+	minCGPA, discontinueGPA := scheme.MinCGPA, scheme.DiscontinueCGPA
+	if minCGPA == 0 {
+		minCGPA = s.minCGPA
+	}
+	if discontinueGPA == 0 {
+		discontinueGPA = s.discontinueGPA
+	}
+
+	switch {
+	case cgpa < discontinueGPA:
+		*probations = 0
+		return StandingDiscontinued
+	case cgpa < minCGPA:
+		*probations++
+		if *probations > s.maxProbations {
+			return StandingDiscontinued
+		}
+		return StandingProbation
+	}
+
+	*probations = 0
+	if failed > 0 {
+		return StandingSupplementary
+	}
+	return StandingPass
+}
+
+// gpa divides quality points by credits, rounded to two places
+func gpa(points float64, credits int) float64 {
+	if credits == 0 {
+		return 0
+	}
+	return round2(points / float64(credits))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// parseDegreeClasses parses "First Class:4.4,Upper Second Class:3.5"; malformed entries are ignored
+func parseDegreeClasses(raw string) []DegreeClass {
+	var classes []DegreeClass
+	for _, entry := range strings.Split(raw, ",") {
+		name, minRaw, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		min, err := strconv.ParseFloat(strings.TrimSpace(minRaw), 64)
+		if err != nil || strings.TrimSpace(name) == "" {
+			continue
+		}
+		classes = append(classes, DegreeClass{Name: strings.TrimSpace(name), MinCGPA: min})
+	}
+
+	// Highest threshold first so the first match wins
+	sort.SliceStable(classes, func(i, j int) bool { return classes[i].MinCGPA > classes[j].MinCGPA })
+	return classes
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Results workflow statuses, in order
+const (
+	ResultsDraft        = "draft"
+	ResultsSubmitted    = "submitted"
+	ResultsHODApproved  = "hod_approved"
+	ResultsDeanApproved = "dean_approved"
+	ResultsPublished    = "published"
+)
+
+// Results workflow actions
+const (
+	WorkflowSubmit  = "submit"
+	WorkflowApprove = "approve"
+	WorkflowReject  = "reject"
+	WorkflowPublish = "publish"
+)
+
+// Roles recorded against workflow actions. Senate acts through admin accounts.
+const (
+	RoleLecturer = "lecturer"
+	RoleHOD      = "hod"
+	RoleDean     = "dean"
+	RoleSenate   = "senate"
+)
+
+// workflowApprovers is the role expected to approve (or reject) results at each status
+var workflowApprovers = map[string]string{
+	ResultsSubmitted:    RoleHOD,
+	ResultsHODApproved:  RoleDean,
+	ResultsDeanApproved: RoleSenate,
+}
+
+type ResultsWorkflowService struct {
+	db      *gorm.DB
+	cfg     *config.Config
+	events  *EventPublisher
+	results *ResultsService
+}
+
+func NewResultsWorkflowService(db *gorm.DB, cfg *config.Config) *ResultsWorkflowService {
+	return &ResultsWorkflowService{
+		db:      db,
+		cfg:     cfg,
+		events:  NewEventPublisher(db, cfg),
+		results: NewResultsService(db, cfg),
+	}
+}
+
+// Get returns an offering's results workflow with its action history, starting a draft on first use
+func (s *ResultsWorkflowService) Get(offeringID uint) (*models.ResultsWorkflow, error) {
+	var offering models.CourseOffering
+	if err := s.db.First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	workflow, err := resultsWorkflow(s.db, offeringID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("workflow_id = ?", workflow.ID).Order("created_at, id").Find(&workflow.Actions).Error; err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// Transition applies an action to an offering's results:
+// submit (lecturer, draft → submitted), approve (HOD, submitted → hod_approved; Dean, hod_approved → dean_approved),
+// publish (Senate, dean_approved → published) and reject (the current approver, back to draft with a comment).
+// Admins may take any step.
+func (s *ResultsWorkflowService) Transition(offeringID uint, action string, actor Actor, comment string) (*models.ResultsWorkflow, error) {
+	var offering models.CourseOffering
+	if err := s.db.Preload("Course.Department").First(&offering, offeringID).Error; err != nil {
+		return nil, errors.New("offering not found")
+	}
+	workflow, err := resultsWorkflow(s.db, offeringID)
+	if err != nil {
+		return nil, err
+	}
+	workflow.Offering = offering
+
+	action = strings.ToLower(strings.TrimSpace(action))
+	comment = strings.TrimSpace(comment)
+	from := workflow.Status
+	now := time.Now()
+
+	var to, required string
+	switch action {
+	case WorkflowSubmit:
+		if from != ResultsDraft {
+			return nil, fmt.Errorf("results are %s; only draft results can be submitted", from)
+		}
+		to, required = ResultsSubmitted, RoleLecturer
+	case WorkflowApprove:
+		switch from {
+		case ResultsSubmitted:
+			to = ResultsHODApproved
+		case ResultsHODApproved:
+			to = ResultsDeanApproved
+		default:
+			return nil, fmt.Errorf("results are %s and cannot be approved", from)
+		}
+		required = workflowApprovers[from]
+	case WorkflowPublish:
+		if from != ResultsDeanApproved {
+			return nil, fmt.Errorf("results are %s; only Dean approved results can be published", from)
+		}
+		to, required = ResultsPublished, RoleSenate
+	case WorkflowReject:
+		if workflowApprovers[from] == "" {
+			return nil, fmt.Errorf("results are %s and cannot be rejected", from)
+		}
+		if comment == "" {
+			return nil, errors.New("a comment explaining the rejection is required")
+		}
+		to, required = ResultsDraft, workflowApprovers[from]
+	default:
+		return nil, fmt.Errorf("unknown action %q; use submit, approve, reject or publish", action)
+	}
+
+	role, err := offeringRole(s.db, &offering, actor, required)
+	if err != nil {
+		return nil, err
+	}
+	if action == WorkflowSubmit {
+		if err := s.checkComplete(offeringID); err != nil {
+			return nil, err
+		}
+	}
+
+	published := 0
+	var publishedGrades []models.Grade
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		// Guard against a concurrent transition from the same status
+		updates := map[string]interface{}{"status": to}
+		switch to {
+		case ResultsSubmitted:
+			updates["submitted_at"] = now
+		case ResultsHODApproved:
+			updates["hod_approved_at"] = now
+		case ResultsDeanApproved:
+			updates["dean_approved_at"] = now
+		case ResultsPublished:
+			updates["published_at"] = now
+		case ResultsDraft:
+			updates["submitted_at"] = nil
+			updates["hod_approved_at"] = nil
+			updates["dean_approved_at"] = nil
+		}
+		result := tx.Model(&models.ResultsWorkflow{}).Where("id = ? AND status = ?", workflow.ID, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("the results workflow changed; reload and try again")
+		}
+
+		if err := tx.Create(&models.ResultsWorkflowAction{
+			WorkflowID: workflow.ID,
+			Action:     action,
+			FromStatus: from,
+			ToStatus:   to,
+			UserID:     actor.UserID,
+			Role:       role,
+			Comment:    comment,
+		}).Error; err != nil {
+			return err
+		}
+
+		if to == ResultsPublished {
+			var grades []models.Grade
+			if err := tx.Where("offering_id = ? AND letter_grade <> '' AND published_at IS NULL", offeringID).
+				Find(&grades).Error; err != nil {
+				return err
+			}
+			for i := range grades {
+				before := grades[i]
+				grades[i].PublishedAt = &now
+				if err := tx.Model(&grades[i]).Update("published_at", now).Error; err != nil {
+					return err
+				}
+				if err := recordGradeHistory(tx, &before, &grades[i], actor, nil); err != nil {
+					return err
+				}
+			}
+			published = len(grades)
+
+			// Recompute the students' results in the same transaction, so grades are never
+			// published without them
+			if err := tx.Where("offering_id = ? AND published_at IS NOT NULL", offeringID).Order("id").
+				Find(&publishedGrades).Error; err != nil {
+				return err
+			}
+			results := s.results.withDB(tx)
+			computed := make(map[uint]bool)
+			for _, grade := range publishedGrades {
+				if computed[grade.StudentID] {
+					continue
+				}
+				if _, err := results.ComputeStudent(grade.StudentID); err != nil {
+					return err
+				}
+				computed[grade.StudentID] = true
+			}
+
+			// The LMS is notified once everything is committed
+			for i := range publishedGrades {
+				if err := outbox.Publish(EventGradeSubmitted, gradeEventData(&publishedGrades[i])); err != nil {
+					return err
+				}
+			}
+			workflow.Status = to
+			workflow.PublishedAt = &now
+			return outbox.Publish(EventResultsPublished, resultsPublishedEventData(workflow, published))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(offeringID)
+}
+
+// offeringRole checks the actor may act in the required role on an offering (whose Course.Department
+// is loaded) and returns the role to record. Admins may act in any role.
+func offeringRole(db *gorm.DB, offering *models.CourseOffering, actor Actor, required string) (string, error) {
+	if actor.UserType == "admin" {
+		if required == RoleSenate {
+			return RoleSenate, nil
+		}
+		return "admin", nil
+	}
+	if actor.UserType != "faculty" || required == RoleSenate {
+		return "", fmt.Errorf("this step needs the %s", roleName(required))
+	}
+
+	var faculty models.Faculty
+	if err := db.Where("user_id = ?", actor.UserID).First(&faculty).Error; err != nil {
+		return "", errors.New("faculty member not found")
+	}
+
+	allowed := false
+	switch required {
+	case RoleLecturer:
+		var count int64
+		db.Model(&models.CourseAssignment{}).
+			Where("faculty_id = ? AND course_id = ? AND semester_id = ?", faculty.ID, offering.CourseID, offering.SemesterID).
+			Count(&count)
+		allowed = count > 0
+	case RoleHOD:
+		department := offering.Course.Department
+		allowed = department.HeadFacultyID != nil && *department.HeadFacultyID == faculty.ID
+	case RoleDean:
+		var college models.College
+		if err := db.First(&college, offering.Course.Department.CollegeID).Error; err == nil {
+			allowed = college.DeanFacultyID != nil && *college.DeanFacultyID == faculty.ID
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("this step needs the %s", roleName(required))
+	}
+	return required, nil
+}
+
+// checkComplete refuses submission while an active student in the offering has no grade or outcome
+func (s *ResultsWorkflowService) checkComplete(offeringID uint) error {
+	var missing int64
+	if err := s.db.Model(&models.Enrollment{}).
+		Where("offering_id = ? AND status <> ?", offeringID, "dropped").
+		Where("id NOT IN (?)", s.db.Model(&models.Grade{}).Select("enrollment_id").Where("letter_grade <> ''")).
+		Count(&missing).Error; err != nil {
+		return err
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d enrolled students have no grade yet", missing)
+	}
+	return nil
+}
+
+// resultsWorkflow returns an offering's workflow, creating a draft one when it has none
+func resultsWorkflow(db *gorm.DB, offeringID uint) (*models.ResultsWorkflow, error) {
+	workflow := models.ResultsWorkflow{OfferingID: offeringID}
+	if err := db.Where(models.ResultsWorkflow{OfferingID: offeringID}).
+		Attrs(models.ResultsWorkflow{Status: ResultsDraft}).
+		FirstOrCreate(&workflow).Error; err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// resultsEditable refuses changes to marks once an offering's results have left draft
+func resultsEditable(db *gorm.DB, offeringID uint) error {
+	workflow, err := resultsWorkflow(db, offeringID)
+	if err != nil {
+		return err
+	}
+	if workflow.Status != ResultsDraft {
+		return fmt.Errorf("results for this offering are %s and can no longer be edited", strings.ReplaceAll(workflow.Status, "_", " "))
+	}
+	return nil
+}
+
+func roleName(role string) string {
+	switch role {
+	case RoleLecturer:
+		return "course lecturer"
+	case RoleHOD:
+		return "head of department"
+	case RoleDean:
+		return "dean of the college"
+	}
+	return "Senate (admin)"
+}
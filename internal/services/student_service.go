@@ -2,24 +2,56 @@ package services
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
+	"github.com/mwombeki6/mock-sims/internal/utils"
 	"gorm.io/gorm"
 )
 
 type StudentService struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db     *gorm.DB
+	cfg    *config.Config
+	events *EventPublisher
 }
 
 func NewStudentService(db *gorm.DB, cfg *config.Config) *StudentService {
 	return &StudentService{
-		db:  db,
-		cfg: cfg,
+		db:     db,
+		cfg:    cfg,
+		events: NewEventPublisher(db, cfg),
 	}
 }
 
+// StudentEnrollmentStatuses lists the accepted values for Student.EnrollmentStatus
+var StudentEnrollmentStatuses = []string{"active", "probation", "suspended", "graduated", "discontinued"}
+
+// CreateStudentInput carries the fields required to register a new student
+type CreateStudentInput struct {
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	RegNumber     string `json:"reg_number"`
+	FirstName     string `json:"first_name"`
+	MiddleName    string `json:"middle_name"`
+	LastName      string `json:"last_name"`
+	ProgramCode   string `json:"program_code"`
+	YearOfStudy   int    `json:"year_of_study"`
+	AdmissionYear int    `json:"admission_year"`
+	PaymentStatus string `json:"payment_status"`
+}
+
+// UpdateStudentInput carries optional profile changes (nil fields are left untouched)
+type UpdateStudentInput struct {
+	FirstName     *string `json:"first_name"`
+	MiddleName    *string `json:"middle_name"`
+	LastName      *string `json:"last_name"`
+	ProgramCode   *string `json:"program_code"`
+	YearOfStudy   *int    `json:"year_of_study"`
+	PaymentStatus *string `json:"payment_status"`
+}
+
 // GetStudentByUserID retrieves student profile by user ID
 func (s *StudentService) GetStudentByUserID(userID uint) (*models.Student, error) {
 	var student models.Student
@@ -80,14 +112,14 @@ func (s *StudentService) GetStudentCourses(studentID uint) ([]models.Enrollment,
 	return enrollments, nil
 }
 
-// GetStudentGrades retrieves all grades for a student
+// GetStudentGrades retrieves a student's published grades
 func (s *StudentService) GetStudentGrades(studentID uint) ([]models.Grade, error) {
 	var grades []models.Grade
 	err := s.db.
 		Preload("Course").
 		Preload("Enrollment").
 		Preload("Enrollment.Semester").
-		Where("student_id = ?", studentID).
+		Where("student_id = ? AND published_at IS NOT NULL", studentID).
 		Order("id DESC").
 		Find(&grades).Error
 
@@ -105,7 +137,7 @@ func (s *StudentService) GetStudentTimetable(studentID uint) ([]models.Lecture,
 	err := s.db.
 		Joins("JOIN semesters ON semesters.id = enrollments.semester_id").
 		Where("enrollments.student_id = ? AND semesters.is_current = ?", studentID, true).
-		Select("enrollments.course_id").
+		Select("enrollments.course_id, enrollments.section_id").
 		Find(&enrollments).Error
 
 	if err != nil {
@@ -116,20 +148,14 @@ func (s *StudentService) GetStudentTimetable(studentID uint) ([]models.Lecture,
 		return []models.Lecture{}, nil
 	}
 
-	// Extract course IDs
-	courseIDs := make([]uint, len(enrollments))
-	for i, enrollment := range enrollments {
-		courseIDs[i] = enrollment.CourseID
-	}
-
-	// Get lectures for these courses
+	// Get lectures of the student's sections
 	var lectures []models.Lecture
-	err = s.db.
+	err = enrolledLectures(s.db, enrollments).
 		Preload("Course").
 		Preload("Faculty").
 		Preload("Venue").
 		Preload("Semester").
-		Where("course_id IN ? AND semester_id IN (SELECT id FROM semesters WHERE is_current = ?)", courseIDs, true).
+		Where("semester_id IN (SELECT id FROM semesters WHERE is_current = ?)", true).
 		Order("day_of_week, start_time").
 		Find(&lectures).Error
 
@@ -140,38 +166,170 @@ func (s *StudentService) GetStudentTimetable(studentID uint) ([]models.Lecture,
 	return lectures, nil
 }
 
-// CalculateGPA calculates student's cumulative GPA
-func (s *StudentService) CalculateGPA(studentID uint) (float64, error) {
-	// Get grades with course information
-	var results []struct {
-		GradePoint float64
-		Credits    int
+// CreateStudent creates the user account and student record, then publishes student.created. Without
+// a password a random one is generated and returned, the only time it is available in clear text.
+func (s *StudentService) CreateStudent(input CreateStudentInput) (*models.Student, string, error) {
+	if input.Email == "" || input.RegNumber == "" || input.FirstName == "" || input.LastName == "" || input.ProgramCode == "" {
+		return nil, "", errors.New("email, reg_number, first_name, last_name and program_code are required")
+	}
+	if input.YearOfStudy < 1 {
+		input.YearOfStudy = 1
+	}
+	if input.PaymentStatus == "" {
+		input.PaymentStatus = "pending"
+	}
+	generated := ""
+	if input.Password == "" {
+		password, err := utils.GenerateRandomString(16)
+		if err != nil {
+			return nil, "", err
+		}
+		input.Password, generated = password, password
+	}
+
+	var program models.Program
+	if err := s.db.Where("code = ?", input.ProgramCode).First(&program).Error; err != nil {
+		return nil, "", errors.New("program not found")
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var student models.Student
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		user := models.User{
+			Email:    strings.ToLower(input.Email),
+			Password: hashedPassword,
+			UserType: "student",
+			IsActive: true,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		student = models.Student{
+			UserID:           user.ID,
+			RegNumber:        input.RegNumber,
+			FirstName:        input.FirstName,
+			MiddleName:       input.MiddleName,
+			LastName:         input.LastName,
+			ProgramID:        program.ID,
+			YearOfStudy:      input.YearOfStudy,
+			EnrollmentStatus: "active",
+			PaymentStatus:    input.PaymentStatus,
+			AdmissionYear:    input.AdmissionYear,
+		}
+		if err := tx.Create(&student).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventStudentCreated, studentEventData(&student))
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &student, generated, nil
+}
+
+// UpdateStudent applies profile changes and publishes student.updated
+func (s *StudentService) UpdateStudent(studentID uint, input UpdateStudentInput) (*models.Student, error) {
+	var student models.Student
+	if err := s.db.First(&student, studentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("student not found")
+		}
+		return nil, err
 	}
 
-	err := s.db.Table("grades").
-		Joins("JOIN enrollments ON enrollments.id = grades.enrollment_id").
-		Joins("JOIN courses ON courses.id = enrollments.course_id").
-		Where("grades.student_id = ? AND grades.letter_grade IS NOT NULL AND grades.letter_grade != ''", studentID).
-		Select("grades.grade_point, courses.credits").
-		Find(&results).Error
+	if input.FirstName != nil {
+		student.FirstName = *input.FirstName
+	}
+	if input.MiddleName != nil {
+		student.MiddleName = *input.MiddleName
+	}
+	if input.LastName != nil {
+		student.LastName = *input.LastName
+	}
+	if input.YearOfStudy != nil {
+		student.YearOfStudy = *input.YearOfStudy
+	}
+	if input.PaymentStatus != nil {
+		student.PaymentStatus = *input.PaymentStatus
+	}
+	if input.ProgramCode != nil {
+		var program models.Program
+		if err := s.db.Where("code = ?", *input.ProgramCode).First(&program).Error; err != nil {
+			return nil, errors.New("program not found")
+		}
+		student.ProgramID = program.ID
+	}
 
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Save(&student).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventStudentUpdated, studentEventData(&student))
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if len(results) == 0 {
-		return 0, nil
+	return &student, nil
+}
+
+// UpdateStudentStatus changes a student's enrollment status and publishes student.status_changed
+func (s *StudentService) UpdateStudentStatus(studentID uint, status, reason string) (*models.Student, error) {
+	valid := false
+	for _, allowed := range StudentEnrollmentStatuses {
+		if status == allowed {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, errors.New("invalid enrollment status")
 	}
 
-	var totalPoints, totalCredits float64
-	for _, result := range results {
-		totalPoints += result.GradePoint * float64(result.Credits)
-		totalCredits += float64(result.Credits)
+	var student models.Student
+	if err := s.db.First(&student, studentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("student not found")
+		}
+		return nil, err
+	}
+
+	previousStatus := student.EnrollmentStatus
+	if previousStatus == status {
+		return &student, nil
+	}
+
+	student.EnrollmentStatus = status
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Model(&student).Update("enrollment_status", status).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventStudentStatusChanged, studentStatusEventData(&student, previousStatus, reason))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if totalCredits == 0 {
-		return 0, nil
+	return &student, nil
+}
+
+// DeleteStudent soft-deletes a student and publishes a student.deleted tombstone
+func (s *StudentService) DeleteStudent(studentID uint) error {
+	var student models.Student
+	if err := s.db.First(&student, studentID).Error; err != nil {
+		return errors.New("student not found")
 	}
 
-	return totalPoints / totalCredits, nil
+	return s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Delete(&student).Error; err != nil {
+			return err
+		}
+		return outbox.Publish(EventStudentDeleted, tombstoneEventData("student_id", student.ID, time.Now()))
+	})
 }
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mwombeki6/mock-sims/internal/models"
+)
+
+// Timetable conflict types
+const (
+	ConflictVenueDoubleBooking = "venue_double_booking"
+	ConflictLecturerClash      = "lecturer_clash"
+	ConflictStudentOverlap     = "student_overlap"
+	ConflictVenueCapacity      = "venue_capacity"
+)
+
+var conflictOrder = map[string]int{
+	ConflictVenueDoubleBooking: 0, ConflictLecturerClash: 1, ConflictStudentOverlap: 2, ConflictVenueCapacity: 3,
+}
+
+// TimetableConflict is a lecture in a venue too small for it, or two lectures that overlap
+type TimetableConflict struct {
+	Type       string   `json:"type"`
+	LectureIDs []uint   `json:"lecture_ids"`
+	DayOfWeek  string   `json:"day_of_week"`
+	Message    string   `json:"message"`
+	Students   []string `json:"students,omitempty"` // Registration numbers of the students affected by an overlap
+}
+
+// TimetableAudit lists the conflicts in a semester's lecture timetable
+type TimetableAudit struct {
+	SemesterID uint                `json:"semester_id"`
+	Lectures   int                 `json:"lectures"`
+	Conflicts  []TimetableConflict `json:"conflicts"`
+	Counts     map[string]int      `json:"counts"` // Conflicts per type
+}
+
+// rosterEntry is a student enrolled in a course section for the semester
+type rosterEntry struct {
+	StudentID uint
+	CourseID  uint
+	SectionID *uint
+	RegNumber string
+}
+
+// Conflicts audits a semester's lectures (0 is the current semester) for venue double-bookings,
+// lecturers teaching two lectures at once, students with overlapping lectures and venues smaller
+// than a lecture's enrollment
+func (s *TimetableService) Conflicts(semesterID uint) (*TimetableAudit, error) {
+	var semester models.Semester
+	query := s.db.Where("is_current = ?", true)
+	if semesterID != 0 {
+		query = s.db.Where("id = ?", semesterID)
+	}
+	if err := query.First(&semester).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	lectures, conflicts, err := s.audit(semester.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &TimetableAudit{
+		SemesterID: semester.ID,
+		Lectures:   len(lectures),
+		Conflicts:  conflicts,
+		Counts:     map[string]int{},
+	}
+	for kind := range conflictOrder {
+		audit.Counts[kind] = 0
+	}
+	for _, conflict := range conflicts {
+		audit.Counts[conflict.Type]++
+	}
+	return audit, nil
+}
+
+// LectureConflicts runs the same checks for a new or changed lecture against the rest of its
+// semester's timetable, before it is saved
+func (s *TimetableService) LectureConflicts(lecture *models.Lecture) ([]TimetableConflict, error) {
+	_, conflicts, err := s.audit(lecture.SemesterID, lecture)
+	return conflicts, err
+}
+
+// audit checks a semester's lectures, with candidate replacing (or added to) the saved ones. With a
+// candidate only the conflicts it is part of are reported.
+func (s *TimetableService) audit(semesterID uint, candidate *models.Lecture) ([]models.Lecture, []TimetableConflict, error) {
+	var lectures []models.Lecture
+	if err := s.db.Preload("Course").Preload("Venue").Preload("Faculty").
+		Where("semester_id = ?", semesterID).Order("id").Find(&lectures).Error; err != nil {
+		return nil, nil, err
+	}
+
+	focus := -1
+	if candidate != nil {
+		checked := *candidate
+		if err := s.db.First(&checked.Course, checked.CourseID).Error; err != nil {
+			return nil, nil, errors.New("course not found")
+		}
+		if err := s.db.First(&checked.Faculty, checked.FacultyID).Error; err != nil {
+			return nil, nil, errors.New("faculty member not found")
+		}
+		checked.Venue = models.Venue{}
+		if checked.VenueID != 0 {
+			if err := s.db.First(&checked.Venue, checked.VenueID).Error; err != nil {
+				return nil, nil, errors.New("venue not found")
+			}
+		}
+		for i := range lectures {
+			if checked.ID != 0 && lectures[i].ID == checked.ID {
+				lectures[i], focus = checked, i
+			}
+		}
+		if focus < 0 {
+			lectures, focus = append(lectures, checked), len(lectures)
+		}
+	}
+
+	var enrollments []rosterEntry
+	if err := s.db.Table("enrollments").
+		Select("enrollments.student_id, enrollments.course_id, enrollments.section_id, students.reg_number").
+		Joins("JOIN students ON students.id = enrollments.student_id").
+		Where("enrollments.semester_id = ? AND enrollments.status <> ? AND enrollments.deleted_at IS NULL", semesterID, "dropped").
+		Scan(&enrollments).Error; err != nil {
+		return nil, nil, err
+	}
+	byCourse := make(map[uint][]rosterEntry)
+	for _, enrollment := range enrollments {
+		byCourse[enrollment.CourseID] = append(byCourse[enrollment.CourseID], enrollment)
+	}
+
+	// A lecture is attended by the course's students in its section (every student when either has none)
+	rosters := make([]map[uint]string, len(lectures))
+	for i := range lectures {
+		rosters[i] = map[uint]string{}
+		for _, enrollment := range byCourse[lectures[i].CourseID] {
+			if lectures[i].SectionID == nil || enrollment.SectionID == nil || *lectures[i].SectionID == *enrollment.SectionID {
+				rosters[i][enrollment.StudentID] = enrollment.RegNumber
+			}
+		}
+	}
+
+	conflicts := []TimetableConflict{}
+	for i := range lectures {
+		if focus >= 0 && i != focus {
+			continue
+		}
+		lecture := &lectures[i]
+		if lecture.VenueID != 0 && lecture.Venue.Capacity < len(rosters[i]) {
+			conflicts = append(conflicts, TimetableConflict{
+				Type:       ConflictVenueCapacity,
+				LectureIDs: []uint{lecture.ID},
+				DayOfWeek:  lecture.DayOfWeek,
+				Message: fmt.Sprintf("%s seats %d but %d students are enrolled in %s",
+					venueName(&lecture.Venue), lecture.Venue.Capacity, len(rosters[i]), lectureLabel(lecture)),
+			})
+		}
+	}
+
+	for i := range lectures {
+		for j := i + 1; j < len(lectures); j++ {
+			if focus >= 0 && i != focus && j != focus {
+				continue
+			}
+			a, b := &lectures[i], &lectures[j]
+			if !strings.EqualFold(a.DayOfWeek, b.DayOfWeek) || !(a.StartTime < b.EndTime && b.StartTime < a.EndTime) {
+				continue
+			}
+			pair := []uint{a.ID, b.ID}
+
+			if a.VenueID != 0 && a.VenueID == b.VenueID {
+				conflicts = append(conflicts, TimetableConflict{
+					Type:       ConflictVenueDoubleBooking,
+					LectureIDs: pair,
+					DayOfWeek:  a.DayOfWeek,
+					Message:    fmt.Sprintf("%s is booked for %s and %s", venueName(&a.Venue), lectureLabel(a), lectureLabel(b)),
+				})
+			}
+			if a.FacultyID == b.FacultyID {
+				conflicts = append(conflicts, TimetableConflict{
+					Type:       ConflictLecturerClash,
+					LectureIDs: pair,
+					DayOfWeek:  a.DayOfWeek,
+					Message: fmt.Sprintf("%s %s teaches %s and %s",
+						a.Faculty.FirstName, a.Faculty.LastName, lectureLabel(a), lectureLabel(b)),
+				})
+			}
+
+			var students []string
+			for studentID, regNumber := range rosters[i] {
+				if _, ok := rosters[j][studentID]; ok {
+					students = append(students, regNumber)
+				}
+			}
+			if len(students) > 0 {
+				sort.Strings(students)
+				conflicts = append(conflicts, TimetableConflict{
+					Type:       ConflictStudentOverlap,
+					LectureIDs: pair,
+					DayOfWeek:  a.DayOfWeek,
+					Message:    fmt.Sprintf("%d students are enrolled in both %s and %s", len(students), lectureLabel(a), lectureLabel(b)),
+					Students:   students,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(conflicts, func(a, b int) bool {
+		x, y := conflicts[a], conflicts[b]
+		if x.Type != y.Type {
+			return conflictOrder[x.Type] < conflictOrder[y.Type]
+		}
+		if x.DayOfWeek != y.DayOfWeek {
+			return s.dayIndex(x.DayOfWeek) < s.dayIndex(y.DayOfWeek)
+		}
+		return x.LectureIDs[0] < y.LectureIDs[0]
+	})
+	return lectures, conflicts, nil
+}
+
+// lectureLabel names a lecture by course and slot, e.g. CS101 (Monday 08:00-10:00)
+func lectureLabel(lecture *models.Lecture) string {
+	return fmt.Sprintf("%s (%s %s-%s)", lecture.Course.Code, lecture.DayOfWeek, lecture.StartTime, lecture.EndTime)
+}
+
+func venueName(venue *models.Venue) string {
+	return venue.Building + " " + venue.RoomNumber
+}
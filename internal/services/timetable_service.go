@@ -0,0 +1,830 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Hard timetable constraints, reported when a lecture cannot be placed
+const (
+	ConstraintNoLecturer       = "no_lecturer"
+	ConstraintVenueCapacity    = "venue_capacity"
+	ConstraintLabRequired      = "lab_required"
+	ConstraintLecturerClash    = "lecturer_clash"
+	ConstraintVenueClash       = "venue_clash"
+	ConstraintProgramYearClash = "program_year_clash"
+)
+
+// Soft timetable constraints, weighted by TIMETABLE_SOFT_CONSTRAINTS
+const (
+	SoftElectiveClash     = "elective_clash"      // Overlaps another course of a program year where one is an elective
+	SoftSameDay           = "same_day"            // Two lectures of a course section on one day
+	SoftLecturerDailyLoad = "lecturer_daily_load" // More than two lectures a day for a lecturer
+	SoftProgramDailyLoad  = "program_daily_load"  // More than two core lectures a day for a program year
+	SoftLateSlot          = "late_slot"           // The last slot of the day
+	SoftVenueFit          = "venue_fit"           // Empty seats, as a share of the venue
+	SoftLabVenue          = "lab_venue"           // A non-practical course in a lab
+)
+
+var softConstraints = []string{
+	SoftElectiveClash, SoftSameDay, SoftLecturerDailyLoad, SoftProgramDailyLoad, SoftLateSlot, SoftVenueFit, SoftLabVenue,
+}
+
+// dailyLoadLimit is the number of lectures a day a lecturer or program year takes before it counts as heavy
+const dailyLoadLimit = 2
+
+// TimetableSlot is a weekly lecture slot, e.g. 08:00-10:00
+type TimetableSlot struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type TimetableService struct {
+	db      *gorm.DB
+	cfg     *config.Config
+	events  *EventPublisher
+	days    []string
+	slots   []TimetableSlot
+	weights map[string]float64
+}
+
+func NewTimetableService(db *gorm.DB, cfg *config.Config) *TimetableService {
+	var days []string
+	for _, day := range strings.Split(cfg.TimetableDays, ",") {
+		if day = strings.TrimSpace(day); day != "" {
+			days = append(days, day)
+		}
+	}
+
+	return &TimetableService{
+		db:      db,
+		cfg:     cfg,
+		events:  NewEventPublisher(db, cfg),
+		days:    days,
+		slots:   parseTimetableSlots(cfg.TimetableSlots),
+		weights: parseSoftWeights(cfg.TimetableSoftConstraints),
+	}
+}
+
+// TimetableOptions tune a timetable generation run
+type TimetableOptions struct {
+	Apply           bool               `json:"apply"`             // Write the timetable to the semester's lectures
+	Partial         bool               `json:"partial"`           // Apply even when some lectures cannot be placed
+	LecturesPerWeek int                `json:"lectures_per_week"` // Per course section; 0 keeps each section's current count (at least 1)
+	Weights         map[string]float64 `json:"weights"`           // Overrides TIMETABLE_SOFT_CONSTRAINTS
+}
+
+// PlannedLecture is one lecture of a generated timetable
+type PlannedLecture struct {
+	LectureID  uint    `json:"lecture_id,omitempty"` // Set once applied
+	CourseID   uint    `json:"course_id"`
+	CourseCode string  `json:"course_code"`
+	SectionID  *uint   `json:"section_id"`
+	Section    string  `json:"section,omitempty"`
+	FacultyID  uint    `json:"faculty_id"`
+	VenueID    uint    `json:"venue_id"`
+	Venue      string  `json:"venue"`
+	DayOfWeek  string  `json:"day_of_week"`
+	StartTime  string  `json:"start_time"`
+	EndTime    string  `json:"end_time"`
+	Students   int     `json:"students"`
+	Penalty    float64 `json:"penalty"`
+}
+
+// TimetableIssue is a lecture that cannot be placed, or a soft constraint a placed lecture breaks
+type TimetableIssue struct {
+	CourseCode string `json:"course_code"`
+	Section    string `json:"section,omitempty"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// TimetableResult is a generated weekly timetable for a semester
+type TimetableResult struct {
+	SemesterID     uint               `json:"semester_id"`
+	Applied        bool               `json:"applied"`
+	Days           []string           `json:"days"`
+	Slots          []TimetableSlot    `json:"slots"`
+	Weights        map[string]float64 `json:"weights"`
+	Lectures       []PlannedLecture   `json:"lectures"`
+	Unsatisfied    []TimetableIssue   `json:"unsatisfied"`     // Hard constraints that could not be met
+	SoftViolations []TimetableIssue   `json:"soft_violations"` // Soft constraints the timetable breaks
+	Penalty        float64            `json:"penalty"`         // Weighted total of the soft violations
+}
+
+// timetableUnit is a course section (or an offering without sections) to timetable
+type timetableUnit struct {
+	course    models.Course
+	section   *models.CourseSection
+	facultyID uint
+	students  int
+	lectures  int
+	groups    []groupMembership
+	existing  []models.Lecture
+	placed    []PlannedLecture
+}
+
+// groupMembership places a course in a program year, as a core course or an elective
+type groupMembership struct {
+	key  string
+	name string
+	core bool
+}
+
+type slotKey struct {
+	id   uint
+	slot int
+}
+
+type dayKey struct {
+	id  uint
+	day int
+}
+
+type groupDayKey struct {
+	key string
+	day int
+}
+
+// timetableState tracks what the timetable being built already occupies
+type timetableState struct {
+	lecturerBusy map[slotKey]bool
+	venueBusy    map[slotKey]bool
+	lecturerDay  map[dayKey]int
+	unitDay      map[dayKey]int
+	groupAt      map[string]map[int]map[uint]bool // Program year → slot → course → core
+	groupDay     map[groupDayKey]int              // Core lectures of a program year per day
+}
+
+func newTimetableState() *timetableState {
+	return &timetableState{
+		lecturerBusy: map[slotKey]bool{},
+		venueBusy:    map[slotKey]bool{},
+		lecturerDay:  map[dayKey]int{},
+		unitDay:      map[dayKey]int{},
+		groupAt:      map[string]map[int]map[uint]bool{},
+		groupDay:     map[groupDayKey]int{},
+	}
+}
+
+// clone copies the state, so a unit's bookings can be undone when it cannot be placed
+func (st *timetableState) clone() *timetableState {
+	copied := &timetableState{
+		lecturerBusy: maps.Clone(st.lecturerBusy),
+		venueBusy:    maps.Clone(st.venueBusy),
+		lecturerDay:  maps.Clone(st.lecturerDay),
+		unitDay:      maps.Clone(st.unitDay),
+		groupAt:      make(map[string]map[int]map[uint]bool, len(st.groupAt)),
+		groupDay:     maps.Clone(st.groupDay),
+	}
+	for key, slots := range st.groupAt {
+		copied.groupAt[key] = make(map[int]map[uint]bool, len(slots))
+		for slot, courses := range slots {
+			copied.groupAt[key][slot] = maps.Clone(courses)
+		}
+	}
+	return copied
+}
+
+// Generate builds a weekly timetable for a semester (0 is the current one). Every course section is
+// given its lectures in slots and venues that meet the hard constraints: the venue seats every
+// enrolled student, practical courses sit in labs, and no lecturer, venue or program year (core
+// courses of the same program, year and semester) is booked twice at once. Among those, the
+// placement with the lowest weighted soft constraint penalty wins. Sections that cannot be placed are
+// reported with the constraint that blocked them. With Apply the semester's lectures are rewritten in
+// place, only when every lecture was placed unless Partial is set. With Partial, sections that cannot
+// be placed keep their current lectures and the rest of the timetable is placed around them.
+func (s *TimetableService) Generate(semesterID uint, options TimetableOptions) (*TimetableResult, error) {
+	if len(s.days) == 0 || len(s.slots) == 0 {
+		return nil, errors.New("TIMETABLE_DAYS and TIMETABLE_SLOTS must list at least one day and slot")
+	}
+	weights := make(map[string]float64, len(s.weights))
+	for name, weight := range s.weights {
+		weights[name] = weight
+	}
+	for name, weight := range options.Weights {
+		if _, ok := weights[name]; !ok {
+			return nil, fmt.Errorf("unknown soft constraint %q; use %s", name, strings.Join(softConstraints, ", "))
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight of %s cannot be negative", name)
+		}
+		weights[name] = weight
+	}
+	if options.LecturesPerWeek < 0 || options.LecturesPerWeek > len(s.days)*len(s.slots) {
+		return nil, errors.New("lectures_per_week is out of range")
+	}
+
+	var semester models.Semester
+	query := s.db.Where("is_current = ?", true)
+	if semesterID != 0 {
+		query = s.db.Where("id = ?", semesterID)
+	}
+	if err := query.First(&semester).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+
+	units, issues, err := s.units(&semester, options.LecturesPerWeek)
+	if err != nil {
+		return nil, err
+	}
+	var venues []models.Venue
+	if err := s.db.Order("capacity, id").Find(&venues).Error; err != nil {
+		return nil, err
+	}
+
+	result := &TimetableResult{
+		SemesterID:     semester.ID,
+		Days:           s.days,
+		Slots:          s.slots,
+		Weights:        weights,
+		Lectures:       []PlannedLecture{},
+		Unsatisfied:    issues,
+		SoftViolations: []TimetableIssue{},
+	}
+	// Solve again around the sections left out until no further section fails
+	kept := map[int]TimetableIssue{}
+	for {
+		unsatisfied, violations, failed := s.solve(units, venues, weights, kept)
+		if !options.Partial || len(failed) == 0 {
+			for i := range units {
+				if issue, ok := kept[i]; ok {
+					result.Unsatisfied = append(result.Unsatisfied, issue)
+				}
+			}
+			result.Unsatisfied = append(result.Unsatisfied, unsatisfied...)
+			result.SoftViolations = append(result.SoftViolations, violations...)
+			break
+		}
+		for n, i := range failed {
+			kept[i] = unsatisfied[n]
+		}
+	}
+
+	for i := range units {
+		result.Lectures = append(result.Lectures, units[i].placed...)
+		for _, lecture := range units[i].placed {
+			result.Penalty += lecture.Penalty
+		}
+	}
+	sort.SliceStable(result.Lectures, func(a, b int) bool {
+		x, y := result.Lectures[a], result.Lectures[b]
+		if x.DayOfWeek != y.DayOfWeek {
+			return s.dayIndex(x.DayOfWeek) < s.dayIndex(y.DayOfWeek)
+		}
+		if x.StartTime != y.StartTime {
+			return x.StartTime < y.StartTime
+		}
+		return x.Venue < y.Venue
+	})
+
+	if options.Apply && (len(result.Unsatisfied) == 0 || options.Partial) {
+		if err := s.apply(&semester, units, result); err != nil {
+			return nil, err
+		}
+		result.Applied = true
+	}
+	return result, nil
+}
+
+// solve places every unit's lectures in order, hardest first. A unit that runs out of places is
+// reported with the constraint that blocked it and its bookings are undone; the indexes of those
+// units are returned alongside their issues. Kept units are not placed: their current lectures are
+// booked first so the others avoid them.
+func (s *TimetableService) solve(units []timetableUnit, venues []models.Venue, weights map[string]float64, kept map[int]TimetableIssue) ([]TimetableIssue, []TimetableIssue, []int) {
+	state := newTimetableState()
+	for i := range units {
+		units[i].placed = nil
+		if _, ok := kept[i]; ok {
+			for n := range units[i].existing {
+				s.keep(i, &units[i], &units[i].existing[n], state)
+			}
+		}
+	}
+
+	var unsatisfied, violations []TimetableIssue
+	var failed []int
+	for i := range units {
+		if _, ok := kept[i]; ok {
+			continue
+		}
+		unit := &units[i]
+		saved := state.clone()
+		var unitViolations []TimetableIssue
+		for n := 0; n < unit.lectures; n++ {
+			lecture, issues, ok := s.place(i, unit, venues, state, weights)
+			if !ok {
+				unsatisfied = append(unsatisfied, s.diagnose(unit, venues, state))
+				failed = append(failed, i)
+				unit.placed, unitViolations, state = nil, nil, saved
+				break
+			}
+			unit.placed = append(unit.placed, lecture)
+			unitViolations = append(unitViolations, issues...)
+		}
+		violations = append(violations, unitViolations...)
+	}
+	return unsatisfied, violations, failed
+}
+
+// units loads the course sections of a semester with their lecturer, class size, lecture count and
+// program years, hardest to place first. Sections without a lecturer are returned as issues.
+func (s *TimetableService) units(semester *models.Semester, lecturesPerWeek int) ([]timetableUnit, []TimetableIssue, error) {
+	var offerings []models.CourseOffering
+	if err := s.db.Preload("Course").Preload("Sections", func(db *gorm.DB) *gorm.DB { return db.Order("code") }).
+		Where("semester_id = ?", semester.ID).Find(&offerings).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var programs []models.Program
+	if err := s.db.Find(&programs).Error; err != nil {
+		return nil, nil, err
+	}
+	programCodes := make(map[uint]string, len(programs))
+	for _, program := range programs {
+		programCodes[program.ID] = program.Code
+	}
+
+	var units []timetableUnit
+	issues := []TimetableIssue{}
+	for _, offering := range offerings {
+		sections := make([]*models.CourseSection, 0, len(offering.Sections))
+		for i := range offering.Sections {
+			sections = append(sections, &offering.Sections[i])
+		}
+		if len(sections) == 0 {
+			sections = append(sections, nil)
+		}
+
+		var curriculum []models.ProgramCourse
+		if err := s.db.Where("course_id = ? AND semester_num = ?", offering.CourseID, semester.SemesterNum).
+			Find(&curriculum).Error; err != nil {
+			return nil, nil, err
+		}
+		var assignments []models.CourseAssignment
+		if err := s.db.Where("course_id = ? AND semester_id = ?", offering.CourseID, semester.ID).
+			Order("id").Find(&assignments).Error; err != nil {
+			return nil, nil, err
+		}
+
+		for _, section := range sections {
+			unit := timetableUnit{course: offering.Course, section: section}
+
+			students := s.db.Model(&models.Enrollment{}).Where("status <> ?", "dropped")
+			lectures := s.db.Where("course_id = ? AND semester_id = ?", offering.CourseID, semester.ID)
+			if section != nil {
+				students = students.Where("section_id = ?", section.ID)
+				lectures = lectures.Where("section_id = ?", section.ID)
+			} else {
+				students = students.Where("offering_id = ?", offering.ID)
+				lectures = lectures.Where("section_id IS NULL")
+			}
+			var count int64
+			if err := students.Count(&count).Error; err != nil {
+				return nil, nil, err
+			}
+			unit.students = int(count)
+			if err := lectures.Order("id").Find(&unit.existing).Error; err != nil {
+				return nil, nil, err
+			}
+
+			unit.lectures = lecturesPerWeek
+			if unit.lectures == 0 {
+				unit.lectures = len(unit.existing)
+			}
+			if unit.lectures == 0 {
+				unit.lectures = 1
+			}
+
+			unit.facultyID = sectionLecturer(assignments, section)
+			if unit.facultyID == 0 && len(unit.existing) > 0 {
+				unit.facultyID = unit.existing[0].FacultyID
+			}
+			if unit.facultyID == 0 {
+				issues = append(issues, unit.issue(ConstraintNoLecturer, "no lecturer is assigned to teach it this semester"))
+				continue
+			}
+
+			for _, entry := range curriculum {
+				if section != nil && section.ProgramID != nil && *section.ProgramID != entry.ProgramID {
+					continue
+				}
+				unit.groups = append(unit.groups, groupMembership{
+					key:  fmt.Sprintf("%d:%d", entry.ProgramID, entry.YearOfStudy),
+					name: fmt.Sprintf("%s year %d", programCodes[entry.ProgramID], entry.YearOfStudy),
+					core: entry.CourseType == "core" || entry.CourseType == "",
+				})
+			}
+			units = append(units, unit)
+		}
+	}
+
+	// Practical courses first (few labs), then courses tied to the most program years, then the largest
+	sort.SliceStable(units, func(a, b int) bool {
+		x, y := &units[a], &units[b]
+		if x.course.RequiresLab != y.course.RequiresLab {
+			return x.course.RequiresLab
+		}
+		if x.coreGroups() != y.coreGroups() {
+			return x.coreGroups() > y.coreGroups()
+		}
+		if x.students != y.students {
+			return x.students > y.students
+		}
+		if x.course.Code != y.course.Code {
+			return x.course.Code < y.course.Code
+		}
+		return x.sectionCode() < y.sectionCode()
+	})
+	return units, issues, nil
+}
+
+// place finds the lowest penalty slot and venue for the unit's next lecture and books it
+func (s *TimetableService) place(index int, unit *timetableUnit, venues []models.Venue, state *timetableState, weights map[string]float64) (PlannedLecture, []TimetableIssue, bool) {
+	bestSlot, bestVenue := -1, -1
+	var bestPenalty float64
+	for slot := 0; slot < len(s.days)*len(s.slots); slot++ {
+		if !s.slotFree(unit, slot, state) {
+			continue
+		}
+		for v := range venues {
+			venue := &venues[v]
+			if !unitFits(unit, venue) || state.venueBusy[slotKey{venue.ID, slot}] {
+				continue
+			}
+			penalty, _ := s.penalty(index, unit, venue, slot, state, weights)
+			if bestSlot < 0 || penalty < bestPenalty {
+				bestSlot, bestVenue, bestPenalty = slot, v, penalty
+			}
+		}
+	}
+	if bestSlot < 0 {
+		return PlannedLecture{}, nil, false
+	}
+
+	venue := &venues[bestVenue]
+	penalty, violations := s.penalty(index, unit, venue, bestSlot, state, weights)
+	day, period := bestSlot/len(s.slots), bestSlot%len(s.slots)
+	lecture := PlannedLecture{
+		CourseID:   unit.course.ID,
+		CourseCode: unit.course.Code,
+		Section:    unit.sectionCode(),
+		FacultyID:  unit.facultyID,
+		VenueID:    venue.ID,
+		Venue:      venueName(venue),
+		DayOfWeek:  s.days[day],
+		StartTime:  s.slots[period].Start,
+		EndTime:    s.slots[period].End,
+		Students:   unit.students,
+		Penalty:    penalty,
+	}
+	if unit.section != nil {
+		lecture.SectionID = &unit.section.ID
+	}
+
+	s.book(index, unit, unit.facultyID, venue.ID, bestSlot, state)
+
+	issues := make([]TimetableIssue, 0, len(violations))
+	for _, violation := range violations {
+		violation.Message = fmt.Sprintf("%s %s: %s", lecture.DayOfWeek, lecture.StartTime, violation.Message)
+		issues = append(issues, violation)
+	}
+	return lecture, issues, true
+}
+
+// book marks a slot as taken by a lecture of the unit
+func (s *TimetableService) book(index int, unit *timetableUnit, facultyID, venueID uint, slot int, state *timetableState) {
+	day := slot / len(s.slots)
+	state.lecturerBusy[slotKey{facultyID, slot}] = true
+	if venueID != 0 {
+		state.venueBusy[slotKey{venueID, slot}] = true
+	}
+	state.lecturerDay[dayKey{facultyID, day}]++
+	state.unitDay[dayKey{uint(index), day}]++
+	for _, group := range unit.groups {
+		if state.groupAt[group.key] == nil {
+			state.groupAt[group.key] = map[int]map[uint]bool{}
+		}
+		if state.groupAt[group.key][slot] == nil {
+			state.groupAt[group.key][slot] = map[uint]bool{}
+		}
+		state.groupAt[group.key][slot][unit.course.ID] = group.core
+		if group.core {
+			state.groupDay[groupDayKey{group.key, day}]++
+		}
+	}
+}
+
+// keep books a saved lecture of the unit in every slot it overlaps
+func (s *TimetableService) keep(index int, unit *timetableUnit, lecture *models.Lecture, state *timetableState) {
+	day := s.dayIndex(lecture.DayOfWeek)
+	if day == len(s.days) {
+		return
+	}
+	for period, slot := range s.slots {
+		if lecture.StartTime < slot.End && slot.Start < lecture.EndTime {
+			s.book(index, unit, lecture.FacultyID, lecture.VenueID, day*len(s.slots)+period, state)
+		}
+	}
+}
+
+// slotFree checks the hard constraints of a slot other than the venue: the lecturer is free and no
+// other core course of the unit's core program years is taught then
+func (s *TimetableService) slotFree(unit *timetableUnit, slot int, state *timetableState) bool {
+	if state.lecturerBusy[slotKey{unit.facultyID, slot}] {
+		return false
+	}
+	return !programYearBusy(unit, slot, state)
+}
+
+// programYearBusy reports whether another core course shares a core program year with the unit in a slot.
+// Sections of the same course never clash with each other: students attend one of them.
+func programYearBusy(unit *timetableUnit, slot int, state *timetableState) bool {
+	for _, group := range unit.groups {
+		if !group.core {
+			continue
+		}
+		for courseID, core := range state.groupAt[group.key][slot] {
+			if core && courseID != unit.course.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unitFits checks a venue seats the unit's students and is a lab when the course is practical
+func unitFits(unit *timetableUnit, venue *models.Venue) bool {
+	if venue.Capacity < unit.students {
+		return false
+	}
+	return !unit.course.RequiresLab || strings.EqualFold(venue.VenueType, "Lab")
+}
+
+// penalty weighs the soft constraints a placement breaks and describes them
+func (s *TimetableService) penalty(index int, unit *timetableUnit, venue *models.Venue, slot int, state *timetableState, weights map[string]float64) (float64, []TimetableIssue) {
+	day, period := slot/len(s.slots), slot%len(s.slots)
+	var total float64
+	var violations []TimetableIssue
+	add := func(constraint string, amount float64, message string) {
+		if weights[constraint] == 0 || amount == 0 {
+			return
+		}
+		total += weights[constraint] * amount
+		if message != "" {
+			violations = append(violations, unit.issue(constraint, message))
+		}
+	}
+
+	overlaps := 0
+	for _, group := range unit.groups {
+		for courseID, core := range state.groupAt[group.key][slot] {
+			if courseID != unit.course.ID && !(core && group.core) {
+				overlaps++
+			}
+		}
+	}
+	add(SoftElectiveClash, float64(overlaps), fmt.Sprintf("overlaps %d elective lecture(s) of its program years", overlaps))
+
+	if same := state.unitDay[dayKey{uint(index), day}]; same > 0 {
+		add(SoftSameDay, float64(same), "another lecture of the section is on the same day")
+	}
+	if load := state.lecturerDay[dayKey{unit.facultyID, day}] + 1; load > dailyLoadLimit {
+		add(SoftLecturerDailyLoad, float64(load-dailyLoadLimit), fmt.Sprintf("the lecturer teaches %d lectures that day", load))
+	}
+	for _, group := range unit.groups {
+		if !group.core {
+			continue
+		}
+		if load := state.groupDay[groupDayKey{group.key, day}] + 1; load > dailyLoadLimit {
+			add(SoftProgramDailyLoad, float64(load-dailyLoadLimit), fmt.Sprintf("%s has %d lectures that day", group.name, load))
+		}
+	}
+	if period == len(s.slots)-1 && len(s.slots) > 1 {
+		add(SoftLateSlot, 1, "uses the last slot of the day")
+	}
+	if venue.Capacity > 0 {
+		empty := float64(venue.Capacity-unit.students) / float64(venue.Capacity)
+		message := ""
+		if empty > 0.5 {
+			message = fmt.Sprintf("%d students in a venue for %d", unit.students, venue.Capacity)
+		}
+		add(SoftVenueFit, empty, message)
+	}
+	if !unit.course.RequiresLab && strings.EqualFold(venue.VenueType, "Lab") {
+		add(SoftLabVenue, 1, "a lecture course is using a lab")
+	}
+	return total, violations
+}
+
+// diagnose explains which hard constraint left no place for a unit's next lecture
+func (s *TimetableService) diagnose(unit *timetableUnit, venues []models.Venue, state *timetableState) TimetableIssue {
+	largest, largestLab := 0, 0
+	for _, venue := range venues {
+		if venue.Capacity > largest {
+			largest = venue.Capacity
+		}
+		if strings.EqualFold(venue.VenueType, "Lab") && venue.Capacity > largestLab {
+			largestLab = venue.Capacity
+		}
+	}
+	if unit.course.RequiresLab && largestLab < unit.students {
+		if largestLab == 0 {
+			return unit.issue(ConstraintLabRequired, "the course needs a lab and there are no lab venues")
+		}
+		return unit.issue(ConstraintLabRequired,
+			fmt.Sprintf("%d students but the largest lab seats %d; split the course into more sections", unit.students, largestLab))
+	}
+	if largest < unit.students {
+		return unit.issue(ConstraintVenueCapacity,
+			fmt.Sprintf("%d students but the largest venue seats %d; split the course into more sections", unit.students, largest))
+	}
+
+	slots := len(s.days) * len(s.slots)
+	lecturerFree, studentsFree := 0, 0
+	for slot := 0; slot < slots; slot++ {
+		if state.lecturerBusy[slotKey{unit.facultyID, slot}] {
+			continue
+		}
+		lecturerFree++
+		if !programYearBusy(unit, slot, state) {
+			studentsFree++
+		}
+	}
+	switch {
+	case lecturerFree == 0:
+		return unit.issue(ConstraintLecturerClash, "the lecturer already teaches in every slot of the week")
+	case studentsFree == 0:
+		names := make([]string, 0, len(unit.groups))
+		for _, group := range unit.groups {
+			if group.core {
+				names = append(names, group.name)
+			}
+		}
+		return unit.issue(ConstraintProgramYearClash,
+			fmt.Sprintf("every slot the lecturer has free clashes with another core course of %s", strings.Join(names, ", ")))
+	}
+	return unit.issue(ConstraintVenueClash,
+		fmt.Sprintf("every venue seating %d students is booked in the %d slots free for the lecturer and students", unit.students, studentsFree))
+}
+
+// apply rewrites the semester's lectures from the placed units, reusing each section's lectures in
+// order, creating missing ones and deleting surplus ones. Moved lectures publish lecture.rescheduled.
+func (s *TimetableService) apply(semester *models.Semester, units []timetableUnit, result *TimetableResult) error {
+	lectureIDs := make(map[string]uint)
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		for i := range units {
+			unit := &units[i]
+			if len(unit.placed) == 0 {
+				continue
+			}
+			for n, planned := range unit.placed {
+				lecture := models.Lecture{CourseID: unit.course.ID, SemesterID: semester.ID}
+				if n < len(unit.existing) {
+					lecture = unit.existing[n]
+				}
+				previous := lecture
+				lecture.FacultyID = planned.FacultyID
+				lecture.VenueID = planned.VenueID
+				lecture.DayOfWeek = planned.DayOfWeek
+				lecture.StartTime = planned.StartTime
+				lecture.EndTime = planned.EndTime
+				lecture.SectionID = planned.SectionID
+				if err := tx.Omit("Course", "Faculty", "Semester", "Venue").Save(&lecture).Error; err != nil {
+					return err
+				}
+				lectureIDs[plannedKey(&planned)] = lecture.ID
+				if previous.ID != 0 && (previous.VenueID != lecture.VenueID || previous.DayOfWeek != lecture.DayOfWeek ||
+					previous.StartTime != lecture.StartTime || previous.EndTime != lecture.EndTime) {
+					if err := outbox.Publish(EventLectureRescheduled, lectureEventData(&lecture, &previous)); err != nil {
+						return err
+					}
+				}
+			}
+			for _, surplus := range unit.existing[min(len(unit.placed), len(unit.existing)):] {
+				if err := tx.Delete(&surplus).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range result.Lectures {
+		result.Lectures[i].LectureID = lectureIDs[plannedKey(&result.Lectures[i])]
+	}
+	return nil
+}
+
+func (s *TimetableService) dayIndex(day string) int {
+	for i, name := range s.days {
+		if strings.EqualFold(name, day) {
+			return i
+		}
+	}
+	return len(s.days)
+}
+
+func (u *timetableUnit) coreGroups() int {
+	count := 0
+	for _, group := range u.groups {
+		if group.core {
+			count++
+		}
+	}
+	return count
+}
+
+func (u *timetableUnit) sectionCode() string {
+	if u.section == nil {
+		return ""
+	}
+	return u.section.Code
+}
+
+func (u *timetableUnit) issue(constraint, message string) TimetableIssue {
+	return TimetableIssue{CourseCode: u.course.Code, Section: u.sectionCode(), Constraint: constraint, Message: message}
+}
+
+// plannedKey identifies a planned lecture by its course, section and slot
+func plannedKey(lecture *PlannedLecture) string {
+	return fmt.Sprintf("%d/%s/%s/%s", lecture.CourseID, lecture.Section, lecture.DayOfWeek, lecture.StartTime)
+}
+
+// sectionLecturer picks the lecturer assigned to a section, or to the whole course, preferring the
+// Lecturer role over teaching assistants
+func sectionLecturer(assignments []models.CourseAssignment, section *models.CourseSection) uint {
+	best, bestRank := uint(0), 0
+	for _, assignment := range assignments {
+		rank := 1
+		if assignment.SectionID != nil {
+			if section == nil || *assignment.SectionID != section.ID {
+				continue
+			}
+			rank += 2
+		}
+		if strings.EqualFold(assignment.Role, "Lecturer") {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = assignment.FacultyID, rank
+		}
+	}
+	return best
+}
+
+// parseTimetableSlots parses "08:00-10:00,10:00-12:00", skipping malformed slots
+func parseTimetableSlots(raw string) []TimetableSlot {
+	var slots []TimetableSlot
+	for _, entry := range strings.Split(raw, ",") {
+		start, end, ok := strings.Cut(strings.TrimSpace(entry), "-")
+		if !ok {
+			continue
+		}
+		startTime, err := time.Parse(examTimeLayout, strings.TrimSpace(start))
+		if err != nil {
+			continue
+		}
+		endTime, err := time.Parse(examTimeLayout, strings.TrimSpace(end))
+		if err != nil || !endTime.After(startTime) {
+			continue
+		}
+		slots = append(slots, TimetableSlot{Start: startTime.Format(examTimeLayout), End: endTime.Format(examTimeLayout)})
+	}
+	return slots
+}
+
+// parseSoftWeights parses "constraint:weight" pairs; constraints that are not listed weigh 0
+func parseSoftWeights(raw string) map[string]float64 {
+	weights := make(map[string]float64, len(softConstraints))
+	for _, name := range softConstraints {
+		weights[name] = 0
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if _, known := weights[name]; !known {
+			continue
+		}
+		if weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && weight >= 0 {
+			weights[name] = weight
+		}
+	}
+	return weights
+}
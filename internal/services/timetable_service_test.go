@@ -0,0 +1,376 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mwombeki6/mock-sims/internal/models"
+)
+
+// testTimetable is a solver over two days of two slots, with the given soft constraint weights
+func testTimetable(weights string) *TimetableService {
+	return &TimetableService{
+		days:    []string{"Monday", "Tuesday"},
+		slots:   []TimetableSlot{{Start: "08:00", End: "10:00"}, {Start: "10:00", End: "12:00"}},
+		weights: parseSoftWeights(weights),
+	}
+}
+
+var testVenues = []models.Venue{
+	{ID: 1, Building: "Block A", RoomNumber: "101", Capacity: 40, VenueType: "Classroom"},
+	{ID: 2, Building: "Block B", RoomNumber: "LAB1", Capacity: 30, VenueType: "Lab"},
+	{ID: 3, Building: "Hall", RoomNumber: "1", Capacity: 200, VenueType: "Lecture Hall"},
+}
+
+func testUnit(courseID uint, code string, facultyID uint, students int, lab bool, groups ...groupMembership) *timetableUnit {
+	return &timetableUnit{
+		course:    models.Course{ID: courseID, Code: code, RequiresLab: lab},
+		facultyID: facultyID,
+		students:  students,
+		lectures:  1,
+		groups:    groups,
+	}
+}
+
+var coreYear1 = groupMembership{key: "1:1", name: "BSC-CS year 1", core: true}
+
+func TestPlace(t *testing.T) {
+	tests := []struct {
+		name      string
+		unit      *timetableUnit
+		booked    []*timetableUnit // Placed first, each in its lowest penalty slot
+		wantOK    bool
+		wantSlot  int // day*slots + period
+		wantVenue uint
+	}{
+		{
+			name:      "smallest fitting venue in the first slot",
+			unit:      testUnit(1, "CS101", 10, 35, false),
+			wantOK:    true,
+			wantSlot:  0,
+			wantVenue: 1,
+		},
+		{
+			name:      "practical course only in a lab",
+			unit:      testUnit(1, "CS102", 10, 25, true),
+			wantOK:    true,
+			wantSlot:  0,
+			wantVenue: 2,
+		},
+		{
+			name:      "lecturer clash moves to the next slot",
+			unit:      testUnit(2, "CS103", 10, 35, false),
+			booked:    []*timetableUnit{testUnit(1, "CS101", 10, 35, false)},
+			wantOK:    true,
+			wantSlot:  1,
+			wantVenue: 1,
+		},
+		{
+			name:      "core program year clash moves to the next slot",
+			unit:      testUnit(2, "CS104", 11, 35, false, coreYear1),
+			booked:    []*timetableUnit{testUnit(1, "CS101", 10, 35, false, coreYear1)},
+			wantOK:    true,
+			wantSlot:  1,
+			wantVenue: 1,
+		},
+		{
+			name:      "sections of the same course share a program year slot",
+			unit:      testUnit(1, "CS101", 11, 35, false, coreYear1),
+			booked:    []*timetableUnit{testUnit(1, "CS101", 10, 35, false, coreYear1)},
+			wantOK:    true,
+			wantSlot:  0,
+			wantVenue: 3,
+		},
+		{
+			name: "no venue seats the class",
+			unit: testUnit(1, "CS105", 10, 500, false),
+		},
+		{
+			name: "no lab seats the class",
+			unit: testUnit(1, "CS106", 10, 35, true),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := testTimetable("")
+			state := newTimetableState()
+			for i, unit := range test.booked {
+				if _, _, ok := s.place(i+1, unit, testVenues, state, s.weights); !ok {
+					t.Fatalf("booking %s: no place", unit.course.Code)
+				}
+			}
+
+			lecture, _, ok := s.place(0, test.unit, testVenues, state, s.weights)
+			if ok != test.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			slot := s.dayIndex(lecture.DayOfWeek)*len(s.slots) + periodOf(s, lecture.StartTime)
+			if slot != test.wantSlot || lecture.VenueID != test.wantVenue {
+				t.Errorf("placed in slot %d venue %d, want slot %d venue %d", slot, lecture.VenueID, test.wantSlot, test.wantVenue)
+			}
+			if !state.lecturerBusy[slotKey{test.unit.facultyID, slot}] || !state.venueBusy[slotKey{lecture.VenueID, slot}] {
+				t.Error("placement was not booked in the state")
+			}
+		})
+	}
+}
+
+func periodOf(s *TimetableService, start string) int {
+	for i, slot := range s.slots {
+		if slot.Start == start {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestPenalty(t *testing.T) {
+	s := testTimetable("same_day:5,late_slot:1,lab_venue:4,elective_clash:10")
+	tests := []struct {
+		name     string
+		unit     *timetableUnit
+		venue    models.Venue
+		slot     int
+		booked   []int // Slots already taken by the unit
+		elective bool  // An elective of the unit's program year sits in the slot
+		want     float64
+		wantSoft []string
+	}{
+		{
+			name:  "first slot in a fitting classroom",
+			unit:  testUnit(1, "CS101", 10, 40, false),
+			venue: testVenues[0],
+			slot:  0,
+		},
+		{
+			name:     "last slot of the day",
+			unit:     testUnit(1, "CS101", 10, 40, false),
+			venue:    testVenues[0],
+			slot:     1,
+			want:     1,
+			wantSoft: []string{SoftLateSlot},
+		},
+		{
+			name:     "second lecture on the same day",
+			unit:     testUnit(1, "CS101", 10, 40, false),
+			venue:    testVenues[0],
+			slot:     2,
+			booked:   []int{3},
+			want:     5,
+			wantSoft: []string{SoftSameDay},
+		},
+		{
+			name:     "lecture course in a lab",
+			unit:     testUnit(1, "CS101", 10, 30, false),
+			venue:    testVenues[1],
+			slot:     0,
+			want:     4,
+			wantSoft: []string{SoftLabVenue},
+		},
+		{
+			name:     "overlaps an elective of its program year",
+			unit:     testUnit(1, "CS101", 10, 40, false, coreYear1),
+			venue:    testVenues[0],
+			slot:     0,
+			elective: true,
+			want:     10,
+			wantSoft: []string{SoftElectiveClash},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTimetableState()
+			for _, slot := range test.booked {
+				s.book(0, test.unit, test.unit.facultyID, 99, slot, state)
+			}
+			if test.elective {
+				elective := testUnit(2, "CS150", 11, 20, false, groupMembership{key: coreYear1.key, name: coreYear1.name})
+				s.book(1, elective, elective.facultyID, 98, test.slot, state)
+			}
+
+			got, violations := s.penalty(0, test.unit, &test.venue, test.slot, state, s.weights)
+			if got != test.want {
+				t.Errorf("penalty = %v, want %v", got, test.want)
+			}
+			var soft []string
+			for _, violation := range violations {
+				soft = append(soft, violation.Constraint)
+			}
+			if !reflect.DeepEqual(soft, test.wantSoft) {
+				t.Errorf("violations = %v, want %v", soft, test.wantSoft)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	s := testTimetable("")
+	tests := []struct {
+		name   string
+		unit   *timetableUnit
+		venues []models.Venue
+		fill   func(state *timetableState)
+		want   string
+	}{
+		{
+			name:   "no labs",
+			unit:   testUnit(1, "CS102", 10, 20, true),
+			venues: testVenues[:1],
+			want:   ConstraintLabRequired,
+		},
+		{
+			name:   "labs too small",
+			unit:   testUnit(1, "CS102", 10, 35, true),
+			venues: testVenues,
+			want:   ConstraintLabRequired,
+		},
+		{
+			name:   "venues too small",
+			unit:   testUnit(1, "CS101", 10, 500, false),
+			venues: testVenues,
+			want:   ConstraintVenueCapacity,
+		},
+		{
+			name:   "lecturer busy all week",
+			unit:   testUnit(1, "CS101", 10, 35, false),
+			venues: testVenues,
+			fill: func(state *timetableState) {
+				for slot := 0; slot < 4; slot++ {
+					state.lecturerBusy[slotKey{10, slot}] = true
+				}
+			},
+			want: ConstraintLecturerClash,
+		},
+		{
+			name:   "program year busy whenever the lecturer is free",
+			unit:   testUnit(1, "CS101", 10, 35, false, coreYear1),
+			venues: testVenues,
+			fill: func(state *timetableState) {
+				state.lecturerBusy[slotKey{10, 0}] = true
+				other := testUnit(2, "CS110", 11, 35, false, coreYear1)
+				for slot := 1; slot < 4; slot++ {
+					s.book(1, other, other.facultyID, 0, slot, state)
+				}
+			},
+			want: ConstraintProgramYearClash,
+		},
+		{
+			name:   "every fitting venue booked",
+			unit:   testUnit(1, "CS101", 10, 150, false),
+			venues: testVenues,
+			fill: func(state *timetableState) {
+				for slot := 0; slot < 4; slot++ {
+					state.venueBusy[slotKey{3, slot}] = true
+				}
+			},
+			want: ConstraintVenueClash,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTimetableState()
+			if test.fill != nil {
+				test.fill(state)
+			}
+			if _, _, ok := s.place(0, test.unit, test.venues, state, s.weights); ok {
+				t.Fatal("placed a lecture that should not fit")
+			}
+			if got := s.diagnose(test.unit, test.venues, state).Constraint; got != test.want {
+				t.Errorf("constraint = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSolveUndoesFailedUnit(t *testing.T) {
+	s := testTimetable("")
+	// The lab course wants five lectures in a week of four slots, so its four bookings are undone
+	units := []timetableUnit{
+		*testUnit(1, "CS102", 10, 25, true),
+		*testUnit(2, "CS101", 10, 35, false),
+	}
+	units[0].lectures = 5
+	venues := []models.Venue{testVenues[0], testVenues[1]}
+
+	unsatisfied, _, failed := s.solve(units, venues, s.weights, nil)
+	if !reflect.DeepEqual(failed, []int{0}) || len(unsatisfied) != 1 {
+		t.Fatalf("failed = %v, unsatisfied = %v", failed, unsatisfied)
+	}
+	if len(units[0].placed) != 0 {
+		t.Errorf("failed unit kept %d placed lectures", len(units[0].placed))
+	}
+	if len(units[1].placed) != 1 || units[1].placed[0].StartTime != "08:00" || units[1].placed[0].DayOfWeek != "Monday" {
+		t.Errorf("second unit was not placed in the slot freed by the failed unit: %+v", units[1].placed)
+	}
+}
+
+func TestSolveKeepsUnplacedLectures(t *testing.T) {
+	s := testTimetable("")
+	units := []timetableUnit{
+		*testUnit(1, "CS102", 10, 25, true),
+		*testUnit(2, "CS101", 10, 35, false),
+	}
+	units[0].existing = []models.Lecture{
+		{CourseID: 1, FacultyID: 10, VenueID: 2, DayOfWeek: "Monday", StartTime: "08:00", EndTime: "10:00"},
+	}
+	kept := map[int]TimetableIssue{0: units[0].issue(ConstraintLabRequired, "kept")}
+
+	if _, _, failed := s.solve(units, testVenues, s.weights, kept); len(failed) != 0 {
+		t.Fatalf("failed = %v", failed)
+	}
+	if len(units[0].placed) != 0 {
+		t.Error("kept unit was placed")
+	}
+	if got := units[1].placed[0]; got.DayOfWeek == "Monday" && got.StartTime == "08:00" {
+		t.Error("placed over the lecturer's kept lecture")
+	}
+}
+
+func TestParseTimetableSlots(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []TimetableSlot
+	}{
+		{"08:00-10:00,10:00-12:00", []TimetableSlot{{"08:00", "10:00"}, {"10:00", "12:00"}}},
+		{" 08:00 - 10:00 , 14:00-16:00 ", []TimetableSlot{{"08:00", "10:00"}, {"14:00", "16:00"}}},
+		{"08:00,10:00-12:00", []TimetableSlot{{"10:00", "12:00"}}},
+		{"10:00-08:00,09:00-09:00", nil},
+		{"8am-10am,25:00-26:00", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := parseTimetableSlots(test.raw); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseTimetableSlots(%q) = %v, want %v", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestParseSoftWeights(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want map[string]float64
+	}{
+		{"same_day:5,late_slot:1.5", map[string]float64{SoftSameDay: 5, SoftLateSlot: 1.5}},
+		{" same_day : 2 ", map[string]float64{SoftSameDay: 2}},
+		{"unknown:3,same_day", map[string]float64{}},
+		{"same_day:-1,late_slot:x", map[string]float64{}},
+		{"", map[string]float64{}},
+	}
+	for _, test := range tests {
+		got := parseSoftWeights(test.raw)
+		if len(got) != len(softConstraints) {
+			t.Errorf("parseSoftWeights(%q) has %d constraints, want %d", test.raw, len(got), len(softConstraints))
+		}
+		for _, name := range softConstraints {
+			if got[name] != test.want[name] {
+				t.Errorf("parseSoftWeights(%q)[%s] = %v, want %v", test.raw, name, got[name], test.want[name])
+			}
+		}
+	}
+}
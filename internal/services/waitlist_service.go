@@ -0,0 +1,375 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mwombeki6/mock-sims/internal/config"
+	"github.com/mwombeki6/mock-sims/internal/models"
+	"gorm.io/gorm"
+)
+
+// Waitlist entry statuses
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistPromoted  = "promoted"
+	WaitlistCancelled = "cancelled"
+	WaitlistExpired   = "expired" // No longer eligible when a seat opened
+)
+
+type WaitlistService struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	events *EventPublisher
+	rules  *RegistrationRules
+}
+
+func NewWaitlistService(db *gorm.DB, cfg *config.Config) *WaitlistService {
+	return &WaitlistService{
+		db:     db,
+		cfg:    cfg,
+		events: NewEventPublisher(db, cfg),
+		rules:  NewRegistrationRules(db, cfg),
+	}
+}
+
+// WaitlistPosition is a student's place on a course waitlist
+type WaitlistPosition struct {
+	EntryID    uint      `json:"entry_id"`
+	CourseCode string    `json:"course_code"`
+	CourseName string    `json:"course_name"`
+	Semester   string    `json:"semester"`
+	Position   int       `json:"position"`
+	Waiting    int       `json:"waiting"` // Total students waiting
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// Offering returns the offering of a course in a semester, creating it on first use
+func (s *WaitlistService) Offering(courseID, semesterID uint) (*models.CourseOffering, error) {
+	return findOrCreateOffering(s.db, courseID, semesterID)
+}
+
+// Seats returns capacity, enrolled and waitlisted counts for a course in a semester. Reading seats
+// never creates an offering: a course without one has no seat limit, as the registration rules see it.
+func (s *WaitlistService) Seats(courseID, semesterID uint) (*OfferingSeats, error) {
+	offering, err := findOffering(s.db, courseID, semesterID)
+	if err != nil {
+		return nil, err
+	}
+	if offering != nil {
+		return offeringSeats(s.db, offering)
+	}
+
+	var course models.Course
+	if err := s.db.First(&course, courseID).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+	if err := s.db.First(&models.Semester{}, semesterID).Error; err != nil {
+		return nil, errors.New("semester not found")
+	}
+	var enrolled int64
+	if err := s.db.Model(&models.Enrollment{}).
+		Where("course_id = ? AND semester_id = ? AND status = ?", courseID, semesterID, "active").
+		Count(&enrolled).Error; err != nil {
+		return nil, err
+	}
+	return &OfferingSeats{
+		CourseCode:     course.Code,
+		SemesterID:     semesterID,
+		CapacitySource: CapacityUnlimited,
+		Enrolled:       int(enrolled),
+	}, nil
+}
+
+// CourseSeats returns the seats of a course by code, in the current semester when semesterID is 0
+func (s *WaitlistService) CourseSeats(courseCode string, semesterID uint) (*OfferingSeats, error) {
+	course, err := s.findCourse(courseCode)
+	if err != nil {
+		return nil, err
+	}
+	if semesterID, err = s.semesterOrCurrent(semesterID); err != nil {
+		return nil, err
+	}
+	return s.Seats(course.ID, semesterID)
+}
+
+// SetCapacity sets or clears (nil) an offering's explicit capacity and fills any seats it opens
+func (s *WaitlistService) SetCapacity(courseCode string, semesterID uint, capacity *int) (*OfferingSeats, error) {
+	if capacity != nil && *capacity < 0 {
+		return nil, errors.New("capacity cannot be negative")
+	}
+
+	course, err := s.findCourse(courseCode)
+	if err != nil {
+		return nil, err
+	}
+	if semesterID, err = s.semesterOrCurrent(semesterID); err != nil {
+		return nil, err
+	}
+
+	offering, err := s.Offering(course.ID, semesterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(offering).Update("capacity", capacity).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := s.FillOpenSeats(course.ID, semesterID); err != nil {
+		return nil, err
+	}
+	return s.Seats(course.ID, semesterID)
+}
+
+// Join puts a student on an offering's waitlist (once) and returns the entry with its position
+func (s *WaitlistService) Join(studentID, courseID, semesterID uint) (*models.WaitlistEntry, int, error) {
+	offering, err := s.Offering(courseID, semesterID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entry models.WaitlistEntry
+	err = s.db.Where("offering_id = ? AND student_id = ? AND status = ?", offering.ID, studentID, WaitlistWaiting).
+		First(&entry).Error
+	if err == nil {
+		entry.Offering = *offering
+		return &entry, s.position(&entry), nil
+	}
+
+	entry = models.WaitlistEntry{
+		OfferingID: offering.ID,
+		StudentID:  studentID,
+		Status:     WaitlistWaiting,
+		JoinedAt:   time.Now(),
+	}
+	entry.Offering = *offering
+	var position int
+	err = s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := tx.Omit("Offering").Create(&entry).Error; err != nil {
+			return err
+		}
+		position = s.position(&entry)
+		return outbox.Publish(EventWaitlistJoined, waitlistEventData(&entry, position, 0))
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &entry, position, nil
+}
+
+// Leave removes a student from a course waitlist in the current semester
+func (s *WaitlistService) Leave(studentID uint, courseCode string) error {
+	result := s.db.Model(&models.WaitlistEntry{}).
+		Where("student_id = ? AND status = ? AND offering_id IN (?)", studentID, WaitlistWaiting,
+			s.db.Model(&models.CourseOffering{}).Select("course_offerings.id").
+				Joins("JOIN courses ON courses.id = course_offerings.course_id").
+				Joins("JOIN semesters ON semesters.id = course_offerings.semester_id").
+				Where("courses.code = ? AND semesters.is_current = ?", strings.ToUpper(courseCode), true)).
+		Update("status", WaitlistCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("you are not on the waitlist for this course")
+	}
+	return nil
+}
+
+// StudentPositions lists the waitlists a student is currently on
+func (s *WaitlistService) StudentPositions(studentID uint) ([]WaitlistPosition, error) {
+	var entries []models.WaitlistEntry
+	if err := s.db.Preload("Offering.Course").Preload("Offering.Semester").
+		Where("student_id = ? AND status = ?", studentID, WaitlistWaiting).
+		Order("joined_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	positions := make([]WaitlistPosition, 0, len(entries))
+	for i := range entries {
+		positions = append(positions, s.describe(&entries[i]))
+	}
+	return positions, nil
+}
+
+// CourseWaitlist lists the students waiting for a course in a semester, in promotion order
+func (s *WaitlistService) CourseWaitlist(courseCode string, semesterID uint) ([]models.WaitlistEntry, error) {
+	course, err := s.findCourse(courseCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if semesterID, err = s.semesterOrCurrent(semesterID); err != nil {
+		return nil, err
+	}
+
+	var entries []models.WaitlistEntry
+	err = s.db.Preload("Student").
+		Joins("JOIN course_offerings ON course_offerings.id = waitlist_entries.offering_id").
+		Where("course_offerings.course_id = ? AND course_offerings.semester_id = ? AND waitlist_entries.status = ?",
+			course.ID, semesterID, WaitlistWaiting).
+		Order("waitlist_entries.joined_at, waitlist_entries.id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// FillOpenSeats promotes waitlisted students, first come first served, while seats are available.
+// Students who are no longer eligible (e.g. a new timetable clash) are expired and skipped.
+func (s *WaitlistService) FillOpenSeats(courseID, semesterID uint) ([]models.WaitlistEntry, error) {
+	offering, err := findOffering(s.db, courseID, semesterID)
+	if err != nil || offering == nil {
+		return nil, err // Without an offering nobody can be waiting
+	}
+
+	var promoted []models.WaitlistEntry
+	for {
+		seats, err := offeringSeats(s.db, offering)
+		if err != nil {
+			return promoted, err
+		}
+		if seats.Full() {
+			return promoted, nil
+		}
+
+		var entry models.WaitlistEntry
+		err = s.db.Where("offering_id = ? AND status = ?", offering.ID, WaitlistWaiting).
+			Order("joined_at, id").First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return promoted, nil // Nobody waiting
+		}
+		if err != nil {
+			return promoted, err
+		}
+		entry.Offering = *offering
+
+		var student models.Student
+		if err := s.db.Preload("Program").First(&student, entry.StudentID).Error; err != nil {
+			s.expire(&entry, "student not found")
+			continue
+		}
+		violations, err := s.rules.Evaluate(&student, semesterID, []models.Course{offering.Course})
+		if err != nil {
+			return promoted, err
+		}
+		if hasBlocking(violations[0]) {
+			s.expire(&entry, violationMessages(violations[0]))
+			continue
+		}
+
+		_, err = s.enroll(&entry, student.ProgramID)
+		if errors.Is(err, ErrSectionsFull) || errors.Is(err, ErrCourseFull) {
+			return promoted, nil // A concurrent registration took the seat, or it is in a section this student cannot join
+		}
+		if err != nil {
+			return promoted, err
+		}
+		promoted = append(promoted, entry)
+	}
+}
+
+// enroll creates (or reactivates) the enrollment of a promoted entry, marks it promoted and publishes
+// the enrollment event with waitlist.promoted
+func (s *WaitlistService) enroll(entry *models.WaitlistEntry, programID uint) (*models.Enrollment, error) {
+	now := time.Now()
+	var enrollment models.Enrollment
+	event := EventEnrollmentCreated
+
+	err := s.events.Transaction(s.db, func(tx *gorm.DB, outbox *EventOutbox) error {
+		if err := reserveSeat(tx, entry.Offering.CourseID, entry.Offering.SemesterID); err != nil {
+			return err
+		}
+		err := tx.Where("student_id = ? AND course_id = ? AND semester_id = ?",
+			entry.StudentID, entry.Offering.CourseID, entry.Offering.SemesterID).First(&enrollment).Error
+		if err == nil {
+			event = EventEnrollmentUpdated
+			enrollment.Status = "active"
+			enrollment.EnrolledAt = now
+			if err := placeEnrollment(tx, &enrollment, programID); err != nil {
+				return err
+			}
+			if err := tx.Save(&enrollment).Error; err != nil {
+				return err
+			}
+		} else {
+			enrollment = models.Enrollment{
+				StudentID:  entry.StudentID,
+				CourseID:   entry.Offering.CourseID,
+				SemesterID: entry.Offering.SemesterID,
+				Status:     "active",
+				EnrolledAt: now,
+			}
+			if err := placeEnrollment(tx, &enrollment, programID); err != nil {
+				return err
+			}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return err
+			}
+		}
+
+		entry.Status = WaitlistPromoted
+		entry.PromotedAt = &now
+		if err := tx.Model(entry).Updates(map[string]interface{}{"status": entry.Status, "promoted_at": now}).Error; err != nil {
+			return err
+		}
+		if err := outbox.Publish(event, enrollmentEventData(&enrollment)); err != nil {
+			return err
+		}
+		return outbox.Publish(EventWaitlistPromoted, waitlistEventData(entry, 0, enrollment.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+func (s *WaitlistService) expire(entry *models.WaitlistEntry, reason string) {
+	s.db.Model(entry).Updates(map[string]interface{}{"status": WaitlistExpired, "reason": reason})
+}
+
+// position is the 1-based place of a waiting entry in its offering's queue
+func (s *WaitlistService) position(entry *models.WaitlistEntry) int {
+	var ahead int64
+	s.db.Model(&models.WaitlistEntry{}).
+		Where("offering_id = ? AND status = ? AND (joined_at < ? OR (joined_at = ? AND id < ?))",
+			entry.OfferingID, WaitlistWaiting, entry.JoinedAt, entry.JoinedAt, entry.ID).
+		Count(&ahead)
+	return int(ahead) + 1
+}
+
+func (s *WaitlistService) describe(entry *models.WaitlistEntry) WaitlistPosition {
+	var waiting int64
+	s.db.Model(&models.WaitlistEntry{}).
+		Where("offering_id = ? AND status = ?", entry.OfferingID, WaitlistWaiting).
+		Count(&waiting)
+
+	return WaitlistPosition{
+		EntryID:    entry.ID,
+		CourseCode: entry.Offering.Course.Code,
+		CourseName: entry.Offering.Course.Name,
+		Semester:   entry.Offering.Semester.Name,
+		Position:   s.position(entry),
+		Waiting:    int(waiting),
+		JoinedAt:   entry.JoinedAt,
+	}
+}
+
+func (s *WaitlistService) semesterOrCurrent(semesterID uint) (uint, error) {
+	if semesterID != 0 {
+		return semesterID, nil
+	}
+	var semester models.Semester
+	if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+		return 0, errors.New("no current semester")
+	}
+	return semester.ID, nil
+}
+
+func (s *WaitlistService) findCourse(code string) (*models.Course, error) {
+	var course models.Course
+	if err := s.db.Where("code = ?", strings.ToUpper(code)).First(&course).Error; err != nil {
+		return nil, errors.New("course not found")
+	}
+	return &course, nil
+}
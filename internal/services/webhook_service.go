@@ -3,16 +3,27 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mwombeki6/mock-sims/internal/config"
 	"github.com/mwombeki6/mock-sims/internal/models"
 	"github.com/mwombeki6/mock-sims/internal/utils"
 	"gorm.io/gorm"
 )
 
+// DefaultSubscriptionID identifies the LMS endpoint configured through LMS_WEBHOOK_URL
+const DefaultSubscriptionID = "default"
+
+// maxResponseBody caps how much of a receiver's response body is kept for test fires
+const maxResponseBody = 64 * 1024
+
 type WebhookService struct {
 	db  *gorm.DB
 	cfg *config.Config
@@ -27,148 +38,181 @@ func NewWebhookService(db *gorm.DB, cfg *config.Config) *WebhookService {
 
 // WebhookPayload represents the structure of webhook notifications sent to LMS
 type WebhookPayload struct {
+	ID        string                 `json:"id"`
 	Event     string                 `json:"event"`
+	Version   int                    `json:"version"`
 	Timestamp string                 `json:"timestamp"`
+	Test      bool                   `json:"test,omitempty"` // Set on synthetic test-fire deliveries
 	Data      map[string]interface{} `json:"data"`
 }
 
-// SendEnrollmentCreated sends a webhook notification when a student is enrolled in a course
-func (s *WebhookService) SendEnrollmentCreated(enrollment *models.Enrollment) error {
-	// Build payload
-	payload := WebhookPayload{
-		Event:     "enrollment.created",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Data: map[string]interface{}{
-			"enrollment_id": enrollment.ID,
-			"student_id":    enrollment.StudentID,
-			"course_id":     enrollment.CourseID,
-			"semester_id":   enrollment.SemesterID,
-			"status":        enrollment.Status,
-			"enrolled_at":   enrollment.EnrolledAt.Format(time.RFC3339),
-		},
-	}
+// webhookTarget is a resolved delivery destination
+type webhookTarget struct {
+	SubscriptionID *uint
+	Name           string
+	URL            string
+	Secret         string
+}
 
-	return s.sendWebhook(payload)
+// DeliveryResult describes a single webhook request and the receiver's response
+type DeliveryResult struct {
+	URL          string `json:"url"`
+	StatusCode   int    `json:"status_code"`
+	LatencyMs    int64  `json:"latency_ms"`
+	ResponseBody string `json:"response_body"`
+	Signature    string `json:"signature"`
+	Error        string `json:"error,omitempty"`
 }
 
-// SendEnrollmentUpdated sends a webhook notification when enrollment status changes
-func (s *WebhookService) SendEnrollmentUpdated(enrollment *models.Enrollment) error {
-	payload := WebhookPayload{
-		Event:     "enrollment.updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Data: map[string]interface{}{
-			"enrollment_id": enrollment.ID,
-			"student_id":    enrollment.StudentID,
-			"course_id":     enrollment.CourseID,
-			"semester_id":   enrollment.SemesterID,
-			"status":        enrollment.Status,
-			"updated_at":    time.Now().UTC().Format(time.RFC3339),
-		},
+// SubscriptionInput registers an additional webhook receiver
+type SubscriptionInput struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Secret     string `json:"secret"`
+	EventTypes string `json:"event_types"` // Comma-separated patterns, e.g. "enrollment.*,grade.submitted"
+}
+
+// Deliver sends the payload to the configured LMS URL and every active subscription for the event
+func (s *WebhookService) Deliver(payload WebhookPayload) error {
+	targets, err := s.targetsFor(payload.Event)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, target := range targets {
+		if result := s.deliverTo(target, payload, 1); result.Error != "" {
+			failures = append(failures, target.URL+": "+result.Error)
+		}
 	}
 
-	return s.sendWebhook(payload)
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to send webhook: %s", strings.Join(failures, "; "))
+	}
+	return nil
 }
 
-// SendGradeSubmitted sends a webhook notification when grades are submitted
-func (s *WebhookService) SendGradeSubmitted(grade *models.Grade) error {
-	payload := WebhookPayload{
-		Event:     "grade.submitted",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Data: map[string]interface{}{
-			"grade_id":      grade.ID,
-			"enrollment_id": grade.EnrollmentID,
-			"ca_marks":      grade.CAMarks,
-			"final_exam":    grade.FinalExam,
-			"total_marks":   grade.TotalMarks,
-			"letter_grade":  grade.LetterGrade,
-			"grade_point":   grade.GradePoint,
-			"submitted_at":  grade.SubmittedAt.Format(time.RFC3339),
-		},
+// targetsFor returns every destination interested in an event type
+func (s *WebhookService) targetsFor(eventType string) ([]webhookTarget, error) {
+	var targets []webhookTarget
+
+	// Skip the built-in target if webhook URL is not configured
+	if s.cfg.LMSWebhookURL != "" {
+		targets = append(targets, s.defaultTarget())
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		if NewEventFilter(subscriptions[i].EventTypes).Matches(eventType) {
+			targets = append(targets, subscriptionTarget(&subscriptions[i]))
+		}
 	}
 
-	return s.sendWebhook(payload)
+	return targets, nil
 }
 
-// SendPaymentReceived sends a webhook notification when payment is received
-func (s *WebhookService) SendPaymentReceived(payment *models.Payment) error {
-	payload := WebhookPayload{
-		Event:     "payment.received",
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Data: map[string]interface{}{
-			"payment_id":     payment.ID,
-			"student_id":     payment.StudentID,
-			"invoice_number": payment.InvoiceNumber,
-			"amount":         payment.Amount,
-			"payment_method": payment.PaymentMethod,
-			"payment_date":   payment.PaymentDate.Format(time.RFC3339),
-			"academic_year":  payment.AcademicYear,
-			"semester":       payment.Semester,
-			"status":         payment.Status,
-		},
+func (s *WebhookService) defaultTarget() webhookTarget {
+	return webhookTarget{
+		Name:   "LMS (LMS_WEBHOOK_URL)",
+		URL:    s.cfg.LMSWebhookURL,
+		Secret: s.cfg.LMSWebhookSecret,
 	}
-
-	return s.sendWebhook(payload)
 }
 
-// sendWebhook sends an HTTP POST request with HMAC signature to LMS webhook URL
-func (s *WebhookService) sendWebhook(payload WebhookPayload) error {
-	// Skip if webhook URL is not configured
-	if s.cfg.LMSWebhookURL == "" {
-		return nil
+func subscriptionTarget(subscription *models.WebhookSubscription) webhookTarget {
+	id := subscription.ID
+	return webhookTarget{
+		SubscriptionID: &id,
+		Name:           subscription.Name,
+		URL:            subscription.URL,
+		Secret:         subscription.Secret,
 	}
+}
+
+// deliverTo sends an HTTP POST request with HMAC signature to one target and logs the attempt
+func (s *WebhookService) deliverTo(target webhookTarget, payload WebhookPayload, attempt int) DeliveryResult {
+	result := DeliveryResult{URL: target.URL}
 
 	// Marshal payload to JSON
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		result.Error = fmt.Sprintf("failed to marshal webhook payload: %v", err)
+		return result
 	}
 
 	// Generate HMAC signature
-	signature := utils.GenerateHMACSignature(payloadBytes, s.cfg.LMSWebhookSecret)
+	signature := utils.GenerateHMACSignature(payloadBytes, target.Secret)
+	result.Signature = signature
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", s.cfg.LMSWebhookURL, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequest("POST", target.URL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		result.Error = fmt.Sprintf("failed to create webhook request: %v", err)
+		return result
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-SIMS-Signature", signature)
 	req.Header.Set("X-SIMS-Event", payload.Event)
+	req.Header.Set("X-SIMS-Event-Version", strconv.Itoa(payload.Version))
+	req.Header.Set("X-SIMS-Delivery", payload.ID)
 	req.Header.Set("X-SIMS-Timestamp", payload.Timestamp)
+	req.Header.Set("X-SIMS-Attempt", strconv.Itoa(attempt))
+	if payload.Test {
+		req.Header.Set("X-SIMS-Test", "true")
+	}
 
 	// Send request
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
+	start := time.Now()
 	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
 	if err != nil {
-		s.logWebhookDelivery(payload.Event, 0, err)
-		return fmt.Errorf("failed to send webhook: %w", err)
+		s.logWebhookDelivery(target, payload, payloadBytes, attempt, 0, err)
+		result.Error = err.Error()
+		return result
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.StatusCode = resp.StatusCode
+	result.ResponseBody = string(body)
+
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		err := fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
-		s.logWebhookDelivery(payload.Event, resp.StatusCode, err)
-		return err
+		s.logWebhookDelivery(target, payload, payloadBytes, attempt, resp.StatusCode, err)
+		result.Error = err.Error()
+		return result
 	}
 
 	// Log webhook delivery
-	s.logWebhookDelivery(payload.Event, resp.StatusCode, nil)
+	s.logWebhookDelivery(target, payload, payloadBytes, attempt, resp.StatusCode, nil)
 
-	return nil
+	return result
 }
 
 // logWebhookDelivery logs webhook delivery attempt to database
-func (s *WebhookService) logWebhookDelivery(event string, statusCode int, err error) {
+func (s *WebhookService) logWebhookDelivery(target webhookTarget, payload WebhookPayload, body []byte, attempt int, statusCode int, err error) {
+	event := payload.Event
+	if payload.Test {
+		event += " (test)"
+	}
+
 	log := models.WebhookLog{
-		Event:      event,
-		URL:        s.cfg.LMSWebhookURL,
-		StatusCode: statusCode,
-		SentAt:     time.Now(),
+		Event:          event,
+		URL:            target.URL,
+		SubscriptionID: target.SubscriptionID,
+		StatusCode:     statusCode,
+		Payload:        string(body),
+		Attempt:        attempt,
+		SentAt:         time.Now(),
 	}
 
 	if err != nil {
@@ -180,34 +224,339 @@ func (s *WebhookService) logWebhookDelivery(event string, statusCode int, err er
 	s.db.Create(&log)
 }
 
-// RetryFailedWebhooks attempts to retry webhooks that failed to deliver
+// RetryFailedWebhooks resends failed deliveries with their original payload.
+// Each attempt waits twice as long as the previous one, up to WEBHOOK_MAX_ATTEMPTS.
 func (s *WebhookService) RetryFailedWebhooks() error {
 	var failedLogs []models.WebhookLog
 
-	// Find webhooks that failed in the last 24 hours
-	if err := s.db.Where("status_code >= 400 OR error IS NOT NULL").
+	// Find webhooks that failed in the last 24 hours and have not been retried yet
+	if err := s.db.Where("status_code >= 400 OR status_code = 0 OR error IS NOT NULL").
+		Where("retried_at IS NULL").
+		Where("payload <> ''").
+		Where("attempt < ?", s.maxAttempts()).
 		Where("sent_at > ?", time.Now().Add(-24*time.Hour)).
+		Where("event NOT LIKE ?", "% (test)").
 		Order("sent_at ASC").
 		Limit(100).
 		Find(&failedLogs).Error; err != nil {
 		return err
 	}
 
-	// Retry each failed webhook
+	now := time.Now()
 	for _, log := range failedLogs {
-		// Reconstruct payload from log (simplified - in production, store full payload)
-		payload := WebhookPayload{
-			Event:     log.Event,
-			Timestamp: time.Now().UTC().Format(time.RFC3339),
-			Data:      map[string]interface{}{},
+		// Back off: 1x, 2x, 4x ... the retry interval after each failed attempt
+		if now.Sub(log.SentAt) < s.retryInterval()*time.Duration(1<<uint(log.Attempt-1)) {
+			continue
 		}
 
-		// Attempt to resend
-		if err := s.sendWebhook(payload); err == nil {
-			// Mark as successfully retried
-			s.db.Model(&log).Update("status_code", 200)
+		var payload WebhookPayload
+		if err := json.Unmarshal([]byte(log.Payload), &payload); err != nil {
+			continue
 		}
+
+		target, ok := s.retryTarget(log)
+		if !ok {
+			continue
+		}
+
+		// Mark first so an overlapping sweep never sends the same attempt twice
+		s.db.Model(&log).Update("retried_at", now)
+		s.deliverTo(target, payload, log.Attempt+1)
+	}
+
+	return nil
+}
+
+// StartRetryWorker runs RetryFailedWebhooks every WEBHOOK_RETRY_INTERVAL until stop is closed
+func (s *WebhookService) StartRetryWorker(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.retryInterval())
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.RetryFailedWebhooks()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// retryTarget rebuilds the destination of a logged delivery
+func (s *WebhookService) retryTarget(log models.WebhookLog) (webhookTarget, bool) {
+	if log.SubscriptionID == nil {
+		target := s.defaultTarget()
+		target.URL = log.URL
+		return target, true
+	}
+
+	var subscription models.WebhookSubscription
+	if err := s.db.Where("is_active = ?", true).First(&subscription, *log.SubscriptionID).Error; err != nil {
+		return webhookTarget{}, false
+	}
+	return subscriptionTarget(&subscription), true
+}
+
+func (s *WebhookService) retryInterval() time.Duration {
+	seconds, err := strconv.Atoi(s.cfg.WebhookRetryInterval)
+	if err != nil || seconds <= 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (s *WebhookService) maxAttempts() int {
+	attempts, err := strconv.Atoi(s.cfg.WebhookMaxAttempts)
+	if err != nil || attempts <= 0 {
+		attempts = 5
+	}
+	return attempts
+}
+
+// ============================================================================
+// SUBSCRIPTIONS & TEST FIRE
+// ============================================================================
+
+// ListSubscriptions returns all registered webhook subscriptions
+func (s *WebhookService) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// CreateSubscription registers a new webhook receiver
+func (s *WebhookService) CreateSubscription(input SubscriptionInput) (*models.WebhookSubscription, error) {
+	if input.Name == "" || input.URL == "" {
+		return nil, errors.New("name and url are required")
+	}
+	if !strings.HasPrefix(input.URL, "http://") && !strings.HasPrefix(input.URL, "https://") {
+		return nil, errors.New("url must be http or https")
+	}
+	if input.Secret == "" {
+		secret, err := utils.GenerateRandomString(32)
+		if err != nil {
+			return nil, err
+		}
+		input.Secret = secret
+	}
+
+	subscription := models.WebhookSubscription{
+		Name:       input.Name,
+		URL:        input.URL,
+		Secret:     input.Secret,
+		EventTypes: input.EventTypes,
+		IsActive:   true,
 	}
+	if err := s.db.Create(&subscription).Error; err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
 
+// DeleteSubscription removes a webhook receiver
+func (s *WebhookService) DeleteSubscription(subscriptionID uint) error {
+	result := s.db.Delete(&models.WebhookSubscription{}, subscriptionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
 	return nil
 }
+
+// TestFire synchronously sends an event to one subscription ("default" is the configured LMS URL).
+// When data is nil a realistic payload is synthesised from seeded records.
+func (s *WebhookService) TestFire(subscriptionID string, eventType string, data map[string]interface{}) (*WebhookPayload, *DeliveryResult, error) {
+	definition, ok := LookupEvent(eventType)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown event type: %s", eventType)
+	}
+
+	target, err := s.resolveTarget(subscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if data == nil {
+		data, err = s.syntheticEventData(definition)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	payload := WebhookPayload{
+		ID:        uuid.NewString(),
+		Event:     definition.Type,
+		Version:   definition.Version,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Test:      true,
+		Data:      data,
+	}
+
+	result := s.deliverTo(target, payload, 1)
+	return &payload, &result, nil
+}
+
+// resolveTarget looks up a subscription by ID, or the configured LMS URL for "default"
+func (s *WebhookService) resolveTarget(subscriptionID string) (webhookTarget, error) {
+	if subscriptionID == DefaultSubscriptionID {
+		if s.cfg.LMSWebhookURL == "" {
+			return webhookTarget{}, errors.New("LMS_WEBHOOK_URL is not configured")
+		}
+		return s.defaultTarget(), nil
+	}
+
+	id, err := strconv.ParseUint(subscriptionID, 10, 32)
+	if err != nil {
+		return webhookTarget{}, errors.New("invalid subscription ID")
+	}
+
+	var subscription models.WebhookSubscription
+	if err := s.db.First(&subscription, id).Error; err != nil {
+		return webhookTarget{}, errors.New("subscription not found")
+	}
+
+	return subscriptionTarget(&subscription), nil
+}
+
+// syntheticEventData builds event data from the most recent seeded record of the event's entity
+func (s *WebhookService) syntheticEventData(definition *EventDefinition) (map[string]interface{}, error) {
+	notFound := fmt.Errorf("no seeded %s records to build a %s payload from", definition.Entity, definition.Type)
+
+	switch definition.Entity {
+	case "student":
+		var student models.Student
+		if err := s.db.Order("id DESC").First(&student).Error; err != nil {
+			return nil, notFound
+		}
+		if definition.Tombstone {
+			return tombstoneEventData(definition.EntityIDField, student.ID, time.Now()), nil
+		}
+		if definition.Type == EventStudentStatusChanged {
+			previous := student.EnrollmentStatus
+			student.EnrollmentStatus = "suspended"
+			return studentStatusEventData(&student, previous, "Synthetic test event"), nil
+		}
+		return studentEventData(&student), nil
+
+	case "course":
+		var course models.Course
+		if err := s.db.Order("id DESC").First(&course).Error; err != nil {
+			return nil, notFound
+		}
+		if definition.Tombstone {
+			return tombstoneEventData(definition.EntityIDField, course.ID, time.Now()), nil
+		}
+		return courseEventData(&course), nil
+
+	case "program":
+		var program models.Program
+		if err := s.db.Order("id DESC").First(&program).Error; err != nil {
+			return nil, notFound
+		}
+		return programEventData(&program), nil
+
+	case "enrollment":
+		var enrollment models.Enrollment
+		if err := s.db.Order("id DESC").First(&enrollment).Error; err != nil {
+			return nil, notFound
+		}
+		if definition.Tombstone {
+			return tombstoneEventData(definition.EntityIDField, enrollment.ID, time.Now()), nil
+		}
+		if definition.Type == EventEnrollmentUpdated {
+			enrollment.Status = "dropped"
+		}
+		return enrollmentEventData(&enrollment), nil
+
+	case "grade":
+		var grade models.Grade
+		if err := s.db.Where("letter_grade <> ''").Order("id DESC").First(&grade).Error; err != nil {
+			return nil, notFound
+		}
+		return gradeEventData(&grade), nil
+
+	case "lecture":
+		var lecture models.Lecture
+		if err := s.db.Order("id DESC").First(&lecture).Error; err != nil {
+			return nil, notFound
+		}
+		previous := lecture
+		previous.DayOfWeek = "Monday"
+		previous.StartTime = "08:00"
+		previous.EndTime = "10:00"
+		return lectureEventData(&lecture, &previous), nil
+
+	case "course_assignment":
+		var assignment models.CourseAssignment
+		if err := s.db.Order("id DESC").First(&assignment).Error; err != nil {
+			return nil, notFound
+		}
+		return courseAssignmentEventData(&assignment, "assigned"), nil
+
+	case "semester":
+		var semester models.Semester
+		if err := s.db.Where("is_current = ?", true).First(&semester).Error; err != nil {
+			return nil, notFound
+		}
+		return semesterEventData(&semester, 0), nil
+
+	case "waitlist":
+		var entry models.WaitlistEntry
+		if err := s.db.Preload("Offering").Order("id DESC").First(&entry).Error; err != nil {
+			return nil, notFound
+		}
+		if definition.Type == EventWaitlistPromoted {
+			now := time.Now()
+			entry.Status = "promoted"
+			entry.PromotedAt = &now
+			return waitlistEventData(&entry, 0, 0), nil
+		}
+		entry.Status = "waiting"
+		return waitlistEventData(&entry, 1, 0), nil
+
+	case "offering":
+		var workflow models.ResultsWorkflow
+		if err := s.db.Preload("Offering.Course").Order("id DESC").First(&workflow).Error; err != nil {
+			return nil, notFound
+		}
+		if workflow.PublishedAt == nil {
+			now := time.Now()
+			workflow.PublishedAt = &now
+		}
+		var grades int64
+		s.db.Model(&models.Grade{}).Where("offering_id = ? AND letter_grade <> ''", workflow.OfferingID).Count(&grades)
+		return resultsPublishedEventData(&workflow, int(grades)), nil
+
+	case "attendance":
+		var enrollment models.Enrollment
+		if err := s.db.Preload("Student").Preload("Course").Where("offering_id IS NOT NULL").
+			Order("id DESC").First(&enrollment).Error; err != nil {
+			return nil, notFound
+		}
+		attendance := NewAttendanceService(s.db, s.cfg)
+		summary, err := attendance.summary(&enrollment)
+		if err != nil {
+			return nil, err
+		}
+		summary.ExamEligible = false
+		return attendanceEventData(summary, attendance.threshold), nil
+
+	case "payment":
+		var payment models.Payment
+		if err := s.db.Order("id DESC").First(&payment).Error; err != nil {
+			return nil, notFound
+		}
+		if definition.Tombstone {
+			return tombstoneEventData(definition.EntityIDField, payment.ID, time.Now()), nil
+		}
+		return paymentEventData(&payment), nil
+	}
+
+	return nil, notFound
+}